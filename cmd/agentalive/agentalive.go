@@ -20,6 +20,9 @@ limitations under the License.
 * Transfer agent is running or not. The program reads the PID
 * of agent JVM from from agent.pid file and verifies if it is
 * running or not. Returns true if the process is alive else false.
+* If runagent serves health endpoints in the container, the program
+* queries /livez instead and checks the agent directly only if the
+* endpoint can not be reached.
 * Based on the return value of this probe, a container orchestration
 * platform like Kubernetes can recycle an agent.
  */
@@ -41,6 +44,7 @@ const AGENT_ALIV_EXIT_CODE_3 = 3
 const AGENT_ALIV_EXIT_CODE_4 = 4
 const AGENT_ALIV_EXIT_CODE_5 = 5
 const AGENT_ALIV_EXIT_CODE_6 = 6
+const AGENT_ALIV_EXIT_CODE_7 = 7
 
 /*
 * Main entry point to liveness probe
@@ -58,6 +62,15 @@ func main() {
 		os.Exit(AGENT_ALIV_EXIT_CODE_1)
 	}
 
+	// Ask runagent first. Older runagent versions do not serve the endpoint.
+	if live, status, err := utils.QueryHealthEndpoint(utils.HEALTH_PATH_LIVE); err == nil {
+		if live {
+			os.Exit(AGENT_ALIV_EXIT_CODE_0)
+		}
+		utils.PrintLog(fmt.Sprintf(utils.AGENT_ALIV_NOT_LIVE_4005, agentNameEnv, status))
+		os.Exit(AGENT_ALIV_EXIT_CODE_7)
+	}
+
 	/*
 	 * Read the name of an agent configuration file from environment
 	 * variable MFT_AGENT_CONFIG_FILE
@@ -70,6 +83,9 @@ func main() {
 
 	// Read agent configuration data from JSON file.
 	agentConfig, e = utils.ReadConfigurationDataFromFile(bfgConfigFilePath)
+	if e == nil {
+		agentConfig, e = utils.ResolveConfigurationReferences(agentConfig)
+	}
 	// Exit if we had any error when reading configuration file
 	if e != nil {
 		utils.PrintLog(fmt.Sprintf(utils.AGENT_ALIV_ENV_CFG_FILE_READ_4003, bfgConfigFilePath, e))
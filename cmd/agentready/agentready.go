@@ -33,6 +33,7 @@ const AGENT_REDY_EXIT_CODE_4 = 4
 const AGENT_REDY_EXIT_CODE_5 = 5
 const AGENT_REDY_EXIT_CODE_6 = 6
 const AGENT_REDY_EXIT_CODE_7 = 7
+const AGENT_REDY_EXIT_CODE_8 = 8
 
 /*
 * This file contains the source code for the readiness probe. The
@@ -40,6 +41,9 @@ const AGENT_REDY_EXIT_CODE_7 = 7
 * Transfer agent is ready or not. The program scans the output0.log
 * of agent for BFGAG0059I and BFGAG0191I events. Returns true if
 * any of the above events are found in the log file else false.
+* If runagent serves health endpoints in the container, the program
+* queries /readyz instead and scans the log file only if the endpoint
+* can not be reached.
 * Based on the return value of this probe, a container orchestration
 * platform like Kubernetes can recycle an agent.
  */
@@ -56,6 +60,15 @@ func main() {
 		os.Exit(AGENT_REDY_EXIT_CODE_1)
 	}
 
+	// Ask runagent first. Older runagent versions do not serve the endpoint.
+	if ready, status, err := utils.QueryHealthEndpoint(utils.HEALTH_PATH_READY); err == nil {
+		if ready {
+			os.Exit(AGENT_REDY_EXIT_CODE_0)
+		}
+		utils.PrintLog(fmt.Sprintf(utils.AGENT_REDY_NOT_READY_3006, agentNameEnv, status))
+		os.Exit(AGENT_REDY_EXIT_CODE_8)
+	}
+
 	/*
 	 * Read the name of an agent configuration file from environment
 	 * variable MFT_AGENT_CONFIG_FILE
@@ -68,6 +81,9 @@ func main() {
 
 	// Read agent configuration data from JSON file.
 	agentConfig, e = utils.ReadConfigurationDataFromFile(bfgConfigFilePath)
+	if e == nil {
+		agentConfig, e = utils.ResolveConfigurationReferences(agentConfig)
+	}
 	// Exit if we had any error when reading configuration file
 	if e != nil {
 		utils.PrintLog(fmt.Sprintf(utils.AGENT_REDY_ENV_CFG_FILE_READ_3003, bfgConfigFilePath, e))
@@ -214,6 +214,9 @@ func getLogPath(logFilePath string) string {
 			if bfgConfigFilePathSet {
 				// Read agent configuration data from JSON file.
 				agentConfig, e = utils.ReadConfigurationDataFromFile(bfgConfigFilePath)
+				if e == nil {
+					agentConfig, e = utils.ResolveConfigurationReferences(agentConfig)
+				}
 				// Exit if we had any error when reading configuration file
 				if e != nil {
 					fmt.Print(e)
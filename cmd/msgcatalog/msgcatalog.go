@@ -0,0 +1,225 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+/*
+ * Generates documentation of the messages of the container from the message
+ * catalog, a template of a translated catalog, or the table of the names of
+ * the variables holding the messages, read from the Go file declaring them.
+ *
+ * Usage: msgcatalog [--format markdown|json|names] [--lang <language>] [--input <file>] [--output <file>]
+ */
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
+	flag "github.com/spf13/pflag"
+)
+
+const FORMAT_MARKDOWN = "markdown"
+const FORMAT_JSON = "json"
+const FORMAT_NAMES = "names"
+
+// Function the messages are declared with
+const messageFunction = "message"
+
+// Header of the generated Go source
+const sourceHeader = `/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+`
+
+// Sections of the documentation, by the first number of their messages
+var catalogSections = []struct {
+	first int
+	title string
+}{
+	{0, "Messages of runagent"},
+	{3000, "Messages of agentready"},
+	{4000, "Messages of agentalive"},
+}
+
+// Names of the severities of messages
+var severityNames = map[string]string{
+	utils.MESSAGE_SEVERITY_INFO:    "Information",
+	utils.MESSAGE_SEVERITY_WARNING: "Warning",
+	utils.MESSAGE_SEVERITY_ERROR:   "Error",
+}
+
+// Main entry point of the program
+func main() {
+	var format string
+	var lang string
+	var input string
+	var output string
+	flag.StringVar(&format, "format", FORMAT_MARKDOWN, "Format of the output: markdown, json or names")
+	flag.StringVar(&lang, "lang", utils.MESSAGE_LANG_DEFAULT, "Language of the messages")
+	flag.StringVar(&input, "input", "", "Go file declaring the messages, read to generate the table of their names")
+	flag.StringVar(&output, "output", "", "File the output is written to, instead of standard output")
+	flag.Parse()
+
+	catalog := utils.TranslatedMessageCatalog(lang)
+	var data []byte
+	var err error
+	switch format {
+	case FORMAT_MARKDOWN:
+		data = generateMarkdown(catalog)
+	case FORMAT_JSON:
+		data, err = generateTemplate(catalog)
+	case FORMAT_NAMES:
+		data, err = generateNames(input)
+	default:
+		err = fmt.Errorf("format %s is not supported", format)
+	}
+	if err == nil {
+		if len(output) > 0 {
+			err = os.WriteFile(output, data, 0644)
+		} else {
+			_, err = os.Stdout.Write(data)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// Returns the documentation of the messages in markdown
+func generateMarkdown(catalog []utils.CatalogMessage) []byte {
+	var doc bytes.Buffer
+	doc.WriteString("# Messages of the container\n\n")
+	doc.WriteString("<!-- Generated from pkg/utils/messages.go by cmd/msgcatalog. Do not edit. -->\n\n")
+	doc.WriteString("Every message displayed by the container starts with its ID, for example `MFTC0013E`. " +
+		"The last letter of the ID is the severity of the message: `I` for information, `W` for warning and `E` for error. " +
+		"IDs do not change between releases, so they can be used to search and alert on messages. " +
+		"When messages are logged in JSON format, the ID is also logged in the `messageId` field, " +
+		"the name of the message in the `messageName` field, " +
+		"and the values reported by the message in the `fields` object, under the names listed for the message.\n\n")
+	doc.WriteString("Messages are displayed in the language selected by the `LANG` environment variable when a translated catalog exists for it, " +
+		"and in English otherwise. See [pkg/utils/catalog](../pkg/utils/catalog/README.md).\n\n")
+	doc.WriteString("`%s`, `%v` and `%d` in the text of a message are replaced by the values it reports.\n")
+
+	section := -1
+	for _, m := range catalog {
+		number, _ := strconv.Atoi(strings.TrimPrefix(m.ID, utils.MESSAGE_ID_PREFIX)[:4])
+		for section+1 < len(catalogSections) && number >= catalogSections[section+1].first {
+			section++
+			doc.WriteString("\n## " + catalogSections[section].title + "\n")
+		}
+		fmt.Fprintf(&doc, "\n### %s\n\n", m.ID)
+		fmt.Fprintf(&doc, "%s\n\n", escapeMarkdown(m.Text))
+		fmt.Fprintf(&doc, "**Name:** `%s`\n\n", m.Name)
+		fmt.Fprintf(&doc, "**Severity:** %s\n\n", severityNames[m.Severity])
+		fmt.Fprintf(&doc, "**Explanation:** %s\n\n", escapeMarkdown(m.Explanation))
+		fmt.Fprintf(&doc, "**User action:** %s\n", escapeMarkdown(m.UserAction))
+		if len(m.Fields) > 0 {
+			fmt.Fprintf(&doc, "\n**Fields:** `%s`\n", strings.Join(m.Fields, "`, `"))
+		}
+	}
+	return doc.Bytes()
+}
+
+// Returns a template of a translated catalog holding the messages
+func generateTemplate(catalog []utils.CatalogMessage) ([]byte, error) {
+	translations := make(map[string]utils.MessageTranslation, len(catalog))
+	for _, m := range catalog {
+		translations[m.ID] = utils.MessageTranslation{
+			Text:        m.Text,
+			Explanation: m.Explanation,
+			UserAction:  m.UserAction,
+		}
+	}
+	data, err := json.MarshalIndent(translations, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Returns the Go source of the table of the names of the variables holding the
+// messages declared in a file, by message ID
+func generateNames(file string) ([]byte, error) {
+	parsed, err := parser.ParseFile(token.NewFileSet(), file, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	var source bytes.Buffer
+	source.WriteString(sourceHeader)
+	fmt.Fprintf(&source, "// Code generated by cmd/msgcatalog from %s. DO NOT EDIT.\n\n", filepath.Base(file))
+	fmt.Fprintf(&source, "package %s\n\n", parsed.Name.Name)
+	source.WriteString("// Names of the variables holding the messages of the container, by message ID\n")
+	source.WriteString("var messageNames = map[string]string{\n")
+	for _, decl := range parsed.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			value := spec.(*ast.ValueSpec)
+			for i, name := range value.Names {
+				if i >= len(value.Values) {
+					break
+				}
+				call, ok := value.Values[i].(*ast.CallExpr)
+				if !ok || len(call.Args) == 0 {
+					continue
+				}
+				if function, ok := call.Fun.(*ast.Ident); !ok || function.Name != messageFunction {
+					continue
+				}
+				if id, ok := call.Args[0].(*ast.BasicLit); ok && id.Kind == token.STRING {
+					fmt.Fprintf(&source, "%s: %q,\n", id.Value, name.Name)
+				}
+			}
+		}
+	}
+	source.WriteString("}\n")
+	return format.Source(source.Bytes())
+}
+
+// Escape the characters of a text that markdown would interpret
+var escapeMarkdown = strings.NewReplacer(
+	"<", "&lt;",
+	">", "&gt;",
+	"*", "\\*",
+	"[", "\\[",
+	"]", "\\]",
+	"\n", "<br>",
+).Replace
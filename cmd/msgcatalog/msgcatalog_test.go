@@ -0,0 +1,154 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
+)
+
+func TestMessageCatalog(t *testing.T) {
+	idPattern := regexp.MustCompile(`^MFTC[0-9]{4}[IWE]$`)
+	verbPattern := regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z]`)
+	fieldPattern := regexp.MustCompile(`^[a-z][a-zA-Z]*$`)
+	ids := make(map[string]bool)
+	numbers := make(map[string]bool)
+	for _, m := range utils.MessageCatalog() {
+		if !idPattern.MatchString(m.ID) {
+			t.Errorf("ID %s is not valid", m.ID)
+			continue
+		}
+		if ids[m.ID] || numbers[m.ID[:8]] {
+			t.Errorf("Number of message %s is not unique", m.ID)
+		}
+		ids[m.ID] = true
+		numbers[m.ID[:8]] = true
+		if len(strings.TrimSpace(m.Text)) == 0 || len(m.Explanation) == 0 || len(m.UserAction) == 0 {
+			t.Errorf("Message %s is missing its text, explanation or user action", m.ID)
+		}
+		if strings.HasPrefix(m.Text, "IBMFT") || strings.HasPrefix(m.Text, utils.MESSAGE_ID_PREFIX) {
+			t.Errorf("Text of message %s must not contain an ID", m.ID)
+		}
+		// Every value of a message is named, so that it can be indexed
+		if verbs := len(verbPattern.FindAllString(m.Text, -1)); len(m.Fields) != verbs {
+			t.Errorf("Message %s reports %d values but names %d", m.ID, verbs, len(m.Fields))
+		}
+		names := make(map[string]bool)
+		for _, name := range m.Fields {
+			if !fieldPattern.MatchString(name) || names[name] {
+				t.Errorf("Field %s of message %s is not valid or not unique", name, m.ID)
+			}
+			names[name] = true
+		}
+	}
+	if msg := utils.MFT_CONT_CFG_FILE_READ_0013; !strings.HasPrefix(msg, "MFTC0013E: ") {
+		t.Errorf("Expected the message to be displayed with its ID, got %s", msg)
+	}
+}
+
+// docs/messages.md must be generated again when the catalog changes
+func TestMessageDocumentation(t *testing.T) {
+	doc, err := os.ReadFile("../../docs/messages.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(doc, generateMarkdown(utils.MessageCatalog())) {
+		t.Error("docs/messages.md is out of date. Run go generate ./pkg/utils")
+	}
+}
+
+// The table of the names of the messages must be generated again when the
+// catalog changes
+func TestMessageNames(t *testing.T) {
+	source, err := os.ReadFile("../../pkg/utils/messagenames.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, err := generateNames("../../pkg/utils/messages.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(source, generated) {
+		t.Error("pkg/utils/messagenames.go is out of date. Run go generate ./pkg/utils")
+	}
+	names := make(map[string]bool)
+	for _, m := range utils.MessageCatalog() {
+		if len(m.Name) == 0 || names[m.Name] {
+			t.Errorf("Name %q of message %s is not valid or not unique", m.Name, m.ID)
+		}
+		names[m.Name] = true
+	}
+}
+
+func TestTranslationTemplate(t *testing.T) {
+	catalog := utils.MessageCatalog()
+	data, err := generateTemplate(catalog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var translations map[string]utils.MessageTranslation
+	if err := json.Unmarshal(data, &translations); err != nil {
+		t.Fatal(err)
+	}
+	if len(translations) != len(catalog) || translations[catalog[0].ID].Text != catalog[0].Text {
+		t.Errorf("Unexpected template %s", data)
+	}
+	// Messages of a language without a catalog are in English
+	if translated := utils.TranslatedMessageCatalog(utils.ResolveLanguage("vi_VN")); !reflect.DeepEqual(translated[0], catalog[0]) {
+		t.Errorf("Expected message %v, got %v", catalog[0], translated[0])
+	}
+}
+
+// Every message has a French translation, which is displayed when LANG selects
+// French
+func TestFrenchCatalog(t *testing.T) {
+	if os.Getenv("MSGCATALOG_TEST_PRINT") == "1" {
+		fmt.Print(utils.MFT_CONT_CFG_FILE_READ_0013)
+		return
+	}
+	lang := utils.ResolveLanguage("fr_FR.UTF-8")
+	catalog := utils.MessageCatalog()
+	translated := utils.TranslatedMessageCatalog(lang)
+	for i, m := range translated {
+		if m.ID != catalog[i].ID || m.Severity != catalog[i].Severity || !reflect.DeepEqual(m.Fields, catalog[i].Fields) {
+			t.Errorf("Unexpected translation %v of message %v", m, catalog[i])
+		}
+		// A translation whose text does not have the verbs of the English text
+		// is not used, so the message would be in English
+		if m.Explanation == catalog[i].Explanation || m.UserAction == catalog[i].UserAction {
+			t.Errorf("Message %s is not translated", m.ID)
+		}
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFrenchCatalog$")
+	cmd.Env = append(os.Environ(), "MSGCATALOG_TEST_PRINT=1", "LANG=fr_FR.UTF-8")
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "MFTC0013E: Une erreur s'est produite lors de la lecture du fichier de configuration [%s]."; !strings.HasPrefix(string(output), expected) {
+		t.Errorf("Expected %q, got %q", expected, output)
+	}
+}
@@ -16,12 +16,11 @@ limitations under the License.
 package main
 
 import (
-	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
-	"os/exec"
 	"os/user"
 	"path/filepath"
 	"strconv"
@@ -34,93 +33,49 @@ import (
 )
 
 // Call fteStartAgent command to submit a request to start an agent.
-func StartAgent(agentName string, coordinationQMgr string) bool {
-	var outb, errb bytes.Buffer
+func StartAgent(ctx context.Context, agentName string, coordinationQMgr string) bool {
 	var startSubmitted bool = false
 
-	// Get the path of MFT fteStartAgent command.
-	cmdStrAgntPath, lookPathErr := exec.LookPath("fteStartAgent")
-	if lookPathErr == nil {
-		// We are done with creating agent. Start it now.
-		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_STARTING_0041, agentName))
-		var cmdArgs []string
-		cmdArgs = append(cmdArgs, cmdStrAgntPath, "-p", coordinationQMgr, agentName)
-		if commandTracingEnabled {
-			cmdArgs = append(cmdArgs, "-trace", "com.ibm.wmqfte=all")
-			cmdTracePath := GetCommandTracePath()
-			if len(cmdTracePath) > 0 {
-				cmdArgs = append(cmdArgs, "-tracePath", cmdTracePath)
-			}
-		}
-
-		cmdStrAgnt := &exec.Cmd{
-			Path: cmdStrAgntPath,
-			Args: cmdArgs,
-		}
-
-		cmdStrAgnt.Stdout = &outb
-		cmdStrAgnt.Stderr = &errb
-		// Run fteStartAgent command. Log and exit in case of any error.
-		if err := cmdStrAgnt.Run(); err != nil {
-			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_ERROR_0042, outb.String(), errb.String()))
-		} else {
-			if logLevel >= LOG_LEVEL_VERBOSE {
-				utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_INFO_0043, outb.String()))
-			}
-			startSubmitted = true
-		}
+	// We are done with creating agent. Start it now.
+	utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_STARTING_0041, agentName))
+	// Run fteStartAgent command. Log and exit in case of any error.
+	result := runMFTStep(ctx, STEP_START_AGENT, "fteStartAgent", "-p", coordinationQMgr, agentName)
+	if result.NotFound() {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_NOT_FOUND_0028, result.Err))
+	} else if result.Err != nil {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_ERROR_0042, result.Stdout, result.Stderr))
 	} else {
-		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_NOT_FOUND_0028, lookPathErr))
+		if logLevel >= LOG_LEVEL_VERBOSE {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_INFO_0043, result.Stdout))
+		}
+		startSubmitted = true
 	}
 	return startSubmitted
 }
 
 // Verify the status of agent by calling fteListAgents command.
 func VerifyAgentStatus(coordinationQMgr string, agentName string) string {
-	var outb, errb bytes.Buffer
 	var agentStatus string
 
 	utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_VRFY_STATUS_0044, agentName))
-	cmdListAgentPath, lookPathErr := exec.LookPath("fteListAgents")
-	if lookPathErr == nil {
-		var cmdArgs []string
-		cmdArgs = append(cmdArgs, cmdListAgentPath, "-p", coordinationQMgr, agentName)
-		if commandTracingEnabled {
-			cmdArgs = append(cmdArgs, "-trace", "com.ibm.wmqfte=all")
-			cmdTracePath := GetCommandTracePath()
-			if len(cmdTracePath) > 0 {
-				cmdArgs = append(cmdArgs, "-tracePath", cmdTracePath)
-			}
-		}
-
-		cmdListAgents := &exec.Cmd{
-			Path: cmdListAgentPath,
-			Args: cmdArgs,
-		}
-
-		cmdListAgents.Stdout = &outb
-		cmdListAgents.Stderr = &errb
-		// Execute and get the output of the command into a byte buffer
-		if err := cmdListAgents.Run(); err != nil {
-			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_ERROR_0042, outb.String(), errb.String()))
-		} else {
-			if logLevel >= LOG_LEVEL_VERBOSE {
-				utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_INFO_0043, outb.String()))
-			}
-			// Now parse the output of fteListAgents command and take appropriate actions.
-			agentStatus = outb.String()
-		}
+	result := runMFTCommand("fteListAgents", "-p", coordinationQMgr, agentName)
+	if result.NotFound() {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_NOT_FOUND_0028, result.Err))
+	} else if result.Err != nil {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_ERROR_0042, result.Stdout, result.Stderr))
 	} else {
-		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_NOT_FOUND_0028, lookPathErr))
+		if logLevel >= LOG_LEVEL_VERBOSE {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_INFO_0043, result.Stdout))
+		}
+		// Now parse the output of fteListAgents command and take appropriate actions.
+		agentStatus = result.Stdout
 	}
 
 	return agentStatus
 }
 
 // Calls fteCreateAgent/fteCreateBridgeAgent commands to setup agent configuration
-func SetupAgent(agentConfig string, bfgDataPath string, coordinationQMgr string) bool {
-	// Variables for Stdout and Stderr
-	var outb, errb bytes.Buffer
+func SetupAgent(ctx context.Context, agentConfig string, bfgDataPath string, coordinationQMgr string) bool {
 	var created bool = false
 	var cmdSetup bool = false
 	var agentType string = AGENT_TYPE_STANDARD
@@ -148,7 +103,7 @@ func SetupAgent(agentConfig string, bfgDataPath string, coordinationQMgr string)
 	agentName = gjson.Get(agentConfig, "name").String()
 	utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_CREATING_0046, agentType, agentName))
 
-	var cmdCrtAgnt *exec.Cmd
+	var cmdName string
 	// Cance the agent attributes
 	agentQMgrName := gjson.Get(agentConfig, "qmgrName").String()
 	agentQMgrHost := gjson.Get(agentConfig, "qmgrHost").String()
@@ -168,92 +123,47 @@ func SetupAgent(agentConfig string, bfgDataPath string, coordinationQMgr string)
 		agentQMgrChannel = "SYSTEM.DEF.SVRCONN"
 	}
 
+	params := []string{
+		"-p", coordinationQMgr,
+		"-agentName", agentName,
+		"-agentQMgr", agentQMgrName,
+		"-agentQMgrHost", agentQMgrHost,
+		"-agentQMgrPort", agentQMgrPort,
+		"-agentQMgrChannel", agentQMgrChannel, "-f"}
+
 	// We are creating a STANDARD agent
 	if standardAgent {
-		// Get the path of MFT fteCreateAgent command.
-		cmdCrtAgntPath, lookPathErr := exec.LookPath("fteCreateAgent")
-		if lookPathErr == nil {
-			// Creating a standard agent
-			var params []string
-			params = append(params, cmdCrtAgntPath,
-				"-p", coordinationQMgr,
-				"-agentName", agentName,
-				"-agentQMgr", agentQMgrName,
-				"-agentQMgrHost", agentQMgrHost,
-				"-agentQMgrPort", agentQMgrPort,
-				"-agentQMgrChannel", agentQMgrChannel, "-f")
-			if commandTracingEnabled {
-				params = append(params, "-trace", "com.ibm.wmqfte=all")
-				cmdTracePath := GetCommandTracePath()
-				if len(cmdTracePath) > 0 {
-					params = append(params, "-tracePath", cmdTracePath)
-				}
-			}
-
-			// Now build the command to create standard agent
-			cmdCrtAgnt = &exec.Cmd{Path: cmdCrtAgntPath, Args: params}
-			cmdSetup = true
-		} else {
-			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_NOT_FOUND_0028, lookPathErr))
-		}
+		cmdName = "fteCreateAgent"
+		cmdSetup = true
 	} else {
 		// Initialize BridgeProperties.
 		BuildBridgePropertyList()
 
 		// We are creating a BRIDGE agent
-		// Get the path of MFT fteCreateBridgeAgent command
-		cmdCrtBridgeAgntPath, lookPathErr := exec.LookPath("fteCreateBridgeAgent")
-		if lookPathErr == nil {
-			// Creating a bridge agent
-			var params []string
-			params = append(params, cmdCrtBridgeAgntPath,
-				"-p", coordinationQMgr,
-				"-agentName", agentName,
-				"-agentQMgr", agentQMgrName,
-				"-agentQMgrHost", agentQMgrHost,
-				"-agentQMgrPort", agentQMgrPort,
-				"-agentQMgrChannel", agentQMgrChannel, "-f")
-			if commandTracingEnabled {
-				params = append(params, "-trace", "com.ibm.wmqfte=all")
-				cmdTracePath := GetCommandTracePath()
-				if len(cmdTracePath) > 0 {
-					params = append(params, "-tracePath", cmdTracePath)
-				}
-			}
-
-			protocolBridgeConfigs = gjson.Get(agentConfig, "protocolServers").Array()
-			// For creating the agent, take the first element in the array. We will updated the
-			// ProtocolBridgeProperties.xml with other elements in the array.
-			if len(protocolBridgeConfigs) > 0 {
-				singleBridgeConfig := protocolBridgeConfigs[0].String()
-				if updateBridgeParameters(singleBridgeConfig, params) {
-					// Now build the command to create a bridge agent
-					cmdCrtAgnt = &exec.Cmd{
-						Path: cmdCrtBridgeAgntPath,
-						Args: params,
-					}
-					cmdSetup = true
-				} else {
-					utils.PrintLog(utils.MFT_CONT_BRIDGE_NOT_ENOUGH_INFO)
-				}
-			} else {
+		cmdName = "fteCreateBridgeAgent"
+		protocolBridgeConfigs = gjson.Get(agentConfig, "protocolServers").Array()
+		// For creating the agent, take the first element in the array. We will updated the
+		// ProtocolBridgeProperties.xml with other elements in the array.
+		if len(protocolBridgeConfigs) > 0 {
+			singleBridgeConfig := protocolBridgeConfigs[0].String()
+			params, cmdSetup = updateBridgeParameters(singleBridgeConfig, params)
+			if !cmdSetup {
 				utils.PrintLog(utils.MFT_CONT_BRIDGE_NOT_ENOUGH_INFO)
 			}
 		} else {
-			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_NOT_FOUND_0028, lookPathErr))
+			utils.PrintLog(utils.MFT_CONT_BRIDGE_NOT_ENOUGH_INFO)
 		}
 	}
 
 	// Ready to execute the command
 	if cmdSetup == true {
-		// Reuse the same buffer
-		cmdCrtAgnt.Stdout = &outb
-		cmdCrtAgnt.Stderr = &errb
-
 		// Execute the fteCreateAgent/fteCreateBridgeAgent to create agent configuration.
 		// Log an error an exit in case of any error.
-		if err := cmdCrtAgnt.Run(); err != nil {
-			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_ERROR_0042, outb.String(), errb.String()))
+		result := runMFTStep(ctx, STEP_CREATE_AGENT, cmdName, params...)
+		if result.NotFound() {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_NOT_FOUND_0028, result.Err))
+		} else if result.Err != nil {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_ERROR_0042, result.Stdout, result.Stderr))
 		} else {
 			// If it is bridge agent, then update the ProtocolBridgeProperties file with any additional properties specified.
 			if !standardAgent {
@@ -270,88 +180,103 @@ func SetupAgent(agentConfig string, bfgDataPath string, coordinationQMgr string)
 				utils.CopyFile(MOVEFILE_CUSTOM_EXIT, standardAgentCustExit)
 			}
 
-			// Configuration file has not been provided. Make an attempt to read UID/PWD from agent configuration JSON file and create
-			// the MQMFTCredentials file place it in agent's config directory. The credentials file will be encrypted using a fixed key.
-			agentCredFilePath := bfgDataPath + MFT_CONFIG_PATH_SUFFIX + coordinationQMgr + MFT_AGENTS_SLASH + agentName + MFT_AGENT_CRED_SLASH
-			if logLevel >= LOG_LEVEL_VERBOSE {
-				utils.PrintLog("Agent credentials file path " + agentCredFilePath)
-			}
+			// Write agent properties, credentials and sandbox or protocol bridge configuration
+			created = ConfigureAgent(agentConfig, bfgDataPath, bfgDataPath, coordinationQMgr, standardAgent, false)
+		}
+	}
+	return created
+}
 
-			// Update coordination properties file with additional attributes specified.
-			agentPropertiesFile := bfgDataPath + MFT_CONFIG_PATH_SUFFIX + coordinationQMgr + MFT_AGENTS_SLASH + agentName + MFT_AGENT_PROPS_SLASH
-			protocolBridgePropertiesFile := bfgDataPath + MFT_CONFIG_PATH_SUFFIX + coordinationQMgr + MFT_AGENTS_SLASH + agentName + MFT_PBA_PROPS_SLASH
-
-			// Start XML document for credentials file
-			credentialsDoc := InitializeCredentialsDocumentWriter()
-			// Configure TLS for agent connections
-			created, agentConfig = configTLSAgent(agentConfig, credentialsDoc, agentCredFilePath)
-
-			if created {
-				if gjson.Get(agentConfig, "qmgrCredentials").Exists() {
-					// Write agent queue manager credentials
-					err := UpdateXmlWithQmgrCredentials(credentialsDoc, gjson.Get(agentConfig, "qmgrCredentials").String(), agentQMgrName)
-					if err != nil {
-						if logLevel >= LOG_LEVEL_VERBOSE {
-							utils.PrintLog(err.Error())
-						}
-					}
-				}
+// Create the credentials file, sandbox or protocol bridge properties and update agent
+// properties file with additional attributes specified. Files are written under outputRoot
+// while the paths referenced from them are relative to bfgDataPath.
+func ConfigureAgent(agentConfig string, bfgDataPath string, outputRoot string, coordinationQMgr string, standardAgent bool, dryRun bool) bool {
+	var created bool = false
+	agentName := gjson.Get(agentConfig, "name").String()
+	agentQMgrName := gjson.Get(agentConfig, "qmgrName").String()
+	agentConfigPath := MFT_CONFIG_PATH_SUFFIX + coordinationQMgr + MFT_AGENTS_SLASH + agentName
 
-				// Create credentials file for agent.
-				errorSetCred := SetupCredentials(agentCredFilePath, credentialsDoc.XMLPretty())
-				if errorSetCred == nil {
-					// Attempt to encrypt the credentials file with a fixed key
-					EncryptCredentialsFile(agentCredFilePath)
-					agentConfig, _ = sjson.Set(agentConfig, "additionalProperties.agentQMgrAuthenticationCredentialsFile", agentCredFilePath)
-				} else {
-					utils.PrintLog(errorSetCred.Error())
-				}
+	// Configuration file has not been provided. Make an attempt to read UID/PWD from agent configuration JSON file and create
+	// the MQMFTCredentials file place it in agent's config directory. The credentials file will be encrypted using a fixed key.
+	agentCredFilePath := bfgDataPath + agentConfigPath + MFT_AGENT_CRED_SLASH
+	if logLevel >= LOG_LEVEL_VERBOSE {
+		utils.PrintLog("Agent credentials file path " + agentCredFilePath)
+	}
 
-				if logLevel >= LOG_LEVEL_VERBOSE && len(agentConfig) > 0 {
-					utils.PrintLog(fmt.Sprintf("Updated agent configuration - %v", agentConfig))
-				}
+	// Update coordination properties file with additional attributes specified.
+	agentPropertiesFile := outputRoot + agentConfigPath + MFT_AGENT_PROPS_SLASH
+	protocolBridgePropertiesFile := outputRoot + agentConfigPath + MFT_PBA_PROPS_SLASH
+
+	// Start XML document for credentials file
+	credentialsDoc := InitializeCredentialsDocumentWriter()
+	// Configure TLS for agent connections
+	created, agentConfig = configTLSAgent(agentConfig, credentialsDoc, agentCredFilePath, dryRun)
 
-				// Update UserSandbox XML file - valid only for STANDARD agents
-				if standardAgent {
-					errCusbox := CreateUserSandbox(bfgDataPath + MFT_CONFIG_PATH_SUFFIX + coordinationQMgr + MFT_AGENTS_SLASH + agentName + MFT_USER_SANDBOX_SLASH)
-					if errCusbox != nil {
-						utils.PrintLog(errCusbox.Error())
-						created = false
-					}
-				} else {
-					// This is a bridge agent. We need to update the ProtocolBridgeProperties.xml file for all other servers specified
-					// in configuration JSON file.
-					created = updateProtocolBridgePropertiesFile(protocolBridgePropertiesFile, agentConfig)
+	if created {
+		if gjson.Get(agentConfig, "qmgrCredentials").Exists() {
+			// Write agent queue manager credentials
+			err := UpdateXmlWithQmgrCredentials(credentialsDoc, gjson.Get(agentConfig, "qmgrCredentials").String(), agentQMgrName)
+			if err != nil {
+				if logLevel >= LOG_LEVEL_VERBOSE {
+					utils.PrintLog(err.Error())
 				}
 			}
+		}
 
-			if created {
-				// Update agent properties file with additional attributes specified.
-				created = UpdateAgentProperties(agentPropertiesFile, agentConfig, "additionalProperties", !standardAgent)
-				if created {
-					// Tell user that agent has been configured.
-					utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_CREATED_0047, agentName))
-				}
+		// Create credentials file for agent.
+		errorSetCred := WriteCredentialsFile(outputRoot+agentConfigPath+MFT_AGENT_CRED_SLASH, credentialsDoc, dryRun)
+		if errorSetCred == nil {
+			agentConfig, _ = sjson.Set(agentConfig, "additionalProperties.agentQMgrAuthenticationCredentialsFile", agentCredFilePath)
+		} else {
+			utils.PrintLog(errorSetCred.Error())
+		}
+
+		if logLevel >= LOG_LEVEL_VERBOSE && len(agentConfig) > 0 {
+			utils.PrintLog(fmt.Sprintf("Updated agent configuration - %v", utils.RedactResolvedReferences(agentConfig, TEXT_REDACTED)))
+		}
+
+		// Update UserSandbox XML file - valid only for STANDARD agents
+		if standardAgent {
+			errCusbox := CreateUserSandbox(outputRoot + agentConfigPath + MFT_USER_SANDBOX_SLASH)
+			if errCusbox != nil {
+				utils.PrintLog(errCusbox.Error())
+				created = false
 			}
+		} else {
+			// This is a bridge agent. We need to update the ProtocolBridgeProperties.xml file for all other servers specified
+			// in configuration JSON file.
+			created = updateProtocolBridgePropertiesFile(protocolBridgePropertiesFile, agentConfig)
+		}
+	}
+
+	if created {
+		// Update agent properties file with additional attributes specified.
+		created = UpdateAgentProperties(agentPropertiesFile, agentConfig, "additionalProperties", !standardAgent)
+		if created {
+			// Tell user that agent has been configured.
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_CREATED_0047, agentName))
 		}
 	}
 	return created
 }
 
-func configTLSAgent(agentConfig string, credentialsDoc *xmldom.Document, agentCredFilePath string) (bool, string) {
+func configTLSAgent(agentConfig string, credentialsDoc *xmldom.Document, agentCredFilePath string, dryRun bool) (bool, string) {
 	var created bool = true
 
 	// Create keystore using certificate provided if available.
-	cipherName, cipherSet := os.LookupEnv(MFT_AGENT_QMGR_CIPHER)
-	if cipherSet && len(strings.Trim(cipherName, TEXT_TRIM)) > 0 {
-		password := generateRandomPassword()
-		publicKeyFile := getKeyFile(agentQMCertPath, ".crt")
-		if len(publicKeyFile) > 0 {
-			agentConfig, _ = sjson.Set(agentConfig, "additionalProperties.agentSslCipherSpec", cipherName)
+	settings := agentTLSSettings(agentConfig)
+	if settings.enabled() {
+		password, err := generateRandomPassword()
+		if err != nil {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_KEYSTORE_CREATE_FAILED, AGENT_QM_TRUSTSTORE, err.Error()))
+			return false, agentConfig
+		}
+		if settings.hasTrustedCertificates() {
+			agentConfig, _ = sjson.Set(agentConfig, "additionalProperties.agentSslCipherSpec", settings.cipherSpec)
 			// Update coordination properties file
-			errCreateKeyStore := CreateKeyStore(KEYSTORES_PATH, AGENT_QM_TRUSTSTORE, publicKeyFile, password)
+			errCreateKeyStore := prepareTrustStore(KEYSTORES_PATH, AGENT_QM_TRUSTSTORE, settings, password, dryRun)
 			if errCreateKeyStore == nil {
-				agentConfig, _ = sjson.Set(agentConfig, "additionalProperties.agentSslCipherSpec", cipherName)
+				agentConfig, _ = sjson.Set(agentConfig, "additionalProperties.agentSslCipherSpec", settings.cipherSpec)
 				agentConfig, _ = sjson.Set(agentConfig, "additionalProperties.agentSslTrustStore", filepath.Join(KEYSTORES_PATH, AGENT_QM_TRUSTSTORE))
 				agentConfig, _ = sjson.Set(agentConfig, "additionalProperties.agentSslTrustStoreType", "pkcs12")
 				agentConfig, _ = sjson.Set(agentConfig, "additionalProperties.agentSslTrustStoreCredentialsFile", agentCredFilePath)
@@ -362,17 +287,21 @@ func configTLSAgent(agentConfig string, credentialsDoc *xmldom.Document, agentCr
 			}
 		}
 
+		// Distinguished name the certificate of the queue manager must match
+		if len(settings.peerName) > 0 {
+			agentConfig, _ = sjson.Set(agentConfig, "additionalProperties.agentSslPeerName", settings.peerName)
+		}
+
 		// Private key
-		privateKeyCertPath := getKeyFile(agentQMCertPath, ".key")
-		if len(privateKeyCertPath) > 0 {
-			errCreateSslStore := CreateKeyStore(KEYSTORES_PATH, AGENT_QM_KEYSTORE, privateKeyCertPath, password)
+		if settings.hasPrivateKey() {
+			errCreateSslStore := prepareClientKeyStore(KEYSTORES_PATH, AGENT_QM_KEYSTORE, settings, password, dryRun)
 			if errCreateSslStore == nil {
 				agentConfig, _ = sjson.Set(agentConfig, "additionalProperties.agentSslKeyStore", filepath.Join(KEYSTORES_PATH, AGENT_QM_KEYSTORE))
 				agentConfig, _ = sjson.Set(agentConfig, "additionalProperties.agentSslKeyStoreType", "pkcs12")
 				agentConfig, _ = sjson.Set(agentConfig, "additionalProperties.agentSslKeyStoreCredentialsFile", agentCredFilePath)
 				UpdateXmlWithKeyStoreCredentials(credentialsDoc, filepath.Join(KEYSTORES_PATH, AGENT_QM_KEYSTORE), password)
 			} else {
-				utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_KEYSTORE_CREATE_FAILED, AGENT_QM_TRUSTSTORE, errCreateSslStore.Error()))
+				utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_KEYSTORE_CREATE_FAILED, AGENT_QM_KEYSTORE, errCreateSslStore.Error()))
 				created = false
 			}
 			created = true
@@ -383,7 +312,7 @@ func configTLSAgent(agentConfig string, credentialsDoc *xmldom.Document, agentCr
 }
 
 // Read and process protocol bridge server attributes from configuration JSON file
-func updateBridgeParameters(bridgeProperties string, params []string) bool {
+func updateBridgeParameters(bridgeProperties string, params []string) ([]string, bool) {
 	value := false
 	var serverType string
 	if logLevel >= LOG_LEVEL_VERBOSE {
@@ -517,7 +446,7 @@ func updateBridgeParameters(bridgeProperties string, params []string) bool {
 		}
 	}
 
-	return value
+	return params, value
 }
 
 func isValidBridgeListFormat(platform string) bool {
@@ -911,37 +840,14 @@ func cleanAgent(agentConfig string, coordinationQMgr string, agentName string) {
 
 // Unregister and delete agent
 func deleteAgent(coordinationQMgr string, agentName string) error {
-	var outb, errb bytes.Buffer
 	utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_DLTNG_0049, agentName))
 
-	// Get the path of MFT fteDeleteAgent command.
-	cmdDltAgentPath, lookErr := exec.LookPath("fteDeleteAgent")
-	if lookErr != nil {
-		return lookErr
-	}
-
-	var cmdArgs []string
-	cmdArgs = append(cmdArgs, cmdDltAgentPath, "-p", coordinationQMgr, "-f", agentName)
-	if commandTracingEnabled {
-		cmdArgs = append(cmdArgs, "-trace", "com.ibm.wmqfte=all")
-		cmdTracePath := GetCommandTracePath()
-		if len(cmdTracePath) > 0 {
-			cmdArgs = append(cmdArgs, "-tracePath", cmdTracePath)
-		}
-	}
-
-	// -f force option is not used so that monitor is not recreated if it already exists.
-	cmdDltAgentCmd := &exec.Cmd{
-		Path: cmdDltAgentPath,
-		Args: cmdArgs,
-	}
-
-	// Reuse the same buffer
-	cmdDltAgentCmd.Stdout = &outb
-	cmdDltAgentCmd.Stderr = &errb
 	// Execute the fteDeleteAgent command. Log an error an exit in case of any error.
-	if err := cmdDltAgentCmd.Run(); err != nil {
-		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_ERROR_0042, outb.String(), errb.String()))
+	result := runMFTCommand("fteDeleteAgent", "-p", coordinationQMgr, "-f", agentName)
+	if result.NotFound() {
+		return result.Err
+	} else if result.Err != nil {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_ERROR_0042, result.Stdout, result.Stderr))
 		// Return no error even if we fail to create monitor. We have output the
 		// information to console.
 	} else {
@@ -952,42 +858,19 @@ func deleteAgent(coordinationQMgr string, agentName string) error {
 
 // Clean agent on start of container.
 func cleanAgentItem(coordinationQMgr string, agentName string, item string, option string) error {
-	var outb, errb bytes.Buffer
 	utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_CLN_0051, item, agentName))
 
-	// Get the path of MFT fteCleanAgent command.
-	cmdCleanAgentPath, lookErr := exec.LookPath("fteCleanAgent")
-	if lookErr != nil {
-		return lookErr
-	}
-
-	var cmdArgs []string
-	cmdArgs = append(cmdArgs, cmdCleanAgentPath, "-p", coordinationQMgr, option, agentName)
-	if commandTracingEnabled {
-		cmdArgs = append(cmdArgs, "-trace", "com.ibm.wmqfte=all")
-		cmdTracePath := GetCommandTracePath()
-		if len(cmdTracePath) > 0 {
-			cmdArgs = append(cmdArgs, "-tracePath", cmdTracePath)
-		}
-	}
-
-	// Clean agent before starting
-	cmdCleanAgentCmd := &exec.Cmd{
-		Path: cmdCleanAgentPath,
-		Args: cmdArgs,
-	}
-
-	// Reuse the same buffer
-	cmdCleanAgentCmd.Stdout = &outb
-	cmdCleanAgentCmd.Stderr = &errb
 	// Execute the fteCleanAgent command. Log an error an exit in case of any error.
-	if err := cmdCleanAgentCmd.Run(); err != nil {
-		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_ERROR_0042, outb.String(), errb.String()))
+	result := runMFTCommand("fteCleanAgent", "-p", coordinationQMgr, option, agentName)
+	if result.NotFound() {
+		return result.Err
+	} else if result.Err != nil {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_ERROR_0042, result.Stdout, result.Stderr))
 		// Return no error even if we fail to create monitor. We have output the
 		// information to console.
 	} else {
 		if logLevel >= LOG_LEVEL_VERBOSE {
-			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_NOT_FOUND_0028, outb.String()))
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_INFO_0043, result.Stdout))
 		}
 		if item == "all" {
 			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_ALL_ITEM_CLN_0076, agentName))
@@ -999,37 +882,48 @@ func cleanAgentItem(coordinationQMgr string, agentName string, item string, opti
 }
 
 // Create resource monitor
-func createResourceMonitor(coordinationQMgr string, agentName string, agentQMgr string,
+func createResourceMonitor(ctx context.Context, coordinationQMgr string, agentName string, agentQMgr string,
 	monitorName string, fileName string) error {
-	var outb, errb bytes.Buffer
 	utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_RM_CRT_0053, monitorName))
 
-	// Get the path of MFT fteCreateAgent command.
-	cmdCrtMonitorPath, lookErr := exec.LookPath("fteCreateMonitor")
-	if lookErr != nil {
-		return lookErr
-	}
-	// -f force option is not used so that monitor is not recreated if it already exists.
-	cmdCrtMonitorCmd := &exec.Cmd{
-		Path: cmdCrtMonitorPath,
-		Args: []string{cmdCrtMonitorPath, "-p", coordinationQMgr,
-			"-mm", agentQMgr,
-			"-ma", agentName,
-			"-mn", monitorName,
-			"-ix", fileName},
-	}
-
-	// Reuse the same buffer
-	cmdCrtMonitorCmd.Stdout = &outb
-	cmdCrtMonitorCmd.Stderr = &errb
-	// Execute the fteSetupCommands command. Log an error an exit in case of any error.
-	if err := cmdCrtMonitorCmd.Run(); err != nil {
-		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_ERROR_0042, outb.String(), errb.String()))
+	// -f replaces an existing monitor of the same name, so that a retry after an
+	// attempt that created the monitor but then failed does not fail as well.
+	// The monitor is always the one defined in the configuration.
+	result, _ := retryMFTStep(ctx, STEP_CREATE_MONITOR, "fteCreateMonitor", "-p", coordinationQMgr,
+		"-mm", agentQMgr,
+		"-ma", agentName,
+		"-mn", monitorName,
+		"-ix", fileName,
+		"-f")
+	if result.NotFound() {
+		return result.Err
+	} else if result.Err != nil {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_ERROR_0042, result.Stdout, result.Stderr))
 		// Return no error even if we fail to create monitor. We have output the
 		// information to console.
 		return nil
 	} else {
-		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_NOT_FOUND_0028, outb.String()))
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_INFO_0043, result.Stdout))
+	}
+	return nil
+}
+
+// Delete resource monitor
+func deleteResourceMonitor(coordinationQMgr string, agentName string, agentQMgr string, monitorName string) error {
+	utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_RM_DLT_0101, monitorName))
+
+	result := runMFTCommand("fteDeleteMonitor", "-p", coordinationQMgr,
+		"-mm", agentQMgr,
+		"-ma", agentName,
+		"-mn", monitorName)
+	if result.NotFound() {
+		return result.Err
+	} else if result.Err != nil {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_ERROR_0042, result.Stdout, result.Stderr))
+		// Return no error even if we fail to delete monitor. We have output the
+		// information to console.
+	} else {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_INFO_0043, result.Stdout))
 	}
 	return nil
 }
@@ -1058,49 +952,31 @@ func readFileContents(propertiesFile string) string {
 *
 Ping the agent to determine if it's ready to process transfer requests
 */
-func PingAgent(coordinationQMgr string, agentName string, waitTime string) bool {
-	var outb, errb bytes.Buffer
+func PingAgent(ctx context.Context, coordinationQMgr string, agentName string, waitTime string) bool {
 	retVal := false
 
 	utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_VRFY_STATUS_0044, agentName))
-	cmdPingAgentPath, lookPathErr := exec.LookPath("ftePingAgent")
-	if lookPathErr == nil {
-		var cmdArgs []string
-		cmdArgs = append(cmdArgs, cmdPingAgentPath, "-p", coordinationQMgr, agentName, "-w", waitTime)
-		if commandTracingEnabled {
-			cmdArgs = append(cmdArgs, "-trace", "com.ibm.wmqfte=all")
-			cmdTracePath := GetCommandTracePath()
-			if len(cmdTracePath) > 0 {
-				cmdArgs = append(cmdArgs, "-tracePath", cmdTracePath)
-			}
-		}
-
-		cmdPingAgentPath := &exec.Cmd{
-			Path: cmdPingAgentPath,
-			Args: cmdArgs,
-		}
-
-		cmdPingAgentPath.Stdout = &outb
-		cmdPingAgentPath.Stderr = &errb
-		if err := cmdPingAgentPath.Run(); err != nil {
-			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_ERROR_0042, outb.String(), errb.String()))
-		} else {
-			if logLevel >= LOG_LEVEL_VERBOSE {
-				utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_INFO_0043, outb.String()))
-			}
-			// The output must contain BFGCL0793I. Ideally we should check
-			// for return code of 0 from command execution. Need to figure
-			// out a way to do that in Go.
-			if strings.Contains(outb.String(), "BFGCL0793I:") == true {
-				retVal = true
-			}
-		}
+	result := runMFTStep(ctx, STEP_PING_AGENT, "ftePingAgent", "-p", coordinationQMgr, agentName, "-w", waitTime)
+	if result.NotFound() {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_NOT_FOUND_0028, result.Err))
+	} else if result.Err != nil {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_ERROR_0042, result.Stdout, result.Stderr))
 	} else {
-		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_NOT_FOUND_0028, lookPathErr))
+		if logLevel >= LOG_LEVEL_VERBOSE {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_INFO_0043, result.Stdout))
+		}
+		retVal = agentResponded(result)
 	}
+	agentHealth.recordPing(retVal)
 	return retVal
 }
 
+// Returns true if the output of ftePingAgent shows that the agent responded.
+// The output must contain BFGCL0793I as well as a return code of 0.
+func agentResponded(result CommandResult) bool {
+	return result.Err == nil && strings.Contains(result.Stdout, "BFGCL0793I:")
+}
+
 func getMonitorXml(monitorConfig string) string {
 	startXml := "<?xml version=\"1.0\" encoding=\"UTF-8\"?><monitor:monitor version=\"6.00\"" +
 		" xmlns:monitor=\"http://www.ibm.com/xmlns/wmqfte/7.0.1/MonitorDefinition\"" +
@@ -67,5 +67,18 @@ func TestProtocolBridgePropertiesFileUpdates(t *testing.T) {
 }
 
 func TestUpdateBridgeParameters(t *testing.T) {
+	params, ok := updateBridgeParameters(`{"name":"sftp","type":"SFTP","host":"sftp.example.com","platform":"UNIX","fileEncoding":"UTF-8","port":22}`,
+		[]string{"-agentName", "BRIDGE"})
+	if !ok {
+		t.Fatal("Expected bridge parameters to be valid")
+	}
+	expected := "-agentName BRIDGE -bt SFTP -bh sftp.example.com -bm UNIX -bfe UTF-8 -bp 22"
+	if strings.Join(params, " ") != expected {
+		t.Errorf("Expected %s, found %s", expected, strings.Join(params, " "))
+	}
 
+	// FTP servers need a time zone and locale
+	if _, ok := updateBridgeParameters(`{"name":"ftp","type":"FTP","host":"ftp.example.com","fileEncoding":"UTF-8"}`, []string{}); ok {
+		t.Error("Expected bridge parameters to be invalid")
+	}
 }
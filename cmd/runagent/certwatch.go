@@ -0,0 +1,374 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
+)
+
+/**
+* Rotation of the keystores of queue manager connections, and monitoring of the
+* expiry of their certificates.
+*
+* The certificate directories and CA bundles of the queue managers that TLS is
+* enabled for are checked for changes at a fixed interval. When they change,
+* for example when cert-manager renews a certificate in a mounted secret, the
+* new files are verified and the keystores and credentials file of the queue
+* manager are created again:
+*  - The agent reads the keystores of the coordination and agent queue managers
+*    when it starts, so it is restarted after they are created again.
+*  - The keystores of the command queue manager are read by each command, so
+*    they are used by the next command run.
+* Certificates that expire within the warning period are reported in the log
+* once a day, and the expiry of every certificate is served as a metric.
+ */
+
+// Built in interval at which certificate directories are checked
+const CERT_CHECK_DEFAULT_INTERVAL = 60 * time.Second
+
+// Built in number of days before expiry a certificate is reported
+const CERT_EXPIRY_WARNING_DEFAULT_DAYS = 30
+
+// Interval at which a certificate about to expire is reported again
+const CERT_EXPIRY_WARNING_REPEAT = 24 * time.Hour
+
+// Queue managers whose certificates are monitored
+const CERT_QMGR_COORDINATION = "coordination"
+const CERT_QMGR_COMMAND = "command"
+const CERT_QMGR_AGENT = "agent"
+
+// Certificate directory of a queue manager, with the other TLS settings
+// its keystores are created from
+type certificateDirectory struct {
+	qmgr     string
+	settings tlsSettings
+}
+
+// Returns the certificate directories of the queue managers of an agent that
+// TLS is enabled for
+func tlsCertificateDirectories(allAgentConfig string, agentConfig string) []certificateDirectory {
+	var directories []certificateDirectory
+	for _, directory := range []certificateDirectory{
+		{CERT_QMGR_COORDINATION, coordinationTLSSettings(allAgentConfig)},
+		{CERT_QMGR_COMMAND, commandTLSSettings(allAgentConfig)},
+		{CERT_QMGR_AGENT, agentTLSSettings(agentConfig)},
+	} {
+		if directory.settings.enabled() {
+			directories = append(directories, directory)
+		}
+	}
+	return directories
+}
+
+// A certificate of the certificate directory of a queue manager
+type monitoredCertificate struct {
+	certificateFile
+	qmgr string
+}
+
+// Monitors the certificates of the queue managers of an agent
+type certificateMonitor struct {
+	reloader      *configReloader
+	directories   []certificateDirectory
+	warningPeriod time.Duration
+	// Creates the keystores of changed directories again. Replaced in tests.
+	rotate func(ctx context.Context, changed []certificateDirectory)
+
+	lock sync.Mutex
+	// Digest of the files of each queue manager when last checked
+	digests      map[string]string
+	certificates []monitoredCertificate
+	// Time each certificate about to expire was last reported
+	warned map[string]time.Time
+}
+
+// Certificates of the agent run by this container, served as metrics
+var agentCertificates *certificateMonitor
+
+// Returns a monitor of the certificate directories of queue managers
+func newCertificateMonitor(directories []certificateDirectory) *certificateMonitor {
+	warningDays := float64(CERT_EXPIRY_WARNING_DEFAULT_DAYS)
+	if value, ok := envNumber(MFT_CERT_EXPIRY_WARNING_DAYS, 0); ok {
+		warningDays = value
+	}
+	m := &certificateMonitor{
+		warningPeriod: time.Duration(warningDays * float64(24*time.Hour)),
+		digests:       make(map[string]string),
+		warned:        make(map[string]time.Time),
+	}
+	m.rotate = m.rotateKeyStores
+	m.directories = directories
+	for _, directory := range directories {
+		m.digests[directory.qmgr] = certificateFilesDigest(directory.settings)
+	}
+	m.checkExpiry()
+	return m
+}
+
+// Check the certificate directories for changes until the context is cancelled.
+// The keystores are created again with the configuration applied by the reloader.
+func (m *certificateMonitor) watch(ctx context.Context, reloader *configReloader) {
+	interval := CERT_CHECK_DEFAULT_INTERVAL
+	if value, ok := envNumber(MFT_CERT_CHECK_INTERVAL, 0); ok {
+		interval = time.Duration(value * float64(time.Second))
+	}
+	// Nothing to do without TLS or an interval.
+	if len(m.directories) == 0 || interval <= 0 {
+		return
+	}
+	m.reloader = reloader
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.check(ctx)
+			}
+		}
+	}()
+}
+
+// Create the keystores of the directories that have changed again, then report
+// the certificates about to expire
+func (m *certificateMonitor) check(ctx context.Context) {
+	var changed []certificateDirectory
+	for _, directory := range m.directories {
+		digest := certificateFilesDigest(directory.settings)
+		m.lock.Lock()
+		previous := m.digests[directory.qmgr]
+		m.digests[directory.qmgr] = digest
+		m.lock.Unlock()
+		if digest == previous {
+			continue
+		}
+		sources := strings.Join(directory.settings.trustSources(), ", ")
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLS_CERTS_CHANGED_0157, directory.qmgr, sources))
+		// Keep the keystores in use if the new files can not be used. They
+		// are verified again when they change.
+		if err := verifyTLSSettings(directory.settings); err != nil {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLS_CERTS_REJECTED_0158, directory.qmgr, sources, err))
+			continue
+		}
+		changed = append(changed, directory)
+	}
+	if len(changed) > 0 {
+		m.rotate(ctx, changed)
+	}
+	m.checkExpiry()
+}
+
+// Create the keystores and credentials files of the queue managers whose
+// certificates have changed, and restart the agent if it uses them
+func (m *certificateMonitor) rotateKeyStores(ctx context.Context, changed []certificateDirectory) {
+	agentLifecycleLock.Lock()
+	defer agentLifecycleLock.Unlock()
+	// Nothing to do if the container is being stopped.
+	if isAgentStopRequested() {
+		return
+	}
+
+	allAgentConfig, agentConfig := m.reloader.currentConfig()
+	bfgDataPath := m.reloader.bfgDataPath
+	restartAgent := false
+	for _, directory := range changed {
+		switch directory.qmgr {
+		case CERT_QMGR_COORDINATION:
+			if ConfigureCoordination(allAgentConfig, bfgDataPath, bfgDataPath, false) {
+				utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLS_CERTS_ROTATED_0160, directory.qmgr))
+				restartAgent = true
+			}
+		case CERT_QMGR_COMMAND:
+			if ConfigureCommands(allAgentConfig, bfgDataPath, bfgDataPath, false) {
+				utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLS_CERTS_ROTATED_0160, directory.qmgr))
+			}
+		case CERT_QMGR_AGENT:
+			// The keystores of the agent queue manager are created again with
+			// the configuration of the agent.
+			restartAgent = true
+		}
+	}
+	if restartAgent {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLS_AGENT_RESTART_0159, m.reloader.agentName))
+		if !m.reloader.restartAgent(ctx, agentConfig) {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLS_AGENT_RESTART_FAILED_0161, m.reloader.agentName))
+		}
+	}
+}
+
+// Read the certificates of the monitored directories and report the ones that
+// expire within the warning period
+func (m *certificateMonitor) checkExpiry() {
+	var certificates []monitoredCertificate
+	for _, directory := range m.directories {
+		for _, c := range readMonitoredCertificates(directory.settings) {
+			certificates = append(certificates, monitoredCertificate{certificateFile: c, qmgr: directory.qmgr})
+		}
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.certificates = certificates
+	now := time.Now()
+	for _, c := range certificates {
+		if c.cert.NotAfter.Sub(now) > m.warningPeriod {
+			continue
+		}
+		key := c.qmgr + ":" + certificateFingerprint(c.cert.Raw)
+		if reported, exists := m.warned[key]; exists && now.Sub(reported) < CERT_EXPIRY_WARNING_REPEAT {
+			continue
+		}
+		m.warned[key] = now
+		expiry := c.cert.NotAfter.UTC().Format(time.RFC3339)
+		if now.After(c.cert.NotAfter) {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLS_CERT_HAS_EXPIRED_0163, c.cert.Subject, c.qmgr, c.file, expiry))
+		} else {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLS_CERT_EXPIRING_0162, c.cert.Subject, c.qmgr, c.file, expiry,
+				int(math.Ceil(c.cert.NotAfter.Sub(now).Hours()/24))))
+		}
+	}
+}
+
+// Write the expiry of the certificates in the Prometheus text format
+func (m *certificateMonitor) write(w io.Writer) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if len(m.directories) == 0 {
+		return
+	}
+	labelNames := []string{"queue_manager", "subject", "file"}
+	writeMetricHeader(w, "mqmft_certificate_expiry_timestamp_seconds",
+		"Time the certificates used to connect to queue managers expire, in seconds since the epoch.", "gauge")
+	expiring := make(map[string]int)
+	for _, c := range m.certificates {
+		fmt.Fprintf(w, "mqmft_certificate_expiry_timestamp_seconds%s %d\n",
+			formatLabels(labelNames, []string{c.qmgr, c.cert.Subject.String(), c.file}), c.cert.NotAfter.Unix())
+		if time.Until(c.cert.NotAfter) <= m.warningPeriod {
+			expiring[c.qmgr]++
+		}
+	}
+	writeMetricHeader(w, "mqmft_certificates_expiring",
+		"Number of certificates used to connect to queue managers that expire within the warning period.", "gauge")
+	for _, directory := range m.directories {
+		fmt.Fprintf(w, "mqmft_certificates_expiring%s %d\n",
+			formatLabels([]string{"queue_manager"}, []string{directory.qmgr}), expiring[directory.qmgr])
+	}
+}
+
+// Returns an error if the keystores of a queue manager can not be created
+// from its TLS settings
+func verifyTLSSettings(settings tlsSettings) error {
+	password, err := generateRandomPassword()
+	if err != nil {
+		return err
+	}
+	if settings.hasTrustedCertificates() {
+		if _, _, err := buildTrustStore(settings, password); err != nil {
+			return err
+		}
+	}
+	if settings.hasPrivateKey() {
+		if _, err := buildClientKeyStore(settings, password); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the certificates to trust and the certificates supplied with the
+// private key of a queue manager. A certificate found more than once is
+// returned once.
+func readMonitoredCertificates(settings tlsSettings) []certificateFile {
+	certs, err := settings.readTrustedCertificates()
+	if err != nil && logLevel >= LOG_LEVEL_VERBOSE {
+		utils.PrintLog(err.Error())
+	}
+	if settings.hasPrivateKey() {
+		contents, err := settings.readPrivateKey()
+		if err != nil && logLevel >= LOG_LEVEL_VERBOSE {
+			utils.PrintLog(err.Error())
+		}
+		certs = append(certs, contents.certs...)
+	}
+	var monitored []certificateFile
+	found := make(map[string]bool)
+	for _, c := range certs {
+		if fingerprint := certificateFingerprint(c.cert.Raw); !found[fingerprint] {
+			found[fingerprint] = true
+			monitored = append(monitored, c)
+		}
+	}
+	return monitored
+}
+
+// Returns a digest of the files of the certificate directory and the CA bundle
+// of a queue manager
+func certificateFilesDigest(settings tlsSettings) string {
+	digest := directoryDigest(settings.certificateDir)
+	if len(settings.caBundle) > 0 {
+		// #nosec G304
+		data, _ := os.ReadFile(settings.caBundle)
+		sum := sha256.Sum256(data)
+		digest += ":" + hex.EncodeToString(sum[:])
+	}
+	return digest
+}
+
+// Returns a digest of the names and contents of the files of a directory, or
+// a blank string if the directory can not be read. Links are followed, so a
+// secret updated by swapping the link to its data directory is a change.
+func directoryDigest(dir string) string {
+	fileList, err := os.ReadDir(dir)
+	if err != nil {
+		return TEXT_BLANK
+	}
+	digest := sha256.New()
+	for _, fileInfo := range fileList {
+		file := filepath.Join(dir, fileInfo.Name())
+		if info, err := os.Stat(file); err != nil || info.IsDir() {
+			continue
+		}
+		// #nosec G304
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(digest, "%s\x00%d\x00", fileInfo.Name(), len(data))
+		digest.Write(data)
+	}
+	return hex.EncodeToString(digest.Sum(nil))
+}
+
+// Returns the SHA-256 fingerprint of a certificate
+func certificateFingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
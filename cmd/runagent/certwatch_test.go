@@ -0,0 +1,129 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Returns a monitor of a single certificate directory, and the directories it
+// would create keystores for
+func newTestCertificateMonitor(t *testing.T, qmgr string, dir string) (*certificateMonitor, *[]string) {
+	settings := tlsSettings{cipherSpec: "ECDHE_RSA_AES_256_CBC_SHA384", certificateDir: dir}
+	monitor := newCertificateMonitor([]certificateDirectory{{qmgr, settings}})
+	rotated := &[]string{}
+	monitor.rotate = func(ctx context.Context, changed []certificateDirectory) {
+		for _, directory := range changed {
+			*rotated = append(*rotated, directory.qmgr)
+		}
+	}
+	return monitor, rotated
+}
+
+// Keystores are created again when valid certificates change
+func TestCertificateMonitorRotation(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := newTestCertificate(t, "Test CA", time.Now().Add(24*time.Hour), nil, nil)
+	writePEMFile(t, filepath.Join(dir, "ca.crt"), certificateBlock(ca))
+	monitor, rotated := newTestCertificateMonitor(t, CERT_QMGR_AGENT, dir)
+	monitor.check(context.Background())
+	if len(*rotated) != 0 {
+		t.Fatalf("Unexpected rotation %v", *rotated)
+	}
+
+	// A renewed certificate is used
+	client, clientKey := newTestCertificate(t, "Client", time.Now().Add(24*time.Hour), ca, caKey)
+	writePEMFile(t, filepath.Join(dir, "client.key"), ecKeyBlock(t, clientKey), certificateBlock(client))
+	monitor.check(context.Background())
+	if strings.Join(*rotated, ",") != CERT_QMGR_AGENT {
+		t.Fatalf("Expected the keystores to be created again, got %v", *rotated)
+	}
+
+	// An expired certificate to trust is left out of the truststore
+	expired, expiredKey := newTestCertificate(t, "Expired", time.Now().Add(-time.Minute), ca, caKey)
+	writePEMFile(t, filepath.Join(dir, "ca.crt"), certificateBlock(ca), certificateBlock(expired))
+	monitor.check(context.Background())
+	if len(*rotated) != 2 {
+		t.Fatalf("Expected the keystores to be created again, got %v", *rotated)
+	}
+
+	// An expired certificate of the private key is not used, even when checked again
+	writePEMFile(t, filepath.Join(dir, "client.key"), ecKeyBlock(t, expiredKey), certificateBlock(expired))
+	monitor.check(context.Background())
+	monitor.check(context.Background())
+	if len(*rotated) != 2 {
+		t.Errorf("Unexpected rotation %v", *rotated)
+	}
+}
+
+// Certificates about to expire are reported once and served as metrics
+func TestCertificateMonitorExpiry(t *testing.T) {
+	setTestEnv(t, MFT_CERT_EXPIRY_WARNING_DAYS, "10")
+	dir := t.TempDir()
+	ca, caKey := newTestCertificate(t, "Test CA", time.Now().Add(100*24*time.Hour), nil, nil)
+	client, clientKey := newTestCertificate(t, "Client", time.Now().Add(5*24*time.Hour), ca, caKey)
+	writePEMFile(t, filepath.Join(dir, "ca.crt"), certificateBlock(ca))
+	writePEMFile(t, filepath.Join(dir, "client.key"), ecKeyBlock(t, clientKey), certificateBlock(client))
+
+	monitor, _ := newTestCertificateMonitor(t, CERT_QMGR_AGENT, dir)
+	if len(monitor.certificates) != 2 || len(monitor.warned) != 1 {
+		t.Fatalf("Expected 2 certificates and 1 warning, got %d and %d", len(monitor.certificates), len(monitor.warned))
+	}
+	reported := make(map[string]time.Time)
+	for key, value := range monitor.warned {
+		reported[key] = value
+	}
+	monitor.checkExpiry()
+	for key, value := range monitor.warned {
+		if reported[key] != value {
+			t.Errorf("Certificate %s reported again", key)
+		}
+	}
+
+	var metrics bytes.Buffer
+	monitor.write(&metrics)
+	for _, expected := range []string{
+		`mqmft_certificates_expiring{queue_manager="agent"} 1`,
+		fmt.Sprintf(`mqmft_certificate_expiry_timestamp_seconds{queue_manager="agent",subject="CN=Client",file="%s"} %d`,
+			filepath.Join(dir, "client.key"), client.NotAfter.Unix()),
+	} {
+		if !strings.Contains(metrics.String(), expected) {
+			t.Errorf("Expected %s in metrics %s", expected, metrics.String())
+		}
+	}
+}
+
+// Only the queue managers TLS is enabled for are monitored
+func TestTLSCertificateDirectories(t *testing.T) {
+	setTestEnv(t, MFT_COORD_QMGR_CIPHER, "")
+	setTestEnv(t, MFT_CMD_QMGR_CIPHER, "")
+	setTestEnv(t, MFT_AGENT_QMGR_CIPHER, "ECDHE_RSA_AES_256_CBC_SHA384")
+	allAgentConfig := `{"coordinationQMgr":{"tls":{"cipherSpec":"TLS_AES_128_GCM_SHA256","certificateDirectory":"/etc/certs/coord"}},
+		"commandQMgr":{"tls":{"certificateDirectory":"/etc/certs/cmd"}}}`
+	directories := tlsCertificateDirectories(allAgentConfig, `{"name":"SRC"}`)
+	if len(directories) != 2 || directories[0].qmgr != CERT_QMGR_COORDINATION || directories[1].qmgr != CERT_QMGR_AGENT {
+		t.Fatalf("Expected the coordination and agent queue managers, got %v", directories)
+	}
+	if directories[0].settings.certificateDir != "/etc/certs/coord" || directories[1].settings.certificateDir != agentQMCertPath {
+		t.Errorf("Unexpected certificate directories %v", directories)
+	}
+}
@@ -16,13 +16,10 @@ limitations under the License.
 package main
 
 import (
-	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"os"
-	"os/exec"
 	"path/filepath"
-	"strings"
 
 	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
 	"github.com/subchen/go-xmldom"
@@ -31,157 +28,150 @@ import (
 )
 
 // Calls fteSetupCommands to create command queue manager configuration.
-func SetupCommands(allAgentConfig string, bfgDataPath string, agentName string) bool {
-	// Variables for Stdout and Stderr
-	var outb, errb bytes.Buffer
+func SetupCommands(ctx context.Context, allAgentConfig string, bfgDataPath string, agentName string) bool {
 	var created bool = false
 	commandQueueManager := gjson.Get(allAgentConfig, "commandQMgr.name").String()
 
 	utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_SETUP_STRT_0055, agentName, commandQueueManager))
 
-	// Get the path of MFT fteSetupCommands command.
-	cmdCmdsPath, lookPathErr := exec.LookPath("fteSetupCommands")
-	if lookPathErr == nil {
-		// Setup commands configuration
-		if !gjson.Get(allAgentConfig, "commandQMgr.name").Exists() {
-			utils.PrintLog("Command queue manager name not provided")
-			return false
-		}
-		var port string
-		var channel string
-		var hostName string
-		if gjson.Get(allAgentConfig, "commandQMgr.host").Exists() {
-			hostName = gjson.Get(allAgentConfig, "commandQMgr.host").String()
-		} else {
-			hostName = "localhost"
-		}
-
-		if gjson.Get(allAgentConfig, "commandQMgr.port").Exists() {
-			port = gjson.Get(allAgentConfig, "commandQMgr.port").String()
-		} else {
-			port = "1414"
-		}
+	// Setup commands configuration
+	if !gjson.Get(allAgentConfig, "commandQMgr.name").Exists() {
+		utils.PrintLog("Command queue manager name not provided")
+		return false
+	}
+	var port string
+	var channel string
+	var hostName string
+	if gjson.Get(allAgentConfig, "commandQMgr.host").Exists() {
+		hostName = gjson.Get(allAgentConfig, "commandQMgr.host").String()
+	} else {
+		hostName = "localhost"
+	}
 
-		if gjson.Get(allAgentConfig, "commandQMgr.channel").Exists() {
-			channel = gjson.Get(allAgentConfig, "commandQMgr.channel").String()
-		} else {
-			channel = "SYSTEM.DEF.SVRCONN"
-		}
+	if gjson.Get(allAgentConfig, "commandQMgr.port").Exists() {
+		port = gjson.Get(allAgentConfig, "commandQMgr.port").String()
+	} else {
+		port = "1414"
+	}
 
-		var cmdArgs []string
-		cmdArgs = append(cmdArgs, cmdCmdsPath,
-			"-p", gjson.Get(allAgentConfig, "coordinationQMgr.name").String(),
-			"-connectionQMgr", commandQueueManager,
-			"-connectionQMgrHost", hostName,
-			"-connectionQMgrPort", port, "-connectionQMgrChannel", channel, "-f")
-		if commandTracingEnabled {
-			cmdArgs = append(cmdArgs, "-trace", "com.ibm.wmqfte=all")
-			cmdTracePath := GetCommandTracePath()
-			if len(cmdTracePath) > 0 {
-				cmdArgs = append(cmdArgs, "-tracePath", cmdTracePath)
-			}
-		}
+	if gjson.Get(allAgentConfig, "commandQMgr.channel").Exists() {
+		channel = gjson.Get(allAgentConfig, "commandQMgr.channel").String()
+	} else {
+		channel = "SYSTEM.DEF.SVRCONN"
+	}
 
-		cmdSetupCmds := &exec.Cmd{
-			Path: cmdCmdsPath,
-			Args: cmdArgs,
+	// Execute the fteSetupCommands command. Log an error in case of any error.
+	result := runMFTStep(ctx, STEP_COMMANDS, "fteSetupCommands",
+		"-p", gjson.Get(allAgentConfig, "coordinationQMgr.name").String(),
+		"-connectionQMgr", commandQueueManager,
+		"-connectionQMgrHost", hostName,
+		"-connectionQMgrPort", port, "-connectionQMgrChannel", channel, "-f")
+	if result.NotFound() {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_NOT_FOUND_0028, result.Err))
+	} else if result.Err != nil {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_ERROR_0042, result.Stdout, result.Stderr))
+	} else {
+		if logLevel >= LOG_LEVEL_VERBOSE {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_INFO_0043, result.Stdout))
 		}
 
-		cmdSetupCmds.Stdout = &outb
-		cmdSetupCmds.Stderr = &errb
-		// Execute the fteSetupCommands command. Log an error an exit in case of any error.
-		if err := cmdSetupCmds.Run(); err != nil {
-			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_ERROR_0042, outb.String(), errb.String()))
-			os.Exit(1)
-		} else {
-			if logLevel >= LOG_LEVEL_VERBOSE {
-				utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_INFO_0043, outb.String()))
-			}
+		// Write command properties and credentials
+		created = ConfigureCommands(allAgentConfig, bfgDataPath, bfgDataPath, false)
+	}
 
-			coordinationQmgrName := gjson.Get(allAgentConfig, "coordinationQMgr.name").String()
-			// Start XML document for credentials file
-			credentialsDoc := InitializeCredentialsDocumentWriter()
-			cmdCredFilePath := bfgDataPath + MFT_CONFIG_PATH_SUFFIX + coordinationQmgrName + MFT_CMD_CRED_SLASH
-			// Configure TLS for command queue manager
-			created, allAgentConfig = configTLSCommand(allAgentConfig, credentialsDoc, cmdCredFilePath)
+	return created
+}
 
-			if gjson.Get(allAgentConfig, "commandQMgr.qmgrCredentials").Exists() {
-				// Write coordination queue manager credentials
-				UpdateXmlWithQmgrCredentials(credentialsDoc, gjson.Get(allAgentConfig, "commandQMgr.qmgrCredentials").String(), commandQueueManager)
-			}
+// Update command properties file with additional attributes specified and create the
+// credentials file for command queue manager. Files are written under outputRoot while
+// the paths referenced from them are relative to bfgDataPath.
+func ConfigureCommands(allAgentConfig string, bfgDataPath string, outputRoot string, dryRun bool) bool {
+	var created bool = false
+	commandQueueManager := gjson.Get(allAgentConfig, "commandQMgr.name").String()
+	coordinationQmgrName := gjson.Get(allAgentConfig, "coordinationQMgr.name").String()
+	// Start XML document for credentials file
+	credentialsDoc := InitializeCredentialsDocumentWriter()
+	cmdCredFilePath := bfgDataPath + MFT_CONFIG_PATH_SUFFIX + coordinationQmgrName + MFT_CMD_CRED_SLASH
+	// Configure TLS for command queue manager
+	created, allAgentConfig = configTLSCommand(allAgentConfig, credentialsDoc, cmdCredFilePath, dryRun)
+
+	if gjson.Get(allAgentConfig, "commandQMgr.qmgrCredentials").Exists() {
+		// Write coordination queue manager credentials
+		UpdateXmlWithQmgrCredentials(credentialsDoc, gjson.Get(allAgentConfig, "commandQMgr.qmgrCredentials").String(), commandQueueManager)
+	}
 
-			errSetCred := SetupCredentials(cmdCredFilePath, credentialsDoc.XMLPretty())
-			if errSetCred == nil {
-				// Attempt to encrypt the credentials file with a fixed key
-				EncryptCredentialsFile(cmdCredFilePath)
-				allAgentConfig, _ = sjson.Set(allAgentConfig, "commandQMgr.additionalProperties.connectionQMgrAuthenticationCredentialsFile", cmdCredFilePath)
-			} else {
-				utils.PrintLog(errSetCred.Error())
-			}
+	errSetCred := WriteCredentialsFile(outputRoot+MFT_CONFIG_PATH_SUFFIX+coordinationQmgrName+MFT_CMD_CRED_SLASH, credentialsDoc, dryRun)
+	if errSetCred == nil {
+		allAgentConfig, _ = sjson.Set(allAgentConfig, "commandQMgr.additionalProperties.connectionQMgrAuthenticationCredentialsFile", cmdCredFilePath)
+	} else {
+		utils.PrintLog(errSetCred.Error())
+	}
 
-			if logLevel >= LOG_LEVEL_VERBOSE && len(cmdCredFilePath) > 0 {
-				utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_QMGR_CRED_PATH_0056, cmdCredFilePath))
-			}
+	if logLevel >= LOG_LEVEL_VERBOSE && len(cmdCredFilePath) > 0 {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_QMGR_CRED_PATH_0056, cmdCredFilePath))
+	}
 
-			if logLevel >= LOG_LEVEL_VERBOSE && len(allAgentConfig) > 0 {
-				utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_UPDATED_CMD_CONFIG, allAgentConfig))
-			}
+	if logLevel >= LOG_LEVEL_VERBOSE && len(allAgentConfig) > 0 {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_UPDATED_CMD_CONFIG, utils.RedactResolvedReferences(allAgentConfig, TEXT_REDACTED)))
+	}
 
-			// Update command properties file with additional attributes specified.
-			commandsPropertiesFile := bfgDataPath + MFT_CONFIG_PATH_SUFFIX + coordinationQmgrName + MFT_CMD_PROPS_SLASH
-			err := UpdateProperties(commandsPropertiesFile, allAgentConfig, "commandQMgr.additionalProperties")
-			if err != nil {
-				utils.PrintLog(err.Error())
-			} else {
-				utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_SETUP_COMP_0057, commandQueueManager))
-				created = true
-			}
-		}
+	// Update command properties file with additional attributes specified.
+	commandsPropertiesFile := outputRoot + MFT_CONFIG_PATH_SUFFIX + coordinationQmgrName + MFT_CMD_PROPS_SLASH
+	err := UpdateProperties(commandsPropertiesFile, allAgentConfig, "commandQMgr.additionalProperties")
+	if err != nil {
+		utils.PrintLog(err.Error())
 	} else {
-		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_NOT_FOUND_0028, lookPathErr))
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_SETUP_COMP_0057, commandQueueManager))
+		created = true
 	}
-
 	return created
 }
 
 // Configure TLS for command queue manager
-func configTLSCommand(allAgentConfig string, credentialsDoc *xmldom.Document, cmdCredFilePath string) (bool, string) {
+func configTLSCommand(allAgentConfig string, credentialsDoc *xmldom.Document, cmdCredFilePath string, dryRun bool) (bool, string) {
 	var created bool
 	// Create keystore using certificate provided if available.
-	cipherName, cipherSet := os.LookupEnv(MFT_CMD_QMGR_CIPHER)
-	if cipherSet && len(strings.Trim(cipherName, TEXT_TRIM)) > 0 {
-		password := generateRandomPassword()
-		// Search for .crt file in the predefined directory
-		publicKeyCertPath := getKeyFile(commandQMCertPath, ".crt")
-		if len(publicKeyCertPath) > 0 {
-			// Trust store - public key of command queue manager
-			errCreateKeyStore := CreateKeyStore(KEYSTORES_PATH, CMD_QM_TRUSTSTORE, publicKeyCertPath, password)
+	settings := commandTLSSettings(allAgentConfig)
+	if settings.enabled() {
+		password, err := generateRandomPassword()
+		if err != nil {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_KEYSTORE_CREATE_FAILED, CMD_QM_TRUSTSTORE, err.Error()))
+			return false, allAgentConfig
+		}
+		// Search for certificate files in the predefined directory
+		if settings.hasTrustedCertificates() {
+			// Trust store - certificates of command queue manager and its CAs
+			errCreateKeyStore := prepareTrustStore(KEYSTORES_PATH, CMD_QM_TRUSTSTORE, settings, password, dryRun)
 			if errCreateKeyStore == nil {
 				// Update coordination properties file
-				allAgentConfig, _ = sjson.Set(allAgentConfig, "commandQMgr.additionalProperties.connectionSslCipherSpec", cipherName)
+				allAgentConfig, _ = sjson.Set(allAgentConfig, "commandQMgr.additionalProperties.connectionSslCipherSpec", settings.cipherSpec)
 				allAgentConfig, _ = sjson.Set(allAgentConfig, "commandQMgr.additionalProperties.connectionSslTrustStore", filepath.Join(KEYSTORES_PATH, CMD_QM_TRUSTSTORE))
 				allAgentConfig, _ = sjson.Set(allAgentConfig, "commandQMgr.additionalProperties.connectionSslTrustStoreType", "pkcs12")
 				allAgentConfig, _ = sjson.Set(allAgentConfig, "commandQMgr.additionalProperties.connectionSslTrustStoreCredentialsFile", cmdCredFilePath)
 				UpdateXmlWithKeyStoreCredentials(credentialsDoc, filepath.Join(KEYSTORES_PATH, CMD_QM_TRUSTSTORE), password)
 				created = true
 			} else {
-				utils.PrintLog(errCreateKeyStore.Error())
+				utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_KEYSTORE_CREATE_FAILED, CMD_QM_TRUSTSTORE, errCreateKeyStore.Error()))
 			}
 		}
 
+		// Distinguished name the certificate of the queue manager must match
+		if len(settings.peerName) > 0 {
+			allAgentConfig, _ = sjson.Set(allAgentConfig, "commandQMgr.additionalProperties.connectionSslPeerName", settings.peerName)
+		}
+
 		// Key store details - private key
-		privateKeyCertPath := getKeyFile(commandQMCertPath, ".key")
-		if len(privateKeyCertPath) > 0 {
-			errCreateSslStore := CreateKeyStore(KEYSTORES_PATH, CMD_QM_KEYSTORE, privateKeyCertPath, password)
+		if settings.hasPrivateKey() {
+			errCreateSslStore := prepareClientKeyStore(KEYSTORES_PATH, CMD_QM_KEYSTORE, settings, password, dryRun)
 			if errCreateSslStore == nil {
-				allAgentConfig, _ = sjson.Set(allAgentConfig, "commandQMgr.additionalProperties.connectionSslCipherSpec", cipherName)
+				allAgentConfig, _ = sjson.Set(allAgentConfig, "commandQMgr.additionalProperties.connectionSslCipherSpec", settings.cipherSpec)
 				allAgentConfig, _ = sjson.Set(allAgentConfig, "commandQMgr.additionalProperties.connectionSslKeyStore", filepath.Join(KEYSTORES_PATH, CMD_QM_KEYSTORE))
 				allAgentConfig, _ = sjson.Set(allAgentConfig, "commandQMgr.additionalProperties.connectionSslKeyStoreType", "pkcs12")
 				allAgentConfig, _ = sjson.Set(allAgentConfig, "commandQMgr.additionalProperties.connectionSslKeyStoreCredentialsFile", cmdCredFilePath)
 				UpdateXmlWithKeyStoreCredentials(credentialsDoc, filepath.Join(KEYSTORES_PATH, CMD_QM_KEYSTORE), password)
 				created = true
 			} else {
-				utils.PrintLog(errCreateSslStore.Error())
+				utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_KEYSTORE_CREATE_FAILED, CMD_QM_KEYSTORE, errCreateSslStore.Error()))
 				created = false
 			}
 		}
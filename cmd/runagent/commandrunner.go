@@ -0,0 +1,140 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+/**
+* This file contains the abstraction used for running MFT commands.
+* All external commands are run through the commandRunner so that the startup
+* sequence can be tested without an MFT installation.
+ */
+
+// An external command to run
+type Command struct {
+	// Name of the executable, resolved using PATH
+	Name string
+	// Arguments, not including the executable
+	Args []string
+	// Maximum time the command is allowed to run. Zero means no limit.
+	Timeout time.Duration
+	// Append MFT trace arguments if command tracing is enabled
+	Trace bool
+}
+
+// Result of running a command
+type CommandResult struct {
+	// Path of the executable that was run
+	Path string
+	// Arguments passed, including any trace arguments
+	Args []string
+	// Exit code of the command, -1 if it did not run to completion
+	ExitCode int
+	Stdout   string
+	Stderr   string
+	Duration time.Duration
+	// Set if the command could not be found or started, timed out or ended
+	// with a non-zero exit code
+	Err error
+}
+
+// Runs external commands
+type CommandRunner interface {
+	Run(ctx context.Context, command Command) CommandResult
+}
+
+// Runner used by all MFT commands.
+var commandRunner CommandRunner = &execCommandRunner{}
+
+// Error returned when a command did not complete within its timeout
+var ErrCommandTimedOut = errors.New("command timed out")
+
+// Returns true if the command could not be run because it was not found
+func (r CommandResult) NotFound() bool {
+	return errors.Is(r.Err, exec.ErrNotFound)
+}
+
+// Returns true if the command ran to completion with exit code 0
+func (r CommandResult) Succeeded() bool {
+	return r.Err == nil && r.ExitCode == 0
+}
+
+// Build arguments of a command, adding MFT trace arguments if required
+func commandArgs(command Command) []string {
+	args := append([]string{}, command.Args...)
+	if command.Trace && commandTracingEnabled {
+		args = append(args, "-trace", "com.ibm.wmqfte=all")
+		cmdTracePath := GetCommandTracePath()
+		if len(cmdTracePath) > 0 {
+			args = append(args, "-tracePath", cmdTracePath)
+		}
+	}
+	return args
+}
+
+// Run an MFT command with trace enabled if asked for
+func runMFTCommand(name string, args ...string) CommandResult {
+	return commandRunner.Run(context.Background(), Command{Name: name, Args: args, Trace: true})
+}
+
+// Runs commands as child processes
+type execCommandRunner struct{}
+
+func (r *execCommandRunner) Run(ctx context.Context, command Command) CommandResult {
+	var outb, errb bytes.Buffer
+	result := CommandResult{Args: commandArgs(command), ExitCode: -1}
+
+	cmdPath, lookPathErr := exec.LookPath(command.Name)
+	if lookPathErr != nil {
+		result.Err = lookPathErr
+		return result
+	}
+	result.Path = cmdPath
+
+	if command.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, command.Timeout)
+		defer cancel()
+	}
+
+	// #nosec G204
+	cmd := exec.CommandContext(ctx, cmdPath, result.Args...)
+	cmd.Stdout = &outb
+	cmd.Stderr = &errb
+	start := time.Now()
+	err := cmd.Run()
+	result.Duration = time.Since(start)
+	result.Stdout = outb.String()
+	result.Stderr = errb.String()
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			result.Err = fmt.Errorf("%s: %w after %v", command.Name, ErrCommandTimedOut, command.Timeout)
+		} else {
+			result.Err = fmt.Errorf("%s: %w", command.Name, err)
+		}
+	}
+	return result
+}
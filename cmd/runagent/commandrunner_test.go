@@ -0,0 +1,235 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// In-memory command runner that records the commands run. Commands succeed
+// unless a handler is registered for the command.
+type fakeCommandRunner struct {
+	lock     sync.Mutex
+	calls    []Command
+	handlers map[string]func(args []string) CommandResult
+}
+
+func newFakeCommandRunner() *fakeCommandRunner {
+	return &fakeCommandRunner{handlers: make(map[string]func(args []string) CommandResult)}
+}
+
+func (f *fakeCommandRunner) Run(ctx context.Context, command Command) CommandResult {
+	args := commandArgs(command)
+	f.lock.Lock()
+	f.calls = append(f.calls, Command{Name: command.Name, Args: args, Timeout: command.Timeout, Trace: command.Trace})
+	handler := f.handlers[command.Name]
+	f.lock.Unlock()
+
+	if handler != nil {
+		result := handler(args)
+		result.Path = "/fake/" + command.Name
+		result.Args = args
+		return result
+	}
+	return CommandResult{Path: "/fake/" + command.Name, Args: args}
+}
+
+// Names of the commands run so far, in order
+func (f *fakeCommandRunner) commandNames() []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	names := make([]string, 0, len(f.calls))
+	for _, call := range f.calls {
+		names = append(names, call.Name)
+	}
+	return names
+}
+
+// Arguments of the first call to the named command
+func (f *fakeCommandRunner) commandArgs(name string) []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for _, call := range f.calls {
+		if call.Name == name {
+			return call.Args
+		}
+	}
+	return nil
+}
+
+// Replace the command runner for the duration of a test
+func useFakeCommandRunner(t *testing.T) *fakeCommandRunner {
+	fake := newFakeCommandRunner()
+	previous := commandRunner
+	commandRunner = fake
+	t.Cleanup(func() { commandRunner = previous })
+	return fake
+}
+
+// Set an environment variable for the duration of a test
+func setTestEnv(t *testing.T, name string, value string) {
+	previous, wasSet := os.LookupEnv(name)
+	os.Setenv(name, value)
+	t.Cleanup(func() {
+		if wasSet {
+			os.Setenv(name, previous)
+		} else {
+			os.Unsetenv(name)
+		}
+	})
+}
+
+// Returns a handler that creates the given file, as the MFT commands do
+func createFileHandler(t *testing.T, fileName string, contents string) func(args []string) CommandResult {
+	return func(args []string) CommandResult {
+		if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+			t.Error(err)
+		}
+		if err := os.WriteFile(fileName, []byte(contents), 0644); err != nil {
+			t.Error(err)
+		}
+		return CommandResult{}
+	}
+}
+
+func TestExecCommandRunner(t *testing.T) {
+	result := commandRunner.Run(context.Background(), Command{Name: "sh",
+		Args: []string{"-c", "echo out; echo err >&2; exit 3"}})
+	if result.ExitCode != 3 || result.Err == nil || result.Succeeded() {
+		t.Errorf("Expected exit code 3 and an error, found %d %v", result.ExitCode, result.Err)
+	}
+	if result.Stdout != "out\n" || result.Stderr != "err\n" {
+		t.Errorf("Unexpected output %q %q", result.Stdout, result.Stderr)
+	}
+
+	result = commandRunner.Run(context.Background(), Command{Name: "fteNoSuchCommand"})
+	if !result.NotFound() || result.ExitCode != -1 {
+		t.Errorf("Expected command not found, found %d %v", result.ExitCode, result.Err)
+	}
+
+	result = commandRunner.Run(context.Background(), Command{Name: "sleep", Args: []string{"5"}, Timeout: 100 * time.Millisecond})
+	if !errors.Is(result.Err, ErrCommandTimedOut) || result.Duration >= 5*time.Second {
+		t.Errorf("Expected command to time out, found %v after %v", result.Err, result.Duration)
+	}
+}
+
+// Trace arguments are only added to MFT commands when tracing is enabled
+func TestCommandArgsTrace(t *testing.T) {
+	previous := commandTracingEnabled
+	defer func() { commandTracingEnabled = previous }()
+
+	commandTracingEnabled = false
+	if args := commandArgs(Command{Name: "fteListAgents", Args: []string{"-p", "QM1"}, Trace: true}); !reflect.DeepEqual(args, []string{"-p", "QM1"}) {
+		t.Errorf("Unexpected arguments %v", args)
+	}
+
+	commandTracingEnabled = true
+	if args := commandArgs(Command{Name: "keytool", Args: []string{"-list"}}); !reflect.DeepEqual(args, []string{"-list"}) {
+		t.Errorf("Unexpected arguments %v", args)
+	}
+	args := commandArgs(Command{Name: "fteListAgents", Args: []string{"-p", "QM1"}, Trace: true})
+	if !strings.Contains(strings.Join(args, " "), "-p QM1 -trace com.ibm.wmqfte=all") {
+		t.Errorf("Trace arguments missing %v", args)
+	}
+}
+
+// Run the whole startup sequence of the container against the fake runner
+func TestRunAgentStartupSequence(t *testing.T) {
+	bfgDataPath := t.TempDir()
+	configFile := filepath.Join(t.TempDir(), "agentconfig.json")
+	configData := `{
+		"coordinationQMgr":{"name":"COORDQM","host":"localhost","port":1414},
+		"commandQMgr":{"name":"CMDQM","host":"localhost","port":1414},
+		"agents":[{"name":"SRC","type":"STANDARD","qmgrName":"AGENTQM","qmgrHost":"localhost",
+			"deleteOnTermination":"true"}]
+	}`
+	if err := os.WriteFile(configFile, []byte(configData), 0644); err != nil {
+		t.Fatal(err)
+	}
+	setTestEnv(t, MFT_AGENT_NAME, "SRC")
+	setTestEnv(t, MFT_AGENT_CONFIG_FILE, configFile)
+	setTestEnv(t, BFG_DATA, bfgDataPath)
+	setTestEnv(t, MFT_AGENT_START_WAIT_TIME, "1")
+
+	configDir := filepath.Join(bfgDataPath, "mqft/config/COORDQM")
+	fake := useFakeCommandRunner(t)
+	fake.handlers["fteSetupCoordination"] = createFileHandler(t, filepath.Join(configDir, "coordination.properties"), "")
+	fake.handlers["fteSetupCommands"] = createFileHandler(t, filepath.Join(configDir, "command.properties"), "")
+	fake.handlers["fteCreateAgent"] = createFileHandler(t, filepath.Join(configDir, "agents/SRC/agent.properties"), "")
+	fake.handlers["fteStartAgent"] = createFileHandler(t, filepath.Join(bfgDataPath, "mqft/logs/COORDQM/agents/SRC/logs/output0.log"),
+		"BFGAG0059I: The agent SRC has been successfully initialized.\n")
+	fake.handlers["ftePingAgent"] = func(args []string) CommandResult {
+		return CommandResult{Stdout: "BFGCL0793I: The agent SRC responded to the ping in 0.1 seconds.\n"}
+	}
+
+	// Cancel the context up front so that the agent is stopped as soon as it is ready.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if exitCode := runAgent(ctx); exitCode != MFT_CONT_SUCCESS_CODE_0 {
+		t.Fatalf("Expected exit code 0, found %d. Commands run: %v", exitCode, fake.commandNames())
+	}
+
+	expected := []string{"fteSetupCoordination", "fteObfuscate", "fteSetupCommands", "fteObfuscate",
+		"fteCreateAgent", "fteObfuscate", "fteStartAgent", "ftePingAgent", "fteStopAgent", "fteDeleteAgent"}
+	if !reflect.DeepEqual(fake.commandNames(), expected) {
+		t.Errorf("Expected commands %v, found %v", expected, fake.commandNames())
+	}
+	createArgs := strings.Join(fake.commandArgs("fteCreateAgent"), " ")
+	if !strings.Contains(createArgs, "-p COORDQM -agentName SRC -agentQMgr AGENTQM -agentQMgrHost localhost -agentQMgrPort 1414") {
+		t.Errorf("Unexpected fteCreateAgent arguments %s", createArgs)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "agents/SRC/UserSandboxes.xml")); err != nil {
+		t.Errorf("Sandbox configuration not written: %v", err)
+	}
+}
+
+// A failing MFT command stops the startup sequence with the matching exit code
+func TestRunAgentSetupCoordinationFails(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "agentconfig.json")
+	configData := `{
+		"coordinationQMgr":{"name":"COORDQM","host":"localhost"},
+		"commandQMgr":{"name":"CMDQM","host":"localhost"},
+		"agents":[{"name":"SRC","qmgrName":"AGENTQM","qmgrHost":"localhost"}]
+	}`
+	if err := os.WriteFile(configFile, []byte(configData), 0644); err != nil {
+		t.Fatal(err)
+	}
+	setTestEnv(t, MFT_AGENT_NAME, "SRC")
+	setTestEnv(t, MFT_AGENT_CONFIG_FILE, configFile)
+	setTestEnv(t, BFG_DATA, t.TempDir())
+	useTestRetrySleep(t)
+
+	fake := useFakeCommandRunner(t)
+	fake.handlers["fteSetupCoordination"] = func(args []string) CommandResult {
+		return CommandResult{ExitCode: 1, Stderr: "BFGCL0001E: failed", Err: errors.New("exit status 1")}
+	}
+
+	if exitCode := runAgent(context.Background()); exitCode != MFT_CONT_ERR_CODE_15 {
+		t.Errorf("Expected exit code %d, found %d", MFT_CONT_ERR_CODE_15, exitCode)
+	}
+	// The step is retried as per the default policy before giving up.
+	if !reflect.DeepEqual(fake.commandNames(), []string{"fteSetupCoordination", "fteSetupCoordination", "fteSetupCoordination"}) {
+		t.Errorf("Unexpected commands run %v", fake.commandNames())
+	}
+}
@@ -0,0 +1,356 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/**
+* Validates the agent configuration file against a JSON schema.
+*
+* The schema is embedded in the binary and is versioned through its file name.
+* Only the subset of JSON Schema keywords used by the schema is implemented:
+* type, properties, required, additionalProperties, items, minItems, enum,
+* minimum, maximum, minLength, pattern and local $ref to #/definitions.
+ */
+
+// Version of the agent configuration schema
+const AGENT_CONFIG_SCHEMA_VERSION = "v1"
+
+//go:embed schema/agentconfig-v1.json
+var agentConfigSchemaData []byte
+
+// Parsed schema, loaded on first use
+var agentConfigSchema map[string]interface{}
+var agentConfigSchemaOnce sync.Once
+var agentConfigSchemaErr error
+
+// A single schema violation with the JSON path of the offending element
+type SchemaViolation struct {
+	Path    string
+	Message string
+}
+
+func (v SchemaViolation) String() string {
+	return v.Path + ": " + v.Message
+}
+
+// Cache of compiled patterns used by the schema
+var schemaPatterns = map[string]*regexp.Regexp{}
+var schemaPatternsLock sync.Mutex
+
+// Validate the supplied configuration data against the agent configuration schema.
+// Returns a list of all violations found, an empty list if the document is valid.
+func ValidateConfigurationSchema(configData string) []SchemaViolation {
+	agentConfigSchemaOnce.Do(func() {
+		agentConfigSchemaErr = json.Unmarshal(agentConfigSchemaData, &agentConfigSchema)
+	})
+	if agentConfigSchemaErr != nil {
+		return []SchemaViolation{{Path: "$", Message: fmt.Sprintf("schema could not be loaded: %v", agentConfigSchemaErr)}}
+	}
+
+	var document interface{}
+	decoder := json.NewDecoder(strings.NewReader(configData))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return []SchemaViolation{{Path: "$", Message: fmt.Sprintf("not a valid JSON document: %v", err)}}
+	}
+
+	violations := make([]SchemaViolation, 0)
+	validateSchemaNode(agentConfigSchema, agentConfigSchema, document, "$", &violations)
+	return violations
+}
+
+// Validate a single node of the document against the given schema
+func validateSchemaNode(root map[string]interface{}, schema map[string]interface{}, value interface{}, path string, violations *[]SchemaViolation) {
+	schema = resolveSchemaRef(root, schema)
+	if schema == nil {
+		return
+	}
+
+	if types, ok := schema["type"]; ok {
+		if !matchesSchemaType(types, value) {
+			*violations = append(*violations, SchemaViolation{Path: path,
+				Message: fmt.Sprintf("expected %s but found %s", describeSchemaType(types), jsonTypeName(value))})
+			// No point checking further if the type is wrong.
+			return
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			if fmt.Sprint(allowed) == fmt.Sprint(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			allowedValues := make([]string, 0, len(enum))
+			for _, allowed := range enum {
+				allowedValues = append(allowedValues, fmt.Sprint(allowed))
+			}
+			*violations = append(*violations, SchemaViolation{Path: path,
+				Message: fmt.Sprintf("value %v is not one of %s", value, strings.Join(allowedValues, ", "))})
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		validateSchemaObject(root, schema, v, path, violations)
+	case []interface{}:
+		if minItems, ok := schema["minItems"].(float64); ok && float64(len(v)) < minItems {
+			*violations = append(*violations, SchemaViolation{Path: path,
+				Message: fmt.Sprintf("must contain at least %v item(s)", minItems)})
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validateSchemaNode(root, items, item, fmt.Sprintf("%s[%d]", path, i), violations)
+			}
+		}
+	case string:
+		if minLength, ok := schema["minLength"].(float64); ok && float64(len(strings.TrimSpace(v))) < minLength {
+			*violations = append(*violations, SchemaViolation{Path: path, Message: "must not be blank"})
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			re := compileSchemaPattern(pattern)
+			if re != nil && !re.MatchString(v) {
+				*violations = append(*violations, SchemaViolation{Path: path,
+					Message: fmt.Sprintf("value %q does not match pattern %s", v, pattern)})
+				return
+			}
+		}
+		// Numeric values supplied as strings are range checked too.
+		if n, ok := new(big.Float).SetString(v); ok {
+			validateSchemaRange(schema, n, path, violations)
+		}
+	case json.Number:
+		if n, ok := new(big.Float).SetString(v.String()); ok {
+			validateSchemaRange(schema, n, path, violations)
+		}
+	}
+}
+
+// Validate properties of an object
+func validateSchemaObject(root map[string]interface{}, schema map[string]interface{}, object map[string]interface{}, path string, violations *[]SchemaViolation) {
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			if _, present := object[name.(string)]; !present {
+				*violations = append(*violations, SchemaViolation{Path: path + "." + name.(string),
+					Message: "required attribute is missing"})
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	// Iterate in sorted order so that violations are reported consistently.
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		childPath := path + "." + key
+		if propertySchema, ok := properties[key].(map[string]interface{}); ok {
+			validateSchemaNode(root, propertySchema, object[key], childPath, violations)
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				*violations = append(*violations, SchemaViolation{Path: childPath,
+					Message: unknownAttributeMessage(key, properties)})
+			}
+		case map[string]interface{}:
+			validateSchemaNode(root, additional, object[key], childPath, violations)
+		}
+	}
+}
+
+// Check minimum and maximum constraints
+func validateSchemaRange(schema map[string]interface{}, value *big.Float, path string, violations *[]SchemaViolation) {
+	if minimum, ok := schema["minimum"].(float64); ok && value.Cmp(big.NewFloat(minimum)) < 0 {
+		*violations = append(*violations, SchemaViolation{Path: path,
+			Message: fmt.Sprintf("value %s is less than minimum %v", value.Text('f', -1), minimum)})
+	}
+	if maximum, ok := schema["maximum"].(float64); ok && value.Cmp(big.NewFloat(maximum)) > 0 {
+		*violations = append(*violations, SchemaViolation{Path: path,
+			Message: fmt.Sprintf("value %s is greater than maximum %v", value.Text('f', -1), maximum)})
+	}
+}
+
+// Resolve a local "#/definitions/..." reference
+func resolveSchemaRef(root map[string]interface{}, schema map[string]interface{}) map[string]interface{} {
+	for {
+		ref, ok := schema["$ref"].(string)
+		if !ok {
+			return schema
+		}
+		var node interface{} = root
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			parent, ok := node.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			node = parent[part]
+		}
+		resolved, ok := node.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		schema = resolved
+	}
+}
+
+// Return true if the value matches one of the types listed in the schema
+func matchesSchemaType(types interface{}, value interface{}) bool {
+	switch t := types.(type) {
+	case string:
+		return matchesSingleSchemaType(t, value)
+	case []interface{}:
+		for _, single := range t {
+			if matchesSingleSchemaType(single.(string), value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchesSingleSchemaType(typeName string, value interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return typeName == "object"
+	case []interface{}:
+		return typeName == "array"
+	case string:
+		return typeName == "string"
+	case bool:
+		return typeName == "boolean"
+	case nil:
+		return typeName == "null"
+	case json.Number:
+		if typeName == "number" {
+			return true
+		}
+		if typeName == "integer" {
+			_, err := v.Int64()
+			return err == nil
+		}
+	}
+	return false
+}
+
+func describeSchemaType(types interface{}) string {
+	if list, ok := types.([]interface{}); ok {
+		names := make([]string, 0, len(list))
+		for _, t := range list {
+			names = append(names, t.(string))
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(types)
+}
+
+func jsonTypeName(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// Build the message for an attribute that is not defined in the schema. Suggest
+// a known attribute if the name differs only slightly, e.g. commandsQMgr.
+func unknownAttributeMessage(key string, properties map[string]interface{}) string {
+	best := ""
+	bestDistance := 3
+	for name := range properties {
+		distance := editDistance(strings.ToLower(key), strings.ToLower(name))
+		if distance < bestDistance || (distance == bestDistance && best != "" && name < best) {
+			best = name
+			bestDistance = distance
+		}
+	}
+	if best != "" {
+		return fmt.Sprintf("unknown attribute, did you mean %q?", best)
+	}
+	return "unknown attribute"
+}
+
+// Levenshtein distance between two strings
+func editDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(minInt(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func compileSchemaPattern(pattern string) *regexp.Regexp {
+	schemaPatternsLock.Lock()
+	defer schemaPatternsLock.Unlock()
+	if re, ok := schemaPatterns[pattern]; ok {
+		return re
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		re = nil
+	}
+	schemaPatterns[pattern] = re
+	return re
+}
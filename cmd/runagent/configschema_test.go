@@ -0,0 +1,92 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
+)
+
+// Sample configuration files shipped with the tests must conform to the schema
+func TestValidateConfigurationSchemaValidFiles(t *testing.T) {
+	for _, fileName := range []string{"./data/test_agcfg.json", "./data/ocpagcfg.json"} {
+		configData, err := utils.ReadConfigurationDataFromFile(fileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		violations := ValidateConfigurationSchema(configData)
+		if len(violations) > 0 {
+			t.Errorf("%s: unexpected schema violations %v", fileName, violations)
+		}
+	}
+}
+
+// Typos and type errors must be reported with the JSON path of the attribute
+func TestValidateConfigurationSchemaInvalid(t *testing.T) {
+	configData := `{
+		"coordinationQMgr":{"name":"QM1","host":"localhost","port":"14x4"},
+		"commandsQMgr":{"name":"QM1","host":"localhost"},
+		"agents":[{
+			"name":"SRC",
+			"type":"BRIDGE",
+			"qmgrName":"QM1",
+			"qmgrPort":70000,
+			"cleanOnStart":"everything",
+			"deleteOnTermination":"yes",
+			"protocolBridge":{"serverType":"SFTP"},
+			"protocolServers":[{"name":"sftp","type":"SCP","host":"localhost"}]
+		}]
+	}`
+
+	expected := []string{
+		"$.commandQMgr: required attribute is missing",
+		"$.commandsQMgr: unknown attribute, did you mean \"commandQMgr\"?",
+		"$.coordinationQMgr.port: value \"14x4\" does not match pattern",
+		"$.agents[0].qmgrHost: required attribute is missing",
+		"$.agents[0].cleanOnStart: value everything is not one of",
+		"$.agents[0].deleteOnTermination: value \"yes\" does not match pattern",
+		"$.agents[0].protocolBridge: unknown attribute",
+		"$.agents[0].protocolServers[0].type: value \"SCP\" does not match pattern",
+		"$.agents[0].qmgrPort: value 70000 is greater than maximum 65535",
+	}
+
+	violations := ValidateConfigurationSchema(configData)
+	if len(violations) != len(expected) {
+		t.Errorf("Expected %d violations, found %d: %v", len(expected), len(violations), violations)
+	}
+	for _, message := range expected {
+		found := false
+		for _, violation := range violations {
+			if strings.HasPrefix(violation.String(), message) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("Violation %q not reported. Reported: %v", message, violations)
+		}
+	}
+}
+
+// Malformed documents are reported as a single violation
+func TestValidateConfigurationSchemaMalformed(t *testing.T) {
+	violations := ValidateConfigurationSchema("{\"agents\":[")
+	if len(violations) != 1 || violations[0].Path != "$" {
+		t.Errorf("Expected a single violation for malformed JSON, found %v", violations)
+	}
+}
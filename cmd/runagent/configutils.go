@@ -20,12 +20,10 @@ package main
 * configuration.
  */
 import (
-	"bytes"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"os/user"
 	"strconv"
 	"strings"
@@ -78,6 +76,31 @@ func IsCommandTracingEnabled() bool {
 	return commandTraceEnabled
 }
 
+// Read a numeric environment variable, such as an interval or a number of
+// days. Values that are not numbers, or are below the minimum, are reported
+// and ignored.
+func envNumber(name string, minimum float64) (float64, bool) {
+	value, set, valid := lookupEnvNumber(name, minimum)
+	if set && !valid {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_ENV_NUMBER_INVALID_0167, os.Getenv(name), name, minimum))
+	}
+	return value, set && valid
+}
+
+// Read a numeric environment variable. Returns whether it is set, and whether
+// its value is a number of at least the minimum.
+func lookupEnvNumber(name string, minimum float64) (float64, bool, bool) {
+	valueStr, set := os.LookupEnv(name)
+	if !set || strings.Trim(valueStr, TEXT_TRIM) == TEXT_BLANK {
+		return 0, false, false
+	}
+	value, err := strconv.ParseFloat(strings.Trim(valueStr, TEXT_TRIM), 64)
+	if err != nil || value < minimum {
+		return 0, true, false
+	}
+	return value, true, true
+}
+
 // Return command trace path
 func GetCommandTracePath() string {
 	commandTracePath, commandTracePathSet := os.LookupEnv(BFG_DATA)
@@ -195,6 +218,37 @@ func SetupCredentials(mqmftCredentialsXmlFileName string, bufferCred string) err
 	return nil
 }
 
+/**
+* WriteCredentialsFile
+*
+* Writes the credentials document to the specified file and encrypts it. In dry run
+* mode passwords are redacted and the file is left unencrypted so it can be reviewed.
+ */
+func WriteCredentialsFile(credentialsFile string, credentialsDoc *xmldom.Document, dryRun bool) error {
+	if dryRun {
+		redactCredentials(credentialsDoc.Root)
+	}
+
+	err := SetupCredentials(credentialsFile, credentialsDoc.XMLPretty())
+	if err == nil && !dryRun {
+		// Attempt to encrypt the credentials file with a fixed key
+		EncryptCredentialsFile(credentialsFile)
+	}
+	return err
+}
+
+// Replace password attributes of the given node and its children
+func redactCredentials(node *xmldom.Node) {
+	for _, attr := range node.Attributes {
+		if strings.EqualFold(attr.Name, "mqPassword") || strings.EqualFold(attr.Name, "password") {
+			attr.Value = TEXT_REDACTED
+		}
+	}
+	for _, child := range node.Children {
+		redactCredentials(child)
+	}
+}
+
 /**
 * Update XML data with credentials of queue manager
  */
@@ -279,39 +333,16 @@ func InitializeCredentialsDocumentWriter() *xmldom.Document {
 * Returns error if the method fails to encrypt the file.
  */
 func EncryptCredentialsFile(credentialsFile string) error {
-	var outb, errb bytes.Buffer
 	if logLevel >= LOG_LEVEL_VERBOSE {
 		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CRED_ENCRYPTING_0058, credentialsFile))
 	}
 
-	// Get the path of MFT fteObfuscate command.
-	cmdObfuscatePath, lookErr := exec.LookPath("fteObfuscate")
-	if lookErr != nil {
-		return lookErr
-	}
-
-	var cmdArgs []string
-	cmdArgs = append(cmdArgs, cmdObfuscatePath, "-f", credentialsFile)
-	if commandTracingEnabled {
-		cmdArgs = append(cmdArgs, "-trace", "com.ibm.wmqfte=all")
-		cmdTracePath := GetCommandTracePath()
-		if len(cmdTracePath) > 0 {
-			cmdArgs = append(cmdArgs, "-tracePath", cmdTracePath)
-		}
-	}
-
-	// Encrypt the credentials file with default key
-	cmdObfucateCmd := &exec.Cmd{
-		Path: cmdObfuscatePath,
-		Args: cmdArgs,
-	}
-
-	// Reuse the same buffer
-	cmdObfucateCmd.Stdout = &outb
-	cmdObfucateCmd.Stderr = &errb
-	// Execute the fteObfuscate command. Log an error an exit in case of any error.
-	if err := cmdObfucateCmd.Run(); err != nil {
-		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_ERROR_0042, outb.String(), errb.String()))
+	// Encrypt the credentials file with default key. Log an error in case of any error.
+	result := runMFTCommand("fteObfuscate", "-f", credentialsFile)
+	if result.NotFound() {
+		return result.Err
+	} else if result.Err != nil {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_ERROR_0042, result.Stdout, result.Stderr))
 	} else {
 		if logLevel >= LOG_LEVEL_VERBOSE {
 			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CRED_ENCRYPTED_0059, credentialsFile))
@@ -19,7 +19,9 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
 	"github.com/tidwall/gjson"
@@ -129,42 +131,37 @@ func TestSetupCredentials(t *testing.T) {
 	}
 }
 
-func TestCreateKeyStore(t *testing.T) {
-	certFilePath := "NonExistent.crt"
-	keyStorePath := "key.p12"
-	keyStorePwd := "StrongPassword"
-	keyStoreDir := "data/tls"
+// Numeric environment variables that are not valid are reported with their own
+// message and ignored
+func TestEnvNumber(t *testing.T) {
+	var lock sync.Mutex
+	var logged []string
+	utils.SetPrintLogHook(func(logTime time.Time, msg string) {
+		lock.Lock()
+		defer lock.Unlock()
+		if strings.Contains(msg, MFT_CERT_CHECK_INTERVAL) {
+			logged = append(logged, msg)
+		}
+	})
+	defer utils.SetPrintLogHook(nil)
 
-	// Test for invalid certificate file path
-	certNonExistentFileErr := CreateKeyStore(keyStoreDir, keyStorePath, certFilePath, keyStorePwd)
-	if certNonExistentFileErr != nil {
-		t.Log(certNonExistentFileErr.Error())
-	} else {
-		t.Fail()
+	setTestEnv(t, MFT_CERT_CHECK_INTERVAL, " 30 ")
+	if value, ok := envNumber(MFT_CERT_CHECK_INTERVAL, 0); !ok || value != 30 {
+		t.Errorf("Expected 30, got %v %v", value, ok)
 	}
-
-	err := os.MkdirAll("data/tls", 0700)
-	if err != nil {
-		t.Log(err.Error())
-		t.Fail()
+	for _, invalid := range []string{"-1", "soon"} {
+		setTestEnv(t, MFT_CERT_CHECK_INTERVAL, invalid)
+		if _, ok := envNumber(MFT_CERT_CHECK_INTERVAL, 0); ok {
+			t.Errorf("Expected %s to be ignored", invalid)
+		}
 	}
-	certFilePath = "./data/certificate.crt"
-	// Test with a valid certificate
-	certExistErr := CreateKeyStore(keyStoreDir, keyStorePath, certFilePath, keyStorePwd)
-	if certExistErr != nil {
-		t.Log(certExistErr.Error())
-		t.Fail()
-	} else {
-		t.Log("Certificate added")
+	setTestEnv(t, MFT_CERT_CHECK_INTERVAL, "")
+	if _, ok := envNumber(MFT_CERT_CHECK_INTERVAL, 0); ok {
+		t.Error("Expected a blank value to be ignored")
 	}
-	_, err = os.Lstat(keyStorePath)
-	if err == nil {
-		rErr := os.RemoveAll(keyStorePath)
-		if rErr != nil {
-			t.Log(rErr.Error())
-		}
-	} else {
-		t.Log(err.Error())
-		// Just log error if we are unable to delete keystore
+	lock.Lock()
+	defer lock.Unlock()
+	if len(logged) != 2 || !strings.HasPrefix(logged[0], "MFTC0167W") || !strings.HasPrefix(logged[1], "MFTC0167W") {
+		t.Errorf("Unexpected messages %v", logged)
 	}
 }
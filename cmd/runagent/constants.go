@@ -15,6 +15,8 @@ limitations under the License.
 */
 package main
 
+import "time"
+
 /**
 * This file contains the list of constants used by the program
  */
@@ -71,17 +73,54 @@ const LOG_TYPE_CONSOLE = "console"
 // Server type
 const LOG_SERVER_TYPE_DNA = "logDNA"
 const LOG_SERVER_TYPE_ELK = "elk"
+const LOG_SERVER_TYPE_SPLUNK = "splunk"
+const LOG_SERVER_TYPE_LOKI = "loki"
+const LOG_SERVER_TYPE_SYSLOG = "syslog"
 const LOG_SERVER_TYPE_DNA_NUM = 1
 const LOG_SERVER_TYPE_ELK_NUM = 2
+const LOG_SERVER_TYPE_SPLUNK_NUM = 3
+const LOG_SERVER_TYPE_LOKI_NUM = 4
+const LOG_SERVER_TYPE_SYSLOG_NUM = 5
 
 const KEY_TYPE = "type"
+const KEY_NAME = "name"
+const KEY_FILTER = "filter"
 const KEY_URL_DNA = "logDNA.url"
 const KEY_INJESTION_DNA = "logDNA.injestionKey"
 const KEY_URL_ELK = "elk.url"
+const KEY_INDEX_ELK = "elk.index"
+const KEY_USERNAME_ELK = "elk.username"
+const KEY_PASSWORD_ELK = "elk.password"
+const KEY_API_KEY_ELK = "elk.apiKey"
+const KEY_CA_CERT_ELK = "elk.caCertificate"
+const KEY_URL_SPLUNK = "splunk.url"
+const KEY_TOKEN_SPLUNK = "splunk.token"
+const KEY_INDEX_SPLUNK = "splunk.index"
+const KEY_SOURCE_TYPE_SPLUNK = "splunk.sourceType"
+const KEY_SOURCE_SPLUNK = "splunk.source"
+const KEY_URL_LOKI = "loki.url"
+const KEY_USERNAME_LOKI = "loki.username"
+const KEY_PASSWORD_LOKI = "loki.password"
+const KEY_TENANT_LOKI = "loki.tenantId"
+const KEY_ADDRESS_SYSLOG = "syslog.address"
+const KEY_PROTOCOL_SYSLOG = "syslog.protocol"
+const KEY_FACILITY_SYSLOG = "syslog.facility"
+const KEY_APP_NAME_SYSLOG = "syslog.appName"
+const KEY_CA_CERT_SYSLOG = "syslog.caCertificate"
+const KEY_DIAGNOSTICS_SYSLOG = "syslog.diagnostics"
+
+// Options of the transfer log publisher
+const KEY_PUBLISHER = "publisher"
+
+// Time allowed to publish queued transfer log entries when the container stops
+const TLOG_PUBLISH_STOP_TIMEOUT = 5 * time.Second
 
 const DIR_AGENT_LOGS = "/mqft/logs/"
 const DIR_AGENTS = "/agents/"
 
+// Directory under BFG_DATA holding the transfer log publishing checkpoint and spool of each agent
+const DIR_PUBLISH_SPOOL = "/mqft/publish/"
+
 // License file path
 const DIR_LICENSE_FILES = "/opt/mqm/mqft/licences/"
 
@@ -112,6 +151,9 @@ const TEXT_BLANK = ""
 const TEXT_YES = "yes"
 const TEXT_NO = "no"
 
+// Replacement text for secrets in generated files
+const TEXT_REDACTED = "********"
+
 // Credential file names
 const MFT_CMD_CRED_SLASH = "/cmdcredentials.xml"
 const MFT_CORD_CRED_SLASH = "/coordcredentials.xml"
@@ -160,6 +202,9 @@ const MFT_CONT_ERR_CODE_21 = 21
 const MFT_CONT_ERR_CODE_22 = 22
 const MFT_CONT_ERR_CODE_23 = 23
 const MFT_CONT_ERR_CODE_24 = 24
+const MFT_CONT_ERR_CODE_25 = 25
+const MFT_CONT_ERR_CODE_26 = 26
+const MFT_CONT_ERR_CODE_27 = 27
 
 // Data types used by ProtocolBridgeProperties.xml
 const DATA_TYPE_STRING = 1
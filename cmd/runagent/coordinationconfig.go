@@ -16,13 +16,10 @@ limitations under the License.
 package main
 
 import (
-	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"os"
-	"os/exec"
 	"path/filepath"
-	"strings"
 
 	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
 	"github.com/subchen/go-xmldom"
@@ -31,135 +28,125 @@ import (
 )
 
 // Setup coordination configuration for agent.
-func SetupCoordination(allAgentConfig string, bfgDataPath string, agentNameEnv string) bool {
-	// Variables for Stdout and Stderr
-	var outb, errb bytes.Buffer
+func SetupCoordination(ctx context.Context, allAgentConfig string, bfgDataPath string, agentNameEnv string) bool {
 	var created bool = false
 	coordinationQueueManagerName := gjson.Get(allAgentConfig, "coordinationQMgr.name").String()
 	// Setup coordination configuration
 	utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CFG_CORD_CONFIG_MSG_0024, agentNameEnv, coordinationQueueManagerName))
 
-	// Get the path of MFT fteSetupCoordination command.
-	cmdCoordPath, lookPathErr := exec.LookPath("fteSetupCoordination")
-	if lookPathErr == nil {
-		var port string
-		var channel string
-		var host string
+	var port string
+	var channel string
+	var host string
 
-		if gjson.Get(allAgentConfig, "coordinationQMgr.host").Exists() {
-			host = gjson.Get(allAgentConfig, "coordinationQMgr.host").String()
-		} else {
-			host = "localhost"
-		}
+	if gjson.Get(allAgentConfig, "coordinationQMgr.host").Exists() {
+		host = gjson.Get(allAgentConfig, "coordinationQMgr.host").String()
+	} else {
+		host = "localhost"
+	}
 
-		if gjson.Get(allAgentConfig, "coordinationQMgr.port").Exists() {
-			port = gjson.Get(allAgentConfig, "coordinationQMgr.port").String()
-		} else {
-			port = "1414"
-		}
+	if gjson.Get(allAgentConfig, "coordinationQMgr.port").Exists() {
+		port = gjson.Get(allAgentConfig, "coordinationQMgr.port").String()
+	} else {
+		port = "1414"
+	}
 
-		if gjson.Get(allAgentConfig, "coordinationQMgr.channel").Exists() {
-			channel = gjson.Get(allAgentConfig, "coordinationQMgr.channel").String()
-		} else {
-			channel = "SYSTEM.DEF.SVRCONN"
-		}
+	if gjson.Get(allAgentConfig, "coordinationQMgr.channel").Exists() {
+		channel = gjson.Get(allAgentConfig, "coordinationQMgr.channel").String()
+	} else {
+		channel = "SYSTEM.DEF.SVRCONN"
+	}
 
-		var cmdArgs []string
-		cmdArgs = append(cmdArgs, cmdCoordPath,
-			"-coordinationQMgr", coordinationQueueManagerName,
-			"-coordinationQMgrHost", host,
-			"-coordinationQMgrPort", port, "-coordinationQMgrChannel", channel, "-f", "-default")
-		if commandTracingEnabled {
-			cmdArgs = append(cmdArgs, "-trace", "com.ibm.wmqfte=all")
-			cmdTracePath := GetCommandTracePath()
-			if len(cmdTracePath) > 0 {
-				cmdArgs = append(cmdArgs, "-tracePath", cmdTracePath)
-			}
+	// Execute the fteSetupCoordination command. Log an error an exit in case of any error.
+	result := runMFTStep(ctx, STEP_COORDINATION, "fteSetupCoordination",
+		"-coordinationQMgr", coordinationQueueManagerName,
+		"-coordinationQMgrHost", host,
+		"-coordinationQMgrPort", port, "-coordinationQMgrChannel", channel, "-f", "-default")
+	if result.NotFound() {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_NOT_FOUND_0028, result.Err))
+	} else if result.Err != nil {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_ERROR_0042, result.Stdout, result.Stderr))
+	} else {
+		if logLevel >= LOG_LEVEL_VERBOSE {
+			utils.PrintLog(fmt.Sprintf("Command output: %s", result.Stdout))
 		}
 
-		cmdSetupCoord := &exec.Cmd{
-			Path: cmdCoordPath,
-			Args: cmdArgs,
+		// Write coordination properties and credentials
+		created = ConfigureCoordination(allAgentConfig, bfgDataPath, bfgDataPath, false)
+	}
+
+	return created
+}
+
+// Update coordination properties file with additional attributes specified and create
+// the credentials file for coordination queue manager. Files are written under outputRoot
+// while the paths referenced from them are relative to bfgDataPath. When dryRun is true
+// no external commands are run and secrets are redacted from the generated files.
+func ConfigureCoordination(allAgentConfig string, bfgDataPath string, outputRoot string, dryRun bool) bool {
+	var created bool = false
+	coordinationQueueManagerName := gjson.Get(allAgentConfig, "coordinationQMgr.name").String()
+	coordinationPropertiesFile := outputRoot + MFT_CONFIG_PATH_SUFFIX + coordinationQueueManagerName + MFT_CORD_PROPS_SLASH
+	// Coordination queue manager credentials file
+	var coordCredFilePath string = bfgDataPath + MFT_CONFIG_PATH_SUFFIX + coordinationQueueManagerName + MFT_CORD_CRED_SLASH
+
+	// Start XML document for credentials file
+	credentialsDoc := InitializeCredentialsDocumentWriter()
+
+	// Configure TLS security
+	created, allAgentConfig = configTLSCoordination(allAgentConfig, credentialsDoc, coordCredFilePath, dryRun)
+
+	if created {
+		// If a credentials file has been specified as environment variable, then set it here
+		if gjson.Get(allAgentConfig, "coordinationQMgr.qmgrCredentials").Exists() {
+			// Write coordination queue manager credentials
+			UpdateXmlWithQmgrCredentials(credentialsDoc, gjson.Get(allAgentConfig, "coordinationQMgr.qmgrCredentials").String(), coordinationQueueManagerName)
 		}
 
-		// Execute the fteSetupCoordination command. Log an error an exit in case of any error.
-		cmdSetupCoord.Stdout = &outb
-		cmdSetupCoord.Stderr = &errb
-		if err := cmdSetupCoord.Run(); err != nil {
-			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_ERROR_0042, outb.String(), errb.String()))
+		errSetCred := WriteCredentialsFile(outputRoot+MFT_CONFIG_PATH_SUFFIX+coordinationQueueManagerName+MFT_CORD_CRED_SLASH, credentialsDoc, dryRun)
+		if errSetCred == nil {
+			allAgentConfig, _ = sjson.Set(allAgentConfig, "coordinationQMgr.additionalProperties.coordinationQMgrAuthenticationCredentialsFile", coordCredFilePath)
 		} else {
-			if logLevel >= LOG_LEVEL_VERBOSE {
-				utils.PrintLog(fmt.Sprintf("Command output: %s", outb.String()))
-			}
+			utils.PrintLog(errSetCred.Error())
+		}
 
-			// Update coordination properties file with additional attributes specified.
-			coordinationPropertiesFile := bfgDataPath + MFT_CONFIG_PATH_SUFFIX + coordinationQueueManagerName + MFT_CORD_PROPS_SLASH
-			// Coordination queue manager credentials file
-			var coordCredFilePath string = bfgDataPath + MFT_CONFIG_PATH_SUFFIX + coordinationQueueManagerName + MFT_CORD_CRED_SLASH
-
-			// Start XML document for credentials file
-			credentialsDoc := InitializeCredentialsDocumentWriter()
-
-			// Configure TLS security
-			created, allAgentConfig = configTLSCoordination(allAgentConfig, credentialsDoc, coordCredFilePath)
-
-			if created {
-				// If a credentials file has been specified as environment variable, then set it here
-				if gjson.Get(allAgentConfig, "coordinationQMgr.qmgrCredentials").Exists() {
-					// Write coordination queue manager credentials
-					UpdateXmlWithQmgrCredentials(credentialsDoc, gjson.Get(allAgentConfig, "coordinationQMgr.qmgrCredentials").String(), coordinationQueueManagerName)
-				}
-
-				errSetCred := SetupCredentials(coordCredFilePath, credentialsDoc.XMLPretty())
-				if errSetCred == nil {
-					// Attempt to encrypt the credentials file with a fixed key
-					EncryptCredentialsFile(coordCredFilePath)
-					allAgentConfig, _ = sjson.Set(allAgentConfig, "coordinationQMgr.additionalProperties.coordinationQMgrAuthenticationCredentialsFile", coordCredFilePath)
-				} else {
-					utils.PrintLog(errSetCred.Error())
-				}
-
-				if logLevel >= LOG_LEVEL_VERBOSE && len(allAgentConfig) > 0 {
-					utils.PrintLog(fmt.Sprintf(utils.MFT_UPDATED_CONFIGURATION, allAgentConfig))
-				}
+		if logLevel >= LOG_LEVEL_VERBOSE && len(allAgentConfig) > 0 {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_UPDATED_CONFIGURATION, utils.RedactResolvedReferences(allAgentConfig, TEXT_REDACTED)))
+		}
 
-				// Update coordination properties file
-				err := UpdateProperties(coordinationPropertiesFile, allAgentConfig, "coordinationQMgr.additionalProperties")
-				if err != nil {
-					utils.PrintLog(err.Error())
-				} else {
-					if logLevel >= LOG_LEVEL_VERBOSE && len(coordCredFilePath) > 0 {
-						utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CFG_CORD_CONFIG_CRED_PATH_0027, coordCredFilePath))
-					}
-					utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CORD_SETUP_COMP_0054, coordinationQueueManagerName))
-					created = true
-				}
+		// Update coordination properties file
+		err := UpdateProperties(coordinationPropertiesFile, allAgentConfig, "coordinationQMgr.additionalProperties")
+		if err != nil {
+			utils.PrintLog(err.Error())
+		} else {
+			if logLevel >= LOG_LEVEL_VERBOSE && len(coordCredFilePath) > 0 {
+				utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CFG_CORD_CONFIG_CRED_PATH_0027, coordCredFilePath))
 			}
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CORD_SETUP_COMP_0054, coordinationQueueManagerName))
+			created = true
 		}
-	} else {
-		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_NOT_FOUND_0028, lookPathErr))
 	}
-
 	return created
 }
 
 // Create keystore using certificate provided if available. We need cipher name
 // at least public key environment variable to be set.
-func configTLSCoordination(allAgentConfig string, credentialsDoc *xmldom.Document, coordCredFilePath string) (bool, string) {
+func configTLSCoordination(allAgentConfig string, credentialsDoc *xmldom.Document, coordCredFilePath string, dryRun bool) (bool, string) {
 	var created bool
 
-	cipherName, cipherSet := os.LookupEnv(MFT_COORD_QMGR_CIPHER)
-	if cipherSet && len(strings.Trim(cipherName, TEXT_TRIM)) > 0 {
+	settings := coordinationTLSSettings(allAgentConfig)
+	if settings.enabled() {
 		// Generate password for keystore
-		password := generateRandomPassword()
-		// See if we have public key file
-		publicKeyCertPath := getKeyFile(coordinationQMCertPath, ".crt")
-		if len(publicKeyCertPath) > 0 {
-			// Trust Keystore details - public key of queue manager
-			errCreateKeyStore := CreateKeyStore(KEYSTORES_PATH, COORD_QM_TRUSTSTORE, publicKeyCertPath, password)
+		password, err := generateRandomPassword()
+		if err != nil {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_KEYSTORE_CREATE_FAILED, COORD_QM_TRUSTSTORE, err.Error()))
+			return false, allAgentConfig
+		}
+		// See if we have certificate files
+		if settings.hasTrustedCertificates() {
+			// Trust Keystore details - certificates of queue manager and its CAs
+			errCreateKeyStore := prepareTrustStore(KEYSTORES_PATH, COORD_QM_TRUSTSTORE, settings, password, dryRun)
 			if errCreateKeyStore == nil {
 				// Update coordination properties file
-				allAgentConfig, _ = sjson.Set(allAgentConfig, "coordinationQMgr.additionalProperties.coordinationSslCipherSpec", cipherName)
+				allAgentConfig, _ = sjson.Set(allAgentConfig, "coordinationQMgr.additionalProperties.coordinationSslCipherSpec", settings.cipherSpec)
 				allAgentConfig, _ = sjson.Set(allAgentConfig, "coordinationQMgr.additionalProperties.coordinationSslTrustStore", filepath.Join(KEYSTORES_PATH, COORD_QM_TRUSTSTORE))
 				allAgentConfig, _ = sjson.Set(allAgentConfig, "coordinationQMgr.additionalProperties.coordinationSslTrustStoreType", "pkcs12")
 				allAgentConfig, _ = sjson.Set(allAgentConfig, "coordinationQMgr.additionalProperties.coordinationSslTrustStoreCredentialsFile", coordCredFilePath)
@@ -171,19 +158,23 @@ func configTLSCoordination(allAgentConfig string, credentialsDoc *xmldom.Documen
 			}
 		}
 
+		// Distinguished name the certificate of the queue manager must match
+		if len(settings.peerName) > 0 {
+			allAgentConfig, _ = sjson.Set(allAgentConfig, "coordinationQMgr.additionalProperties.coordinationSslPeerName", settings.peerName)
+		}
+
 		// Do we have any private key
-		privateKeyCertPath := getKeyFile(coordinationQMCertPath, ".key")
-		if len(privateKeyCertPath) > 0 {
-			errCreateSslStore := CreateKeyStore(KEYSTORES_PATH, COORD_QM_KEYSTORE, privateKeyCertPath, password)
+		if settings.hasPrivateKey() {
+			errCreateSslStore := prepareClientKeyStore(KEYSTORES_PATH, COORD_QM_KEYSTORE, settings, password, dryRun)
 			if errCreateSslStore == nil {
-				allAgentConfig, _ = sjson.Set(allAgentConfig, "coordinationQMgr.additionalProperties.coordinationSslCipherSpec", cipherName)
+				allAgentConfig, _ = sjson.Set(allAgentConfig, "coordinationQMgr.additionalProperties.coordinationSslCipherSpec", settings.cipherSpec)
 				allAgentConfig, _ = sjson.Set(allAgentConfig, "coordinationQMgr.additionalProperties.coordinationSslKeyStore", filepath.Join(KEYSTORES_PATH, COORD_QM_KEYSTORE))
 				allAgentConfig, _ = sjson.Set(allAgentConfig, "coordinationQMgr.additionalProperties.coordinationSslKeyStoreType", "pkcs12")
 				allAgentConfig, _ = sjson.Set(allAgentConfig, "coordinationQMgr.additionalProperties.coordinationSslKeyStoreCredentialsFile", coordCredFilePath)
 				UpdateXmlWithKeyStoreCredentials(credentialsDoc, filepath.Join(KEYSTORES_PATH, COORD_QM_KEYSTORE), password)
 				created = true
 			} else {
-				utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_KEYSTORE_CREATE_FAILED, COORD_QM_TRUSTSTORE, errCreateSslStore.Error()))
+				utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_KEYSTORE_CREATE_FAILED, COORD_QM_KEYSTORE, errCreateSslStore.Error()))
 				created = false
 			}
 		} else {
@@ -0,0 +1,291 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ibm-messaging/mq-container-mft/pkg/logger"
+	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
+	"github.com/tidwall/gjson"
+)
+
+/*
+ * Destinations of transfer log entries.
+ *
+ * The transfer log publish configuration is either a single destination or an
+ * array of them. Each destination has its own server, publisher and spool, and
+ * optionally a filter selecting the entries published to it.
+ */
+
+// A server to which transfer log entries are published
+type transferLogDestination struct {
+	// Name of the destination, used for its spool directory
+	name          string
+	loggerName    string
+	url           string
+	key           string
+	serverType    int16
+	options       logger.PublisherOptions
+	serverOptions logger.ServerOptions
+	filter        transferLogFilter
+}
+
+// Selects the transfer log entries published to a destination. An entry is
+// selected if it matches every criterion given, by matching any of its values.
+type transferLogFilter struct {
+	levels            []string
+	events            []string
+	sourceAgents      []string
+	destinationAgents []string
+	resultCodes       []int64
+}
+
+// Names of the kinds of transfer events in filters
+var transferEventNames = map[int]string{
+	transferEventStarted:   "started",
+	transferEventProgress:  "progress",
+	transferEventCompleted: "completed",
+}
+
+// Returns the destinations in the transfer log publish configuration.
+// Destinations that are not valid are reported and ignored.
+func transferLogDestinations(serverLogData string, bfgDataPath string, coordinationQMgr string,
+	agentName string) []transferLogDestination {
+	config := gjson.Parse(serverLogData)
+	if !config.IsArray() {
+		// A single destination keeps the spool directory used before arrays
+		// were supported.
+		destination, valid := parseTransferLogDestination(serverLogData, bfgDataPath, coordinationQMgr, agentName, agentName)
+		if !valid {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLOG_DESTINATION_INVALID_0123, config.Get(KEY_TYPE).String()))
+			return nil
+		}
+		return []transferLogDestination{destination}
+	}
+
+	var destinations []transferLogDestination
+	names := make(map[string]bool)
+	for i, item := range config.Array() {
+		name := item.Get(KEY_NAME).String()
+		if len(name) == 0 {
+			name = item.Get(KEY_TYPE).String()
+		}
+		name = destinationDirName(name)
+		if names[name] {
+			name += "-" + strconv.Itoa(i+1)
+		}
+		names[name] = true
+		destination, valid := parseTransferLogDestination(item.Raw, bfgDataPath, coordinationQMgr, agentName,
+			agentName+"/"+name)
+		if !valid {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLOG_DESTINATION_INVALID_0123, name))
+			continue
+		}
+		destination.name = name
+		destinations = append(destinations, destination)
+	}
+	return destinations
+}
+
+// Returns a name usable as a directory name
+func destinationDirName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, strings.Trim(name, TEXT_TRIM))
+	if len(name) == 0 {
+		return "destination"
+	}
+	return name
+}
+
+// Returns the destination described by a configuration. Returns false if the
+// type is not known or details of the server are missing.
+func parseTransferLogDestination(serverLogData string, bfgDataPath string, coordinationQMgr string,
+	agentName string, spoolName string) (transferLogDestination, bool) {
+	destination := transferLogDestination{
+		name:       spoolName,
+		loggerName: agentName,
+		options:    transferLogPublisherOptions(serverLogData, bfgDataPath, spoolName),
+		filter:     parseTransferLogFilter(gjson.Get(serverLogData, KEY_FILTER)),
+	}
+	logType := strings.Trim(gjson.Get(serverLogData, KEY_TYPE).String(), TEXT_BLANK)
+	switch {
+	case strings.EqualFold(logType, LOG_SERVER_TYPE_DNA):
+		if !gjson.Get(serverLogData, KEY_URL_DNA).Exists() || !gjson.Get(serverLogData, KEY_INJESTION_DNA).Exists() {
+			return destination, false
+		}
+		destination.loggerName = "IBMMQMFT Agent " + agentName
+		destination.url = gjson.Get(serverLogData, KEY_URL_DNA).String()
+		destination.key = gjson.Get(serverLogData, KEY_INJESTION_DNA).String()
+		destination.serverType = LOG_SERVER_TYPE_DNA_NUM
+	case strings.EqualFold(logType, LOG_SERVER_TYPE_ELK):
+		if !gjson.Get(serverLogData, KEY_URL_ELK).Exists() {
+			return destination, false
+		}
+		destination.url = gjson.Get(serverLogData, KEY_URL_ELK).String()
+		destination.serverType = LOG_SERVER_TYPE_ELK_NUM
+		destination.serverOptions = logger.ServerOptions{
+			Elk: logger.ElkOptions{
+				Index:      gjson.Get(serverLogData, KEY_INDEX_ELK).String(),
+				Username:   gjson.Get(serverLogData, KEY_USERNAME_ELK).String(),
+				Password:   gjson.Get(serverLogData, KEY_PASSWORD_ELK).String(),
+				APIKey:     gjson.Get(serverLogData, KEY_API_KEY_ELK).String(),
+				CACertFile: gjson.Get(serverLogData, KEY_CA_CERT_ELK).String(),
+			},
+		}
+	case strings.EqualFold(logType, LOG_SERVER_TYPE_SPLUNK):
+		if !gjson.Get(serverLogData, KEY_URL_SPLUNK).Exists() || !gjson.Get(serverLogData, KEY_TOKEN_SPLUNK).Exists() {
+			return destination, false
+		}
+		destination.url = gjson.Get(serverLogData, KEY_URL_SPLUNK).String()
+		destination.key = gjson.Get(serverLogData, KEY_TOKEN_SPLUNK).String()
+		destination.serverType = LOG_SERVER_TYPE_SPLUNK_NUM
+		destination.serverOptions = logger.ServerOptions{
+			Splunk: logger.SplunkOptions{
+				Index:      gjson.Get(serverLogData, KEY_INDEX_SPLUNK).String(),
+				SourceType: gjson.Get(serverLogData, KEY_SOURCE_TYPE_SPLUNK).String(),
+				Source:     gjson.Get(serverLogData, KEY_SOURCE_SPLUNK).String(),
+			},
+		}
+	case strings.EqualFold(logType, LOG_SERVER_TYPE_LOKI):
+		if !gjson.Get(serverLogData, KEY_URL_LOKI).Exists() {
+			return destination, false
+		}
+		destination.url = gjson.Get(serverLogData, KEY_URL_LOKI).String()
+		destination.serverType = LOG_SERVER_TYPE_LOKI_NUM
+		destination.serverOptions = logger.ServerOptions{
+			Loki: logger.LokiOptions{
+				CoordinationQMgr: coordinationQMgr,
+				Username:         gjson.Get(serverLogData, KEY_USERNAME_LOKI).String(),
+				Password:         gjson.Get(serverLogData, KEY_PASSWORD_LOKI).String(),
+				TenantID:         gjson.Get(serverLogData, KEY_TENANT_LOKI).String(),
+			},
+		}
+	case strings.EqualFold(logType, LOG_SERVER_TYPE_SYSLOG):
+		if !gjson.Get(serverLogData, KEY_ADDRESS_SYSLOG).Exists() {
+			return destination, false
+		}
+		serverOptions, valid := syslogServerOptions(serverLogData)
+		if !valid {
+			return destination, false
+		}
+		destination.url = gjson.Get(serverLogData, KEY_ADDRESS_SYSLOG).String()
+		destination.serverType = LOG_SERVER_TYPE_SYSLOG_NUM
+		destination.serverOptions = serverOptions
+	default:
+		return destination, false
+	}
+	return destination, true
+}
+
+// Returns the filter described by a configuration. Every entry is selected
+// if there is none.
+func parseTransferLogFilter(config gjson.Result) transferLogFilter {
+	strs := func(key string) []string {
+		var values []string
+		for _, value := range config.Get(key).Array() {
+			values = append(values, strings.Trim(value.String(), TEXT_TRIM))
+		}
+		return values
+	}
+	filter := transferLogFilter{
+		levels:            strs("levels"),
+		events:            strs("events"),
+		sourceAgents:      strs("sourceAgents"),
+		destinationAgents: strs("destinationAgents"),
+	}
+	for _, value := range config.Get("resultCodes").Array() {
+		filter.resultCodes = append(filter.resultCodes, value.Int())
+	}
+	return filter
+}
+
+// Returns true if the filter selects a transfer log entry
+func (f transferLogFilter) matches(msg string) bool {
+	if len(f.levels) > 0 && !containsFold(f.levels, logger.TransferLogLevel(msg)) {
+		return false
+	}
+	event, isEvent := parseTransferLogJSON(msg)
+	if len(f.events) > 0 && (!isEvent || !containsFold(f.events, transferEventNames[event.kind])) {
+		return false
+	}
+	if len(f.sourceAgents) > 0 && !containsFold(f.sourceAgents, event.sourceAgent) {
+		return false
+	}
+	if len(f.destinationAgents) > 0 && !containsFold(f.destinationAgents, event.destinationAgent) {
+		return false
+	}
+	if len(f.resultCodes) > 0 {
+		resultCode := gjson.Get(msg, "transferCompleted.resultCode")
+		if !resultCode.Exists() {
+			return false
+		}
+		for _, code := range f.resultCodes {
+			if code == resultCode.Int() {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Returns true if the list contains the value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the position from which the transfer log must be read so that no
+// destination misses an entry. Returns nil to read from the start.
+func earliestCheckpoint(checkpoints []*logger.LogPosition) *logger.LogPosition {
+	var earliest *logger.LogPosition
+	for _, checkpoint := range checkpoints {
+		if checkpoint == nil {
+			return nil
+		}
+		if earliest == nil {
+			earliest = checkpoint
+			continue
+		}
+		// Checkpoints in different files can not be compared, so the file is
+		// read from the start.
+		if checkpoint.Device != earliest.Device || checkpoint.Inode != earliest.Inode {
+			return nil
+		}
+		if checkpoint.Offset < earliest.Offset {
+			earliest = checkpoint
+		}
+	}
+	return earliest
+}
+
+// Returns true if the entry at the position was published or spooled before
+// the checkpoint was recorded
+func publishedBefore(position logger.LogPosition, checkpoint *logger.LogPosition) bool {
+	return checkpoint != nil && position.Device == checkpoint.Device && position.Inode == checkpoint.Inode &&
+		position.Offset <= checkpoint.Offset
+}
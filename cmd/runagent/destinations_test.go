@@ -0,0 +1,167 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ibm-messaging/mq-container-mft/pkg/logger"
+	"github.com/tidwall/gjson"
+)
+
+func TestTransferLogDestinations(t *testing.T) {
+	// A single destination keeps its spool directory.
+	destinations := transferLogDestinations(`{"type":"elk","elk":{"url":"http://elk:9200"}}`, "/data", "QM1", "SRC")
+	if len(destinations) != 1 || destinations[0].serverType != LOG_SERVER_TYPE_ELK_NUM ||
+		destinations[0].options.SpoolDir != "/data"+DIR_PUBLISH_SPOOL+"SRC" {
+		t.Fatalf("Unexpected destinations %+v", destinations)
+	}
+
+	config := `[
+		{"type":"logDNA","logDNA":{"url":"http://dna","injestionKey":"key"},"filter":{"levels":["error"]}},
+		{"type":"elk","name":"audit","elk":{"url":"http://elk:9200"},"publisher":{"spool":false}},
+		{"type":"logDNA","logDNA":{"url":"http://dna2","injestionKey":"key"}},
+		{"type":"unknown"}
+	]`
+	destinations = transferLogDestinations(config, "/data", "QM1", "SRC")
+	if len(destinations) != 3 {
+		t.Fatalf("Unexpected destinations %+v", destinations)
+	}
+	expected := []struct {
+		name     string
+		url      string
+		spoolDir string
+	}{
+		{"logDNA", "http://dna", "/data" + DIR_PUBLISH_SPOOL + "SRC/logDNA"},
+		{"audit", "http://elk:9200", ""},
+		{"logDNA-3", "http://dna2", "/data" + DIR_PUBLISH_SPOOL + "SRC/logDNA-3"},
+	}
+	for i, e := range expected {
+		d := destinations[i]
+		if d.name != e.name || d.url != e.url || d.options.SpoolDir != e.spoolDir {
+			t.Errorf("Unexpected destination %+v, expected %+v", d, e)
+		}
+	}
+	if len(destinations[0].filter.levels) != 1 || len(destinations[1].filter.levels) != 0 {
+		t.Errorf("Unexpected filters %+v and %+v", destinations[0].filter, destinations[1].filter)
+	}
+}
+
+func TestTransferLogFilter(t *testing.T) {
+	started := `{"transferId":"a1","eventDescription":"Transfer started","sourceAgent":"SRC","destinationAgent":"DEST","transferStarted":{}}`
+	failed := `{"transferId":"a1","eventDescription":"Transfer failed","sourceAgent":"SRC","destinationAgent":"DEST","transferCompleted":{"resultCode":40}}`
+	tests := []struct {
+		filter string
+		msg    string
+		match  bool
+	}{
+		{`{}`, started, true},
+		{`{"levels":["ERROR"]}`, started, false},
+		{`{"levels":["error"]}`, failed, true},
+		{`{"events":["started","progress"]}`, started, true},
+		{`{"events":["completed"]}`, started, false},
+		{`{"sourceAgents":["src"],"destinationAgents":["OTHER","DEST"]}`, started, true},
+		{`{"destinationAgents":["OTHER"]}`, failed, false},
+		{`{"resultCodes":[40]}`, failed, true},
+		{`{"resultCodes":[0]}`, failed, false},
+		{`{"resultCodes":[0]}`, started, false},
+	}
+	for _, test := range tests {
+		filter := parseTransferLogFilter(gjson.Parse(test.filter))
+		if filter.matches(test.msg) != test.match {
+			t.Errorf("Filter %s matching %s: expected %v", test.filter, test.msg, test.match)
+		}
+	}
+}
+
+func TestEarliestCheckpoint(t *testing.T) {
+	first := &logger.LogPosition{Device: 1, Inode: 2, Offset: 100}
+	second := &logger.LogPosition{Device: 1, Inode: 2, Offset: 50}
+	other := &logger.LogPosition{Device: 1, Inode: 3, Offset: 10}
+	if earliestCheckpoint([]*logger.LogPosition{first, second}) != second {
+		t.Error("Expected the earliest checkpoint")
+	}
+	if earliestCheckpoint([]*logger.LogPosition{first, nil}) != nil {
+		t.Error("Expected to read from the start when a destination has no checkpoint")
+	}
+	if earliestCheckpoint([]*logger.LogPosition{first, other}) != nil {
+		t.Error("Expected to read from the start when checkpoints are in different files")
+	}
+	if !publishedBefore(logger.LogPosition{Device: 1, Inode: 2, Offset: 50}, second) ||
+		publishedBefore(logger.LogPosition{Device: 1, Inode: 2, Offset: 51}, second) ||
+		publishedBefore(logger.LogPosition{Device: 1, Inode: 3, Offset: 1}, second) {
+		t.Error("Unexpected comparison with the checkpoint")
+	}
+}
+
+// Entries read once are published to each destination selecting them
+func TestMirrorTransferLogsFanOut(t *testing.T) {
+	savedEventLog := eventLog
+	defer func() { eventLog = savedEventLog }()
+	eventLog, _ = logger.NewLogger(ioutil.Discard, false, false, "SRC", "", "", -1)
+
+	var lock sync.Mutex
+	received := make(map[string][]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		lock.Lock()
+		defer lock.Unlock()
+		received[r.URL.Path] = append(received[r.URL.Path], string(body))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "transferlog0.json")
+	entries := `{"transferId":"a1","eventDescription":"Transfer started","transferStarted":{}}` + "\n" +
+		`{"transferId":"a1","eventDescription":"Transfer failed","transferCompleted":{"resultCode":1}}` + "\n"
+	if err := os.WriteFile(path, []byte(entries), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := `[
+		{"type":"logDNA","name":"ops","logDNA":{"url":"` + server.URL + `/ops","injestionKey":"key"}},
+		{"type":"logDNA","name":"audit","logDNA":{"url":"` + server.URL + `/audit","injestionKey":"key"},
+			"filter":{"events":["completed"]}}
+	]`
+	destinations := transferLogDestinations(config, dir, "QM1", "SRC")
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	if err := mirrorTransferLogs(ctx, &wg, path, destinations); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+	wg.Wait()
+
+	lock.Lock()
+	defer lock.Unlock()
+	ops := strings.Join(received["/ops"], "")
+	audit := strings.Join(received["/audit"], "")
+	if strings.Count(ops, `"transferId":"a1"`) != 2 {
+		t.Errorf("Expected both entries to be published to ops, received %v", received["/ops"])
+	}
+	if strings.Count(audit, `"transferId":"a1"`) != 1 || !strings.Contains(audit, "Transfer failed") {
+		t.Errorf("Expected the completed entry to be published to audit, received %v", received["/audit"])
+	}
+}
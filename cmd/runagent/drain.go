@@ -0,0 +1,252 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
+	"github.com/tidwall/gjson"
+)
+
+/**
+* Controlled stop of the agent.
+*
+* When the container is stopped, the agent is asked to stop once its current
+* transfers are complete. The agent does not accept new transfers while it
+* stops. If the agent has not ended by the deadline, it is stopped immediately
+* and the transfers that were still active are logged.
+*
+* Active transfers are tracked from the agent's transferlog0.json and
+* capture0.log files, from the point the agent was started by this container.
+ */
+
+// Supported values of MFT_AGENT_STOP_MODE
+const AGENT_STOP_MODE_CONTROLLED = "controlled"
+const AGENT_STOP_MODE_IMMEDIATE = "immediate"
+
+// Time left for the container to end after the deadline of a controlled stop
+const AGENT_STOP_GRACE_MARGIN = 5 * time.Second
+
+// Default deadline of a controlled stop, for the default Kubernetes termination
+// grace period of 30 seconds
+const AGENT_STOP_DEFAULT_TIMEOUT = 25 * time.Second
+
+// Interval at which a stopping agent is checked
+var agentStopPollInterval = time.Second
+
+// Transfers of the agent started by this container
+var agentTransfers *transferTracker
+
+// Matches the transaction ID and the action of a transfer log XML message
+var transferIdPattern = regexp.MustCompile(`<transaction[^>]*\sID="([^"]+)"`)
+var transferActionPattern = regexp.MustCompile(`<action[^>]*>\s*(\w+)\s*</action>`)
+var transferSourcePattern = regexp.MustCompile(`<sourceAgent[^>]*\sagent="([^"]*)"`)
+var transferDestinationPattern = regexp.MustCompile(`<destinationAgent[^>]*\sagent="([^"]*)"`)
+
+// A transfer that has started and not completed
+type activeTransfer struct {
+	id               string
+	sourceAgent      string
+	destinationAgent string
+}
+
+// Tracks active transfers of an agent from its log files
+type transferTracker struct {
+	agentPath string
+	lock      sync.Mutex
+	// Size of each log file when tracking started. Earlier entries belong to
+	// previous runs of the agent.
+	offsets map[string]int64
+}
+
+// Start tracking transfers of the agent from the current end of its log files
+func newTransferTracker(agentPath string) *transferTracker {
+	tracker := &transferTracker{agentPath: agentPath, offsets: make(map[string]int64)}
+	for _, logFile := range tracker.logFiles() {
+		if fileInfo, err := os.Stat(logFile); err == nil {
+			tracker.offsets[logFile] = fileInfo.Size()
+		}
+	}
+	return tracker
+}
+
+func (t *transferTracker) logFiles() []string {
+	return []string{t.agentPath + "/logs/transferlog0.json", t.agentPath + "/logs/capture0.log"}
+}
+
+func (t *transferTracker) pidFileName() string {
+	return t.agentPath + "/agent.pid"
+}
+
+// Returns the transfers that have started and not completed, sorted by ID
+func (t *transferTracker) active() []activeTransfer {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	transfers := make(map[string]activeTransfer)
+	for _, logFile := range t.logFiles() {
+		t.scan(logFile, transfers)
+	}
+	active := make([]activeTransfer, 0, len(transfers))
+	for _, transfer := range transfers {
+		active = append(active, transfer)
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].id < active[j].id })
+	return active
+}
+
+// Update the transfers with the entries of a log file
+func (t *transferTracker) scan(logFile string, transfers map[string]activeTransfer) {
+	file, err := os.Open(logFile)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	// Start from the beginning if the file has been rolled over since.
+	if fileInfo, err := file.Stat(); err == nil && fileInfo.Size() >= t.offsets[logFile] {
+		file.Seek(t.offsets[logFile], 0)
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		var transfer activeTransfer
+		var completed bool
+		if gjson.Valid(line) {
+			// Entry of transferlog0.json
+			transfer.id = strings.ToUpper(gjson.Get(line, "transferId").String())
+			transfer.sourceAgent = gjson.Get(line, "sourceAgent").String()
+			transfer.destinationAgent = gjson.Get(line, "destinationAgent").String()
+			completed = gjson.Get(line, "transferCompleted").Exists()
+		} else if match := transferIdPattern.FindStringSubmatch(line); match != nil {
+			// Transfer log message in capture0.log
+			transfer.id = strings.ToUpper(match[1])
+			if source := transferSourcePattern.FindStringSubmatch(line); source != nil {
+				transfer.sourceAgent = source[1]
+			}
+			if destination := transferDestinationPattern.FindStringSubmatch(line); destination != nil {
+				transfer.destinationAgent = destination[1]
+			}
+			if action := transferActionPattern.FindStringSubmatch(line); action != nil {
+				completed = strings.EqualFold(action[1], "completed") || strings.EqualFold(action[1], "cancelled") ||
+					strings.EqualFold(action[1], "malformed")
+			}
+		}
+		if len(transfer.id) == 0 {
+			continue
+		}
+		if completed {
+			delete(transfers, transfer.id)
+			continue
+		}
+		// Keep agent names already known for the transfer
+		if known, exists := transfers[transfer.id]; exists {
+			if len(transfer.sourceAgent) == 0 {
+				transfer.sourceAgent = known.sourceAgent
+			}
+			if len(transfer.destinationAgent) == 0 {
+				transfer.destinationAgent = known.destinationAgent
+			}
+		}
+		transfers[transfer.id] = transfer
+	}
+}
+
+// Returns true if the agent is to be stopped immediately rather than after
+// its transfers complete
+func isImmediateStopMode() bool {
+	stopMode, stopModeSet := os.LookupEnv(MFT_AGENT_STOP_MODE)
+	stopMode = strings.Trim(stopMode, TEXT_TRIM)
+	if !stopModeSet || stopMode == TEXT_BLANK || strings.EqualFold(stopMode, AGENT_STOP_MODE_CONTROLLED) {
+		return false
+	}
+	if !strings.EqualFold(stopMode, AGENT_STOP_MODE_IMMEDIATE) {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_ENV_STOP_MODE_INVALID_0165, stopMode, MFT_AGENT_STOP_MODE))
+		return false
+	}
+	return true
+}
+
+// Returns the deadline of a controlled stop. An explicit timeout takes
+// precedence over one derived from the termination grace period.
+func agentStopTimeout() time.Duration {
+	if value, ok := retryEnvValue(MFT_AGENT_STOP_TIMEOUT, 0); ok {
+		return time.Duration(value * float64(time.Second))
+	}
+	if value, ok := retryEnvValue(MFT_TERMINATION_GRACE_PERIOD, 0); ok {
+		gracePeriod := time.Duration(value * float64(time.Second))
+		if gracePeriod > AGENT_STOP_GRACE_MARGIN {
+			return gracePeriod - AGENT_STOP_GRACE_MARGIN
+		}
+		return 0
+	}
+	return AGENT_STOP_DEFAULT_TIMEOUT
+}
+
+// Ask the agent to stop once its active transfers are complete and wait for it
+// to end. Returns true if the agent ended by the deadline. Otherwise returns the
+// transfers that are still active.
+func drainAgent(agentName string, coordinationQMgr string) ([]activeTransfer, bool) {
+	tracker := agentTransfers
+	timeout := agentStopTimeout()
+	if tracker == nil || timeout <= 0 {
+		return nil, false
+	}
+
+	deadline := time.Now().Add(timeout)
+	active := tracker.active()
+	utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_STOPPING_0102, agentName, timeout, len(active)))
+	result := commandRunner.Run(context.Background(), Command{Name: "fteStopAgent",
+		Args: []string{"-p", coordinationQMgr, agentName}, Timeout: timeout, Trace: true})
+	if result.Err != nil {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_ERROR_0042, result.Stdout, result.Stderr))
+		return tracker.active(), false
+	}
+
+	for {
+		if !isAgentProcessRunning(tracker.pidFileName()) {
+			return nil, true
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+		if current := tracker.active(); len(current) != len(active) {
+			active = current
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_STOP_WAITING_0103, len(active), agentName))
+		}
+		time.Sleep(agentStopPollInterval)
+	}
+	utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_STOP_TIMEOUT_0104, agentName, timeout))
+	return tracker.active(), false
+}
+
+// Log the transfers interrupted by an immediate stop of the agent
+func logInterruptedTransfers(agentName string, transfers []activeTransfer) {
+	for _, transfer := range transfers {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TRANSFER_INTERRUPTED_0105, transfer.id,
+			transfer.sourceAgent, transfer.destinationAgent, agentName))
+	}
+}
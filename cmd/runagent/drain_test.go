@@ -0,0 +1,163 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func appendTestLog(t *testing.T, fileName string, lines ...string) {
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(fileName, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for _, line := range lines {
+		if _, err := f.WriteString(line + "\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// Use a tracker of a temporary agent directory for the duration of a test
+func useTestTransferTracker(t *testing.T) *transferTracker {
+	previous := agentTransfers
+	agentTransfers = newTransferTracker(t.TempDir())
+	previousInterval := agentStopPollInterval
+	agentStopPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() {
+		agentTransfers = previous
+		agentStopPollInterval = previousInterval
+	})
+	return agentTransfers
+}
+
+func TestTransferTrackerActive(t *testing.T) {
+	agentPath := t.TempDir()
+	transferLog := filepath.Join(agentPath, "logs/transferlog0.json")
+	captureLog := filepath.Join(agentPath, "logs/capture0.log")
+	// Entries of an earlier run of the agent are ignored.
+	appendTestLog(t, transferLog, `{"transferId":"a0","eventDescription":"started"}`)
+
+	tracker := newTransferTracker(agentPath)
+	appendTestLog(t, transferLog,
+		`{"transferId":"a1","sourceAgent":"SRC","destinationAgent":"DEST","eventDescription":"started"}`,
+		`{"transferId":"a2","sourceAgent":"SRC","destinationAgent":"DEST","eventDescription":"started"}`,
+		`{"transferId":"a2","eventDescription":"progress","progressInformation":{"failed":0}}`,
+		`{"transferId":"a1","eventDescription":"completed","transferCompleted":{"resultCode":0}}`)
+	appendTestLog(t, captureLog,
+		`<transaction version="6.00" ID="b1"><action time="2022-01-01T00:00:00Z">started</action><sourceAgent agent="SRC"/><destinationAgent agent="OTHER"/></transaction>`,
+		`<transaction version="6.00" ID="b2"><action time="2022-01-01T00:00:00Z">started</action></transaction>`,
+		`<transaction version="6.00" ID="b2"><action time="2022-01-01T00:00:01Z">cancelled</action></transaction>`,
+		`Not a transfer log message`)
+
+	expected := []activeTransfer{{id: "A2", sourceAgent: "SRC", destinationAgent: "DEST"}, {id: "B1", sourceAgent: "SRC", destinationAgent: "OTHER"}}
+	if active := tracker.active(); !reflect.DeepEqual(active, expected) {
+		t.Errorf("Expected active transfers %v, found %v", expected, active)
+	}
+}
+
+func TestAgentStopTimeout(t *testing.T) {
+	setTestEnv(t, MFT_AGENT_STOP_TIMEOUT, "")
+	setTestEnv(t, MFT_TERMINATION_GRACE_PERIOD, "")
+	if timeout := agentStopTimeout(); timeout != AGENT_STOP_DEFAULT_TIMEOUT {
+		t.Errorf("Expected default timeout, found %v", timeout)
+	}
+	setTestEnv(t, MFT_TERMINATION_GRACE_PERIOD, "120")
+	if timeout := agentStopTimeout(); timeout != 115*time.Second {
+		t.Errorf("Expected timeout derived from grace period, found %v", timeout)
+	}
+	setTestEnv(t, MFT_AGENT_STOP_TIMEOUT, "10")
+	if timeout := agentStopTimeout(); timeout != 10*time.Second {
+		t.Errorf("Expected explicit timeout, found %v", timeout)
+	}
+}
+
+// An agent that stops by the deadline is not stopped immediately
+func TestStopAgentControlled(t *testing.T) {
+	resetAgentStopRequested(t)
+	useTestTransferTracker(t)
+	fake := useFakeCommandRunner(t)
+
+	stopAgent("SRC", "COORDQM")
+
+	if !reflect.DeepEqual(fake.commandArgs("fteStopAgent"), []string{"-p", "COORDQM", "SRC"}) || len(fake.commandNames()) != 1 {
+		t.Errorf("Unexpected commands %v %v", fake.commandNames(), fake.commandArgs("fteStopAgent"))
+	}
+}
+
+// An agent still running at the deadline is stopped immediately
+func TestStopAgentDeadline(t *testing.T) {
+	resetAgentStopRequested(t)
+	tracker := useTestTransferTracker(t)
+	setTestEnv(t, MFT_AGENT_STOP_MODE, AGENT_STOP_MODE_CONTROLLED)
+	setTestEnv(t, MFT_AGENT_STOP_TIMEOUT, "0.2")
+
+	// A process stands in for the agent, and ends only on an immediate stop.
+	process := exec.Command("sleep", "30")
+	if err := process.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { process.Process.Kill() })
+	if err := os.WriteFile(tracker.pidFileName(), []byte(strconv.Itoa(process.Process.Pid)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	appendTestLog(t, filepath.Join(tracker.agentPath, "logs/transferlog0.json"), `{"transferId":"a1","eventDescription":"started"}`)
+
+	fake := useFakeCommandRunner(t)
+	immediateStops := 0
+	fake.handlers["fteStopAgent"] = func(args []string) CommandResult {
+		if args[len(args)-1] == "-i" {
+			immediateStops++
+			process.Process.Kill()
+		}
+		return CommandResult{}
+	}
+
+	started := time.Now()
+	stopAgent("SRC", "COORDQM")
+	if immediateStops != 1 || len(fake.commandNames()) != 2 {
+		t.Errorf("Expected a controlled and an immediate stop, found %v", fake.commandNames())
+	}
+	if elapsed := time.Since(started); elapsed < 200*time.Millisecond {
+		t.Errorf("Agent stopped immediately before the deadline, after %v", elapsed)
+	}
+	if active := tracker.active(); len(active) != 1 || active[0].id != "A1" {
+		t.Errorf("Unexpected active transfers %v", active)
+	}
+}
+
+func TestStopAgentImmediateMode(t *testing.T) {
+	resetAgentStopRequested(t)
+	useTestTransferTracker(t)
+	setTestEnv(t, MFT_AGENT_STOP_MODE, "Immediate")
+	fake := useFakeCommandRunner(t)
+
+	stopAgent("SRC", "COORDQM")
+
+	if !reflect.DeepEqual(fake.commandArgs("fteStopAgent"), []string{"-p", "COORDQM", "SRC", "-i"}) || len(fake.commandNames()) != 1 {
+		t.Errorf("Unexpected commands %v %v", fake.commandNames(), fake.commandArgs("fteStopAgent"))
+	}
+}
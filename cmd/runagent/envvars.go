@@ -80,3 +80,84 @@ const MFT_CMD_QMGR_CIPHER = "MFT_CMD_QMGR_CIPHER"
 
 // Agent queue manager cipherspec
 const MFT_AGENT_QMGR_CIPHER = "MFT_AGENT_QMGR_CIPHER"
+
+// Number of attempts made for each setup step that depends on a queue manager
+// before the container ends. Default is 3.
+const MFT_SETUP_RETRY_MAX_ATTEMPTS = "MFT_SETUP_RETRY_MAX_ATTEMPTS"
+
+// Delay, in seconds, before the first retry of a failed setup step. Default is 5.
+const MFT_SETUP_RETRY_INITIAL_DELAY = "MFT_SETUP_RETRY_INITIAL_DELAY"
+
+// Maximum delay, in seconds, between retries of a setup step. Default is 60.
+const MFT_SETUP_RETRY_MAX_DELAY = "MFT_SETUP_RETRY_MAX_DELAY"
+
+// Factor the delay between retries is multiplied by after every attempt. Default is 2.
+const MFT_SETUP_RETRY_MULTIPLIER = "MFT_SETUP_RETRY_MULTIPLIER"
+
+// Time, in seconds, a single MFT command of a setup step is allowed to run.
+// Default is 300. 0 means no limit.
+const MFT_SETUP_COMMAND_TIMEOUT = "MFT_SETUP_COMMAND_TIMEOUT"
+
+// Number of times the agent is restarted if it ends unexpectedly. Default is 0,
+// the container ends as soon as the agent ends.
+const MFT_AGENT_RESTART_LIMIT = "MFT_AGENT_RESTART_LIMIT"
+
+// Delay, in seconds, before the agent is restarted. The delay doubles after
+// every restart. Default is 5.
+const MFT_AGENT_RESTART_DELAY = "MFT_AGENT_RESTART_DELAY"
+
+// Maximum delay, in seconds, before the agent is restarted. Default is 60.
+const MFT_AGENT_RESTART_MAX_DELAY = "MFT_AGENT_RESTART_MAX_DELAY"
+
+// Interval, in seconds, at which the agent process is checked. Default is 5.
+const MFT_AGENT_MONITOR_INTERVAL = "MFT_AGENT_MONITOR_INTERVAL"
+
+// Interval, in seconds, at which the running agent is pinged through the
+// coordination queue manager to keep its readiness current. Default is 0,
+// which means the agent is only pinged when it is started.
+const MFT_AGENT_PING_INTERVAL = "MFT_AGENT_PING_INTERVAL"
+
+// Interval, in seconds, at which the configuration file is checked for changes.
+// Default is 10. 0 means the configuration is only reloaded on SIGHUP.
+const MFT_AGENT_CONFIG_RELOAD_INTERVAL = "MFT_AGENT_CONFIG_RELOAD_INTERVAL"
+
+// Interval, in seconds, at which the certificate directories of the queue
+// managers are checked for changes. Default is 60. 0 means keystores are only
+// created when the container starts.
+const MFT_CERT_CHECK_INTERVAL = "MFT_CERT_CHECK_INTERVAL"
+
+// Number of days before a certificate of a queue manager expires that it is
+// reported. Default is 30.
+const MFT_CERT_EXPIRY_WARNING_DAYS = "MFT_CERT_EXPIRY_WARNING_DAYS"
+
+// How the agent is stopped when the container stops. "controlled", the default,
+// waits for active transfers to complete. "immediate" stops the agent at once.
+const MFT_AGENT_STOP_MODE = "MFT_AGENT_STOP_MODE"
+
+// Time, in seconds, allowed for a controlled stop before the agent is stopped
+// immediately
+const MFT_AGENT_STOP_TIMEOUT = "MFT_AGENT_STOP_TIMEOUT"
+
+// Termination grace period, in seconds, of the pod. The deadline of a controlled
+// stop is derived from it if MFT_AGENT_STOP_TIMEOUT is not set.
+const MFT_TERMINATION_GRACE_PERIOD = "MFT_TERMINATION_GRACE_PERIOD"
+
+// Collect transfer and agent statistics and serve them to Prometheus. "Yes" and
+// "No" are the supported values with "No" being the default.
+const MFT_ENABLE_METRICS = "MFT_ENABLE_METRICS"
+
+// Port of the metrics endpoint. Default is 9157.
+const MFT_METRICS_PORT = "MFT_METRICS_PORT"
+
+// Standard OpenTelemetry settings of the export of transfers as traces and
+// container diagnostics as logs. Export is enabled when an endpoint is set.
+const OTEL_EXPORTER_OTLP_ENDPOINT = "OTEL_EXPORTER_OTLP_ENDPOINT"
+const OTEL_EXPORTER_OTLP_TRACES_ENDPOINT = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+const OTEL_EXPORTER_OTLP_LOGS_ENDPOINT = "OTEL_EXPORTER_OTLP_LOGS_ENDPOINT"
+const OTEL_EXPORTER_OTLP_HEADERS = "OTEL_EXPORTER_OTLP_HEADERS"
+const OTEL_EXPORTER_OTLP_PROTOCOL = "OTEL_EXPORTER_OTLP_PROTOCOL"
+const OTEL_TRACES_EXPORTER = "OTEL_TRACES_EXPORTER"
+const OTEL_LOGS_EXPORTER = "OTEL_LOGS_EXPORTER"
+const OTEL_SERVICE_NAME = "OTEL_SERVICE_NAME"
+const OTEL_RESOURCE_ATTRIBUTES = "OTEL_RESOURCE_ATTRIBUTES"
+const OTEL_SDK_DISABLED = "OTEL_SDK_DISABLED"
@@ -0,0 +1,248 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
+)
+
+/**
+* Health endpoints of the container.
+*
+* runagent serves /livez, /readyz and /startupz over HTTP from the state it
+* already holds about the agent, so that probes do not need to read the
+* configuration file or scan the agent's log files. The agentalive and
+* agentready programs query these endpoints.
+ */
+
+// Status of a health check
+const HEALTH_STATUS_UP = "UP"
+const HEALTH_STATUS_DOWN = "DOWN"
+const HEALTH_STATUS_STARTING = "STARTING"
+const HEALTH_STATUS_RESTARTING = "RESTARTING"
+const HEALTH_STATUS_STOPPING = "STOPPING"
+
+// Time allowed for the HTTP endpoints to end when the container stops
+const HTTP_SHUTDOWN_TIMEOUT = 2 * time.Second
+
+// Health of the agent run by this container
+var agentHealth = &healthState{}
+
+// What runagent knows about the agent
+type healthState struct {
+	lock      sync.Mutex
+	agentName string
+	agentPath string
+	// Agent has been set up, started and found ready
+	started bool
+	// Agent is being restarted, by supervision or by a reload of the configuration
+	restarting bool
+	// BFGAG0059I was found in output0.log when the agent started
+	readyEventSeen bool
+	// Result of the last ping of the agent through the coordination queue manager
+	pinged     bool
+	pingResult bool
+	pingTime   time.Time
+}
+
+// Response of a health endpoint
+type healthReport struct {
+	Status   string            `json:"status"`
+	Agent    string            `json:"agent"`
+	Checks   map[string]string `json:"checks"`
+	LastPing string            `json:"lastPing,omitempty"`
+}
+
+func newHealthState(agentName string) *healthState {
+	return &healthState{agentName: agentName}
+}
+
+func (h *healthState) setAgentPath(agentPath string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.agentPath = agentPath
+}
+
+// Record that the agent has started and that its ready event was found
+func (h *healthState) setStarted(readyEventSeen bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.started = true
+	h.readyEventSeen = readyEventSeen
+}
+
+func (h *healthState) setRestarting(restarting bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.restarting = restarting
+}
+
+// Returns whether the agent process is running and whether the agent is ready
+func (h *healthState) agentStatus() (bool, bool) {
+	ready := h.ready()
+	return ready.Checks["agentProcess"] == HEALTH_STATUS_UP, ready.Status == HEALTH_STATUS_UP
+}
+
+func (h *healthState) recordPing(succeeded bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.pinged = true
+	h.pingResult = succeeded
+	h.pingTime = time.Now()
+}
+
+// Returns the state of the agent process
+func (h *healthState) processStatus() string {
+	switch {
+	case !h.started:
+		return HEALTH_STATUS_STARTING
+	case isAgentStopRequested():
+		return HEALTH_STATUS_STOPPING
+	case h.restarting:
+		return HEALTH_STATUS_RESTARTING
+	case len(h.agentPath) > 0 && isAgentProcessRunning(h.agentPath+"/agent.pid"):
+		return HEALTH_STATUS_UP
+	}
+	return HEALTH_STATUS_DOWN
+}
+
+// The container is live while the agent is starting, running, being restarted
+// or being stopped
+func (h *healthState) live() healthReport {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	report := h.report(map[string]string{"agentProcess": h.processStatus()})
+	if report.Checks["agentProcess"] != HEALTH_STATUS_DOWN {
+		report.Status = HEALTH_STATUS_UP
+	}
+	return report
+}
+
+// The agent is ready once it is running, its ready event has been seen and it
+// last responded to a ping through the coordination queue manager
+func (h *healthState) ready() healthReport {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	report := h.report(map[string]string{
+		"agentProcess": h.processStatus(),
+		"readyEvent":   statusOf(h.readyEventSeen),
+		"coordination": statusOf(h.pinged && h.pingResult),
+	})
+	report.Status = HEALTH_STATUS_UP
+	for _, status := range report.Checks {
+		if status != HEALTH_STATUS_UP {
+			report.Status = HEALTH_STATUS_DOWN
+		}
+	}
+	return report
+}
+
+// Startup is complete once the agent has been set up, started and found ready
+func (h *healthState) startup() healthReport {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	report := h.report(map[string]string{"agentStarted": statusOf(h.started)})
+	report.Status = report.Checks["agentStarted"]
+	return report
+}
+
+func (h *healthState) report(checks map[string]string) healthReport {
+	report := healthReport{Status: HEALTH_STATUS_DOWN, Agent: h.agentName, Checks: checks}
+	if h.pinged {
+		report.LastPing = h.pingTime.UTC().Format(time.RFC3339)
+	}
+	return report
+}
+
+func statusOf(healthy bool) string {
+	if healthy {
+		return HEALTH_STATUS_UP
+	}
+	return HEALTH_STATUS_DOWN
+}
+
+// Returns the handler of the health endpoints
+func newHealthHandler(health *healthState) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(utils.HEALTH_PATH_LIVE, healthEndpoint(health.live))
+	mux.HandleFunc(utils.HEALTH_PATH_READY, healthEndpoint(health.ready))
+	mux.HandleFunc(utils.HEALTH_PATH_STARTUP, healthEndpoint(health.startup))
+	return mux
+}
+
+func healthEndpoint(check func() healthReport) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := check()
+		w.Header().Set("Content-Type", "application/json")
+		if report.Status == HEALTH_STATUS_UP {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		// #nosec G104
+		json.NewEncoder(w).Encode(report)
+	}
+}
+
+// Serve the health endpoints of the agent. Returns nil if the endpoints are
+// disabled or could not be started, in which case the container carries on
+// without them.
+func startHealthServer(health *healthState) *http.Server {
+	port := utils.GetHealthPort()
+	if port == utils.HEALTH_PORT_DISABLED {
+		return nil
+	}
+	server, err := startHTTPServer(port, newHealthHandler(health))
+	if err != nil {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_HEALTH_FAILED_0107, port, err))
+		return nil
+	}
+	utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_HEALTH_LISTENING_0106, port))
+	return server
+}
+
+// Serve the handler on the port until the server is stopped
+func startHTTPServer(port string, handler http.Handler) (*http.Server, error) {
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return nil, err
+	}
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: utils.HEALTH_QUERY_TIMEOUT}
+	go func() {
+		// #nosec G104
+		server.Serve(listener)
+	}()
+	return server, nil
+}
+
+// Stop a server started by startHTTPServer, if any
+func stopHTTPServer(server *http.Server) {
+	if server == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), HTTP_SHUTDOWN_TIMEOUT)
+	defer cancel()
+	// #nosec G104
+	server.Shutdown(ctx)
+}
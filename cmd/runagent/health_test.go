@@ -0,0 +1,133 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
+)
+
+// Returns the status code and report of a health endpoint
+func getHealth(t *testing.T, url string) (int, healthReport) {
+	response, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	var report healthReport
+	if err := json.NewDecoder(response.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	return response.StatusCode, report
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	resetAgentStopRequested(t)
+	agentPath := t.TempDir()
+	health := newHealthState("SRC")
+	health.setAgentPath(agentPath)
+	server := httptest.NewServer(newHealthHandler(health))
+	defer server.Close()
+
+	expectStatus := func(path string, expected int) healthReport {
+		t.Helper()
+		code, report := getHealth(t, server.URL+path)
+		if code != expected {
+			t.Errorf("Expected status %d from %s, found %d: %+v", expected, path, code, report)
+		}
+		return report
+	}
+
+	// Live but neither started nor ready while the agent is set up
+	expectStatus(utils.HEALTH_PATH_STARTUP, http.StatusServiceUnavailable)
+	expectStatus(utils.HEALTH_PATH_LIVE, http.StatusOK)
+	expectStatus(utils.HEALTH_PATH_READY, http.StatusServiceUnavailable)
+
+	process := exec.Command("sleep", "30")
+	if err := process.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer process.Process.Kill()
+	if err := os.WriteFile(filepath.Join(agentPath, "agent.pid"), []byte(strconv.Itoa(process.Process.Pid)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	health.recordPing(true)
+	health.setStarted(true)
+	expectStatus(utils.HEALTH_PATH_STARTUP, http.StatusOK)
+	expectStatus(utils.HEALTH_PATH_LIVE, http.StatusOK)
+	if report := expectStatus(utils.HEALTH_PATH_READY, http.StatusOK); report.Agent != "SRC" || len(report.LastPing) == 0 {
+		t.Errorf("Unexpected ready report %+v", report)
+	}
+
+	// Not ready once the coordination queue manager can not be reached
+	health.recordPing(false)
+	if report := expectStatus(utils.HEALTH_PATH_READY, http.StatusServiceUnavailable); report.Checks["coordination"] != HEALTH_STATUS_DOWN {
+		t.Errorf("Unexpected ready report %+v", report)
+	}
+	health.recordPing(true)
+
+	// Neither live nor ready once the agent has ended, unless it is being restarted
+	process.Process.Kill()
+	process.Wait()
+	expectStatus(utils.HEALTH_PATH_LIVE, http.StatusServiceUnavailable)
+	expectStatus(utils.HEALTH_PATH_READY, http.StatusServiceUnavailable)
+	health.setRestarting(true)
+	expectStatus(utils.HEALTH_PATH_LIVE, http.StatusOK)
+	expectStatus(utils.HEALTH_PATH_READY, http.StatusServiceUnavailable)
+}
+
+// The probe programs reach the health endpoints through the port in MFT_HEALTH_PORT
+func TestQueryHealthEndpoint(t *testing.T) {
+	resetAgentStopRequested(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+	listener.Close()
+	setTestEnv(t, utils.MFT_HEALTH_PORT, port)
+
+	health := newHealthState("SRC")
+	server := startHealthServer(health)
+	if server == nil {
+		t.Fatalf("Health server not started on port %s", port)
+	}
+	defer stopHTTPServer(server)
+
+	if live, status, err := utils.QueryHealthEndpoint(utils.HEALTH_PATH_LIVE); err != nil || !live {
+		t.Errorf("Expected live, found %v %s %v", live, status, err)
+	}
+	if ready, status, err := utils.QueryHealthEndpoint(utils.HEALTH_PATH_READY); err != nil || ready || len(status) == 0 {
+		t.Errorf("Expected not ready, found %v %s %v", ready, status, err)
+	}
+
+	setTestEnv(t, utils.MFT_HEALTH_PORT, utils.HEALTH_PORT_DISABLED)
+	if startHealthServer(health) != nil {
+		t.Error("Expected health server to be disabled")
+	}
+	if _, _, err := utils.QueryHealthEndpoint(utils.HEALTH_PATH_LIVE); err == nil {
+		t.Error("Expected an error from a disabled health endpoint")
+	}
+}
@@ -21,7 +21,6 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
 )
@@ -29,49 +28,7 @@ import (
 // resolveLicenseFile returns the file name of the MQ MFT license file, taking into
 // account the language set by the LANG environment variable
 func resolveLicenseFile() string {
-	lang, ok := os.LookupEnv("LANG")
-	if !ok {
-		return "Lic_en.txt"
-	}
-	switch {
-	case strings.HasPrefix(lang, "zh_TW"):
-		return "Lic_zh_tw.txt"
-	case strings.HasPrefix(lang, "zh"):
-		return "Lic_zh.txt"
-	// Differentiate Czech (cs) and Kashubian (csb)
-	case strings.HasPrefix(lang, "cs") && !strings.HasPrefix(lang, "csb"):
-		return "Lic_cs.txt"
-	case strings.HasPrefix(lang, "fr"):
-		return "Lic_fr.txt"
-	case strings.HasPrefix(lang, "de"):
-		return "Lic_de.txt"
-	case strings.HasPrefix(lang, "el"):
-		return "Lic_el.txt"
-	case strings.HasPrefix(lang, "id"):
-		return "Lic_id.txt"
-	case strings.HasPrefix(lang, "it"):
-		return "Lic_it.txt"
-	case strings.HasPrefix(lang, "ja"):
-		return "Lic_ja.txt"
-	// Differentiate Korean (ko) from Konkani (kok)
-	case strings.HasPrefix(lang, "ko") && !strings.HasPrefix(lang, "kok"):
-		return "Lic_ko.txt"
-	case strings.HasPrefix(lang, "lt"):
-		return "Lic_lt.txt"
-	case strings.HasPrefix(lang, "pl"):
-		return "Lic_pl.txt"
-	case strings.HasPrefix(lang, "pt"):
-		return "Lic_pt.txt"
-	case strings.HasPrefix(lang, "ru"):
-		return "Lic_ru.txt"
-	case strings.HasPrefix(lang, "sl"):
-		return "Lic_sl.txt"
-	case strings.HasPrefix(lang, "es"):
-		return "Lic_es.txt"
-	case strings.HasPrefix(lang, "tr"):
-		return "Lic_tr.txt"
-	}
-	return "Lic_en.txt"
+	return "Lic_" + utils.ResolveLanguage(os.Getenv("LANG")) + ".txt"
 }
 
 func checkLicense() (bool, error) {
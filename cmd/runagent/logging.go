@@ -30,6 +30,7 @@ import (
 	"github.com/antchfx/xmlquery"
 
 	"github.com/ibm-messaging/mq-container-mft/pkg/logger"
+	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
 )
 
 /*
@@ -43,36 +44,27 @@ func logTerminationf(format string, args ...interface{}) {
 	logTermination(fmt.Sprintf(format, args...))
 }
 
+// Path of the termination log read by Kubernetes
+var terminationLogPath = "/run/termination-log"
+
 // Terminate logging
 func logTermination(args ...interface{}) {
 	msg := fmt.Sprint(args...)
 	// Write the message to the termination log.  This is not the default place
 	// that Kubernetes will look for termination information.
+	err := ioutil.WriteFile(terminationLogPath, []byte(msg), 0660)
+	// Event logger is not set up until log mirroring starts.
+	if eventLog == nil {
+		utils.PrintLog(msg)
+		return
+	}
 	eventLog.Debugf("Writing termination message: %v", msg)
-	err := ioutil.WriteFile("/run/termination-log", []byte(msg), 0660)
 	if err != nil {
 		eventLog.Debug(err)
 	}
 	eventLog.Error(msg)
 }
 
-// Returns log format type. JSON or basic
-// Returns basic if anything other than json is set or not set.
-func getLogFormat() string {
-	logFormat := "basic"
-	logFormatStr, logFormatSet := os.LookupEnv("MFT_LOG_FORMAT")
-	if logFormatSet {
-		if logFormatStr == "json" {
-			logFormat = logFormatStr
-		} else if logFormatStr == "logDNA" {
-			logFormat = "logDNA"
-		}
-	} else {
-		logFormat = "basic"
-	}
-	return logFormat
-}
-
 // formatJSON formats a log message as "JSON" text
 func formatJSON(obj map[string]interface{}) string {
 	xmlString := fmt.Sprintf("%s", obj["message"])
@@ -464,21 +456,23 @@ func getDebug() bool {
 // Setup logger to capture events.
 func configureLogger(name string, logUrl string, logKey string, logType string, logServerType int16) (mirrorFunc, error) {
 	var err error
-	//f := getLogFormat()
+	jsonFormat := utils.IsJSONLogFormat()
 	d := getDebug()
 	switch logType {
 	case "tlog":
-		eventLog, err = logger.NewLogger(os.Stdout, d, false, name, logUrl, logKey, logServerType)
+		eventLog, err = logger.NewLogger(os.Stdout, d, jsonFormat, name, logUrl, logKey, logServerType)
 		if err != nil {
 			return nil, err
 		}
+		// Later loggers replace eventLog, so keep the one publishing transfer logs.
+		publisher := eventLog
 		return func(msg string) bool {
-			eventLog.PushToLogToServer(msg)
+			publisher.PushToLogToServer(msg)
 			return true
 		}, nil
 
 	case "json":
-		eventLog, err = logger.NewLogger(os.Stdout, d, false, name, logUrl, logKey, logServerType)
+		eventLog, err = logger.NewLogger(os.Stdout, d, jsonFormat, name, logUrl, logKey, logServerType)
 		if err != nil {
 			return nil, err
 		}
@@ -494,7 +488,7 @@ func configureLogger(name string, logUrl string, logKey string, logType string,
 		}, nil
 
 	case "console":
-		eventLog, err = logger.NewLogger(os.Stdout, d, false, name, logUrl, logKey, logServerType)
+		eventLog, err = logger.NewLogger(os.Stdout, d, jsonFormat, name, logUrl, logKey, logServerType)
 		if err != nil {
 			return nil, err
 		}
@@ -509,7 +503,7 @@ func configureLogger(name string, logUrl string, logKey string, logType string,
 			return true
 		}, nil
 	default:
-		eventLog, err = logger.NewLogger(os.Stdout, d, false, name, logUrl, logKey, logServerType)
+		eventLog, err = logger.NewLogger(os.Stdout, d, jsonFormat, name, logUrl, logKey, logServerType)
 		if err != nil {
 			return nil, err
 		}
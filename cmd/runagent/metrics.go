@@ -0,0 +1,613 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/ibm-messaging/mq-container-mft/pkg/logger"
+	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
+	"github.com/tidwall/gjson"
+)
+
+/**
+* Prometheus metrics of the agent.
+*
+* Transfer and resource monitor statistics are collected from the agent's
+* transferlog0.json and capture0.log files, from the point the metrics were
+* started. A transfer logged in both files is counted once. The metrics are
+* served in the Prometheus text format on /metrics.
+ */
+
+// Default port of the metrics endpoint
+const METRICS_DEFAULT_PORT = "9157"
+
+// Path of the metrics endpoint
+const METRICS_PATH = "/metrics"
+
+// Upper bounds, in seconds, of the transfer duration histogram buckets
+var transferDurationBuckets = []float64{1, 5, 10, 30, 60, 300, 900, 1800, 3600}
+
+// Number of completed transfers remembered, so that a transfer logged in
+// both log files is counted once
+const METRICS_COMPLETED_TRANSFERS_KEPT = 1000
+
+// Results of a completed transfer, from its result code
+const TRANSFER_RESULT_SUCCESSFUL = "successful"
+const TRANSFER_RESULT_PARTIALLY_SUCCESSFUL = "partially_successful"
+const TRANSFER_RESULT_CANCELLED = "cancelled"
+const TRANSFER_RESULT_FAILED = "failed"
+
+// Result codes of a transfer that did not fail outright
+const TRANSFER_RC_SUCCESSFUL = 0
+const TRANSFER_RC_PARTIALLY_SUCCESSFUL = 40
+const TRANSFER_RC_CANCELLED = 41
+
+// Kinds of transfer log entries
+const (
+	transferEventStarted = iota
+	transferEventProgress
+	transferEventCompleted
+)
+
+// A transfer log entry of interest to the metrics
+type transferEvent struct {
+	kind             int
+	id               string
+	sourceAgent      string
+	destinationAgent string
+	time             time.Time
+	// Start time reported on completion, if any
+	startTime time.Time
+	// Bytes sent so far, or -1 if not reported
+	bytesSent  int64
+	result     string
+	retryCount int64
+	// Items reported by the event, if any
+	items []transferItem
+}
+
+// An item of a transfer reported in a transfer log XML message
+type transferItem struct {
+	source      string
+	destination string
+	resultCode  int64
+	supplement  string
+}
+
+// State of a transfer that has not completed
+type transferProgress struct {
+	sourceAgent      string
+	destinationAgent string
+	startTime        time.Time
+	bytesSent        int64
+}
+
+// A counter with a value for each combination of label values
+type counterVec struct {
+	name       string
+	help       string
+	labelNames []string
+	series     map[string]*counterSeries
+}
+
+type counterSeries struct {
+	labelValues []string
+	value       float64
+}
+
+func newCounterVec(name string, help string, labelNames ...string) *counterVec {
+	return &counterVec{name: name, help: help, labelNames: labelNames, series: make(map[string]*counterSeries)}
+}
+
+func (c *counterVec) add(value float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	series, exists := c.series[key]
+	if !exists {
+		series = &counterSeries{labelValues: labelValues}
+		c.series[key] = series
+	}
+	series.value += value
+}
+
+func (c *counterVec) write(w io.Writer) {
+	writeMetricHeader(w, c.name, c.help, "counter")
+	keys := make([]string, 0, len(c.series))
+	for key := range c.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		series := c.series[key]
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labelNames, series.labelValues), formatValue(series.value))
+	}
+}
+
+// A histogram with a series for each combination of label values
+type histogramVec struct {
+	name       string
+	help       string
+	labelNames []string
+	buckets    []float64
+	series     map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labelValues []string
+	counts      []uint64
+	sum         float64
+	count       uint64
+}
+
+func newHistogramVec(name string, help string, buckets []float64, labelNames ...string) *histogramVec {
+	return &histogramVec{name: name, help: help, labelNames: labelNames, buckets: buckets,
+		series: make(map[string]*histogramSeries)}
+}
+
+func (h *histogramVec) observe(value float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	series, exists := h.series[key]
+	if !exists {
+		series = &histogramSeries{labelValues: labelValues, counts: make([]uint64, len(h.buckets))}
+		h.series[key] = series
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			series.counts[i]++
+		}
+	}
+	series.sum += value
+	series.count++
+}
+
+func (h *histogramVec) write(w io.Writer) {
+	writeMetricHeader(w, h.name, h.help, "histogram")
+	labelNames := append(append([]string{}, h.labelNames...), "le")
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		series := h.series[key]
+		for i, bound := range h.buckets {
+			labelValues := append(append([]string{}, series.labelValues...), formatValue(bound))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(labelNames, labelValues), series.counts[i])
+		}
+		labelValues := append(append([]string{}, series.labelValues...), "+Inf")
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(labelNames, labelValues), series.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labelNames, series.labelValues), formatValue(series.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labelNames, series.labelValues), series.count)
+	}
+}
+
+func writeMetricHeader(w io.Writer, name string, help string, metricType string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+func writeGauge(w io.Writer, name string, help string, labelNames []string, labelValues []string, value float64) {
+	writeMetricHeader(w, name, help, "gauge")
+	fmt.Fprintf(w, "%s%s %s\n", name, formatLabels(labelNames, labelValues), formatValue(value))
+}
+
+// Returns the labels of a series in the Prometheus text format
+func formatLabels(labelNames []string, labelValues []string) string {
+	if len(labelNames) == 0 {
+		return ""
+	}
+	labels := make([]string, len(labelNames))
+	for i, labelName := range labelNames {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labelValues[i])
+		labels[i] = labelName + `="` + value + `"`
+	}
+	return "{" + strings.Join(labels, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// Metrics of the agent run by this container
+type agentMetrics struct {
+	lock      sync.Mutex
+	agentName string
+	health    *healthState
+
+	transfersStarted   *counterVec
+	transfersCompleted *counterVec
+	bytesTransferred   *counterVec
+	transferRetries    *counterVec
+	transferDuration   *histogramVec
+	monitorTriggers    *counterVec
+
+	// Transfers that have not completed, by ID
+	transfers map[string]*transferProgress
+	// Recently completed transfers, oldest first
+	completed      map[string]bool
+	completedOrder []string
+}
+
+func newAgentMetrics(agentName string, health *healthState) *agentMetrics {
+	agentLabels := []string{"source_agent", "destination_agent"}
+	return &agentMetrics{
+		agentName: agentName,
+		health:    health,
+		transfersStarted: newCounterVec("mqmft_transfers_started_total",
+			"Number of transfers started.", agentLabels...),
+		transfersCompleted: newCounterVec("mqmft_transfers_completed_total",
+			"Number of transfers completed, by result.", append(agentLabels, "result")...),
+		bytesTransferred: newCounterVec("mqmft_transfer_bytes_total",
+			"Number of bytes transferred.", agentLabels...),
+		transferRetries: newCounterVec("mqmft_transfer_retries_total",
+			"Number of retries of completed transfers.", agentLabels...),
+		transferDuration: newHistogramVec("mqmft_transfer_duration_seconds",
+			"Duration of completed transfers.", transferDurationBuckets, agentLabels...),
+		monitorTriggers: newCounterVec("mqmft_monitor_triggers_total",
+			"Number of resource monitor trigger evaluations, by outcome.", "monitor", "action"),
+		transfers: make(map[string]*transferProgress),
+		completed: make(map[string]bool),
+	}
+}
+
+// Update the metrics with an entry of transferlog0.json or capture0.log
+func (m *agentMetrics) recordLogEntry(entry string) {
+	if gjson.Valid(entry) {
+		if event, ok := parseTransferLogJSON(entry); ok {
+			m.recordTransfer(event)
+		}
+		return
+	}
+
+	doc := parseLogXML(entry)
+	if doc == nil {
+		return
+	}
+	if monitorLog := xmlquery.FindOne(doc, "//monitorLog"); monitorLog != nil {
+		m.recordMonitorLog(monitorLog)
+	} else if transaction := xmlquery.FindOne(doc, "//transaction"); transaction != nil {
+		if event, ok := parseTransferLogXML(transaction); ok {
+			m.recordTransfer(event)
+		}
+	}
+}
+
+func (m *agentMetrics) recordTransfer(event transferEvent) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.completed[event.id] {
+		return
+	}
+	transfer, exists := m.transfers[event.id]
+	if !exists {
+		transfer = &transferProgress{startTime: event.time}
+		m.transfers[event.id] = transfer
+		if event.kind != transferEventStarted {
+			// Started before the metrics were, so not counted as started.
+			transfer.startTime = time.Time{}
+		}
+	}
+	if len(event.sourceAgent) > 0 {
+		transfer.sourceAgent = event.sourceAgent
+	}
+	if len(event.destinationAgent) > 0 {
+		transfer.destinationAgent = event.destinationAgent
+	}
+
+	if event.kind == transferEventStarted {
+		if !exists {
+			m.transfersStarted.add(1, transfer.sourceAgent, transfer.destinationAgent)
+		}
+		return
+	}
+	// Bytes sent are cumulative, so only the increase is counted.
+	if event.bytesSent > transfer.bytesSent {
+		m.bytesTransferred.add(float64(event.bytesSent-transfer.bytesSent), transfer.sourceAgent, transfer.destinationAgent)
+		transfer.bytesSent = event.bytesSent
+	}
+	if event.kind != transferEventCompleted {
+		return
+	}
+
+	m.transfersCompleted.add(1, transfer.sourceAgent, transfer.destinationAgent, event.result)
+	m.transferRetries.add(float64(event.retryCount), transfer.sourceAgent, transfer.destinationAgent)
+	startTime := event.startTime
+	if startTime.IsZero() {
+		startTime = transfer.startTime
+	}
+	if !startTime.IsZero() && !event.time.IsZero() && !event.time.Before(startTime) {
+		m.transferDuration.observe(event.time.Sub(startTime).Seconds(), transfer.sourceAgent, transfer.destinationAgent)
+	}
+
+	delete(m.transfers, event.id)
+	m.completed[event.id] = true
+	m.completedOrder = append(m.completedOrder, event.id)
+	if len(m.completedOrder) > METRICS_COMPLETED_TRANSFERS_KEPT {
+		delete(m.completed, m.completedOrder[0])
+		m.completedOrder = m.completedOrder[1:]
+	}
+}
+
+// Count the trigger evaluations of a resource monitor
+func (m *agentMetrics) recordMonitorLog(monitorLog *xmlquery.Node) {
+	action := monitorLog.SelectElement("action")
+	if action == nil || !strings.HasPrefix(strings.TrimSpace(action.InnerText()), "trigger") {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.monitorTriggers.add(1, monitorLog.SelectAttr("monitorName"), strings.TrimSpace(action.InnerText()))
+}
+
+// Write the metrics in the Prometheus text format
+func (m *agentMetrics) write(w io.Writer) {
+	agentUp, agentReady := m.health.agentStatus()
+	writeGauge(w, "mqmft_agent_up", "Whether the agent process is running.",
+		[]string{"agent"}, []string{m.agentName}, boolValue(agentUp))
+	writeGauge(w, "mqmft_agent_ready", "Whether the agent is ready to transfer files.",
+		[]string{"agent"}, []string{m.agentName}, boolValue(agentReady))
+
+	m.lock.Lock()
+	m.transfersStarted.write(w)
+	m.transfersCompleted.write(w)
+	m.bytesTransferred.write(w)
+	m.transferRetries.write(w)
+	m.transferDuration.write(w)
+	m.monitorTriggers.write(w)
+	writeGauge(w, "mqmft_transfers_active", "Number of transfers that have started and not completed.",
+		nil, nil, float64(len(m.transfers)))
+	m.lock.Unlock()
+
+	writeMetricHeader(w, "mqmft_log_publish_errors_total", "Number of failed attempts to publish transfer log entries.", "counter")
+	fmt.Fprintf(w, "mqmft_log_publish_errors_total %d\n", logger.PublishErrors())
+	writeMetricHeader(w, "mqmft_log_publish_dropped_total", "Number of transfer log entries that were not published.", "counter")
+	fmt.Fprintf(w, "mqmft_log_publish_dropped_total %d\n", logger.DroppedEntries())
+
+	if agentCertificates != nil {
+		agentCertificates.write(w)
+	}
+}
+
+func (m *agentMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.write(w)
+}
+
+func boolValue(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// Returns the XML document of an entry of capture0.log, or nil if it has none
+func parseLogXML(entry string) *xmlquery.Node {
+	start := strings.Index(entry, "<?xml")
+	if start < 0 {
+		return nil
+	}
+	doc, err := xmlquery.Parse(strings.NewReader(entry[start:]))
+	if err != nil {
+		return nil
+	}
+	return doc
+}
+
+// Returns the transfer event of an entry of transferlog0.json
+func parseTransferLogJSON(entry string) (transferEvent, bool) {
+	event := transferEvent{
+		id:               strings.ToUpper(gjson.Get(entry, "transferId").String()),
+		sourceAgent:      gjson.Get(entry, "sourceAgent").String(),
+		destinationAgent: gjson.Get(entry, "destinationAgent").String(),
+		bytesSent:        -1,
+		time: parseEventTime(firstJSONValue(entry, "transferCompleted.time", "transferStarted.time",
+			"time").String()),
+	}
+	if len(event.id) == 0 {
+		return event, false
+	}
+	if bytesSent := firstJSONValue(entry, "progressInformation.bytesSent", "transferSet.bytesSent"); bytesSent.Exists() {
+		event.bytesSent = bytesSent.Int()
+	}
+
+	switch {
+	case gjson.Get(entry, "transferCompleted").Exists():
+		event.kind = transferEventCompleted
+		event.result = transferResult(gjson.Get(entry, "transferCompleted.resultCode").Int())
+		event.retryCount = firstJSONValue(entry, "transferCompleted.retryCount",
+			"transferCompleted.statistics.retryCount").Int()
+		event.startTime = parseEventTime(firstJSONValue(entry, "transferCompleted.actualStartTime",
+			"transferCompleted.statistics.actualStartTime").String())
+	case gjson.Get(entry, "progressInformation").Exists():
+		event.kind = transferEventProgress
+	case gjson.Get(entry, "transferStarted").Exists() ||
+		strings.Contains(strings.ToLower(gjson.Get(entry, "eventDescription").String()), "started"):
+		event.kind = transferEventStarted
+	default:
+		return event, false
+	}
+	return event, true
+}
+
+// Returns the first of the attributes that exists in the JSON entry
+func firstJSONValue(entry string, paths ...string) gjson.Result {
+	for _, path := range paths {
+		if value := gjson.Get(entry, path); value.Exists() {
+			return value
+		}
+	}
+	return gjson.Result{}
+}
+
+// Returns the transfer event of a transfer log XML message
+func parseTransferLogXML(transaction *xmlquery.Node) (transferEvent, bool) {
+	event := transferEvent{id: strings.ToUpper(transaction.SelectAttr("ID")), bytesSent: -1}
+	action := transaction.SelectElement("action")
+	if len(event.id) == 0 || action == nil {
+		return event, false
+	}
+	event.time = parseEventTime(action.SelectAttr("time"))
+	if sourceAgent := transaction.SelectElement("sourceAgent"); sourceAgent != nil {
+		event.sourceAgent = sourceAgent.SelectAttr("agent")
+	}
+	if destinationAgent := transaction.SelectElement("destinationAgent"); destinationAgent != nil {
+		event.destinationAgent = destinationAgent.SelectAttr("agent")
+	}
+	if transferSet := transaction.SelectElement("transferSet"); transferSet != nil {
+		if bytesSent, err := strconv.ParseInt(transferSet.SelectAttr("bytesSent"), 10, 64); err == nil {
+			event.bytesSent = bytesSent
+		}
+		for _, item := range transferSet.SelectElements("item") {
+			event.items = append(event.items, parseTransferItem(item))
+		}
+	}
+
+	switch strings.ToLower(strings.TrimSpace(action.InnerText())) {
+	case "started":
+		event.kind = transferEventStarted
+	case "progress":
+		event.kind = transferEventProgress
+	case "completed":
+		event.kind = transferEventCompleted
+		event.result = TRANSFER_RESULT_FAILED
+		if status := transaction.SelectElement("status"); status != nil {
+			if resultCode, err := strconv.ParseInt(status.SelectAttr("resultCode"), 10, 64); err == nil {
+				event.result = transferResult(resultCode)
+			}
+		}
+		if statistics := transaction.SelectElement("statistics"); statistics != nil {
+			if actualStartTime := statistics.SelectElement("actualStartTime"); actualStartTime != nil {
+				event.startTime = parseEventTime(actualStartTime.InnerText())
+			}
+			if retryCount := statistics.SelectElement("retryCount"); retryCount != nil {
+				event.retryCount, _ = strconv.ParseInt(strings.TrimSpace(retryCount.InnerText()), 10, 64)
+			}
+		}
+	case "cancelled":
+		event.kind = transferEventCompleted
+		event.result = TRANSFER_RESULT_CANCELLED
+	case "malformed":
+		event.kind = transferEventCompleted
+		event.result = TRANSFER_RESULT_FAILED
+	default:
+		return event, false
+	}
+	return event, true
+}
+
+// Returns an item of a transfer log XML message
+func parseTransferItem(item *xmlquery.Node) transferItem {
+	var result transferItem
+	resourceName := func(resource *xmlquery.Node) string {
+		if resource == nil {
+			return ""
+		}
+		for _, name := range []string{"file", "queue", "directory"} {
+			if element := resource.SelectElement(name); element != nil {
+				return strings.TrimSpace(element.InnerText())
+			}
+		}
+		return ""
+	}
+	result.source = resourceName(item.SelectElement("source"))
+	result.destination = resourceName(item.SelectElement("destination"))
+	if status := item.SelectElement("status"); status != nil {
+		result.resultCode, _ = strconv.ParseInt(status.SelectAttr("resultCode"), 10, 64)
+		if supplement := status.SelectElement("supplement"); supplement != nil {
+			result.supplement = strings.TrimSpace(supplement.InnerText())
+		}
+	}
+	return result
+}
+
+// Returns the result of a transfer from its result code
+func transferResult(resultCode int64) string {
+	switch resultCode {
+	case TRANSFER_RC_SUCCESSFUL:
+		return TRANSFER_RESULT_SUCCESSFUL
+	case TRANSFER_RC_PARTIALLY_SUCCESSFUL:
+		return TRANSFER_RESULT_PARTIALLY_SUCCESSFUL
+	case TRANSFER_RC_CANCELLED:
+		return TRANSFER_RESULT_CANCELLED
+	}
+	return TRANSFER_RESULT_FAILED
+}
+
+// Returns the time of a log entry, or the zero time if it can not be parsed
+func parseEventTime(value string) time.Time {
+	eventTime, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}
+	}
+	return eventTime
+}
+
+// Returns true if metrics are enabled
+func isMetricsEnabled() bool {
+	enabled, enabledSet := os.LookupEnv(MFT_ENABLE_METRICS)
+	return enabledSet && strings.EqualFold(strings.Trim(enabled, TEXT_TRIM), TEXT_YES)
+}
+
+// Returns the port of the metrics endpoint
+func getMetricsPort() string {
+	port := strings.Trim(os.Getenv(MFT_METRICS_PORT), TEXT_TRIM)
+	if len(port) == 0 {
+		return METRICS_DEFAULT_PORT
+	}
+	return port
+}
+
+// Collect metrics from the agent's log files and serve them until the context
+// is cancelled. Returns nil if metrics are disabled or could not be served.
+func startMetrics(ctx context.Context, wg *sync.WaitGroup, agentName string, agentPath string) *http.Server {
+	if !isMetricsEnabled() {
+		return nil
+	}
+	metrics := newAgentMetrics(agentName, agentHealth)
+	port := getMetricsPort()
+	mux := http.NewServeMux()
+	mux.Handle(METRICS_PATH, metrics)
+	server, err := startHTTPServer(port, mux)
+	if err != nil {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_METRICS_FAILED_0109, port, err))
+		return nil
+	}
+
+	// The log mirror reports through the event logger.
+	if eventLog == nil {
+		eventLog, _ = logger.NewLogger(os.Stdout, getDebug(), utils.IsJSONLogFormat(), agentName, "", "", -1)
+	}
+	for _, logFile := range []string{agentPath + "/logs/transferlog0.json", agentPath + "/logs/capture0.log"} {
+		if _, err := mirrorLog(ctx, wg, logFile, false, func(entry string) bool {
+			metrics.recordLogEntry(entry)
+			return false
+		}); err != nil {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_METRICS_FAILED_0109, port, err))
+		}
+	}
+	utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_METRICS_LISTENING_0108, agentName, port))
+	return server
+}
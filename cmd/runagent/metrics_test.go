@@ -0,0 +1,77 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAgentMetrics(t *testing.T) {
+	resetAgentStopRequested(t)
+	metrics := newAgentMetrics("SRC", newHealthState("SRC"))
+	entries := []string{
+		// A transfer logged in both transferlog0.json and capture0.log
+		`{"transferId":"a1","sourceAgent":"SRC","destinationAgent":"DEST","eventDescription":"Transfer started","time":"2022-01-01T00:00:00Z"}`,
+		`{"transferId":"a1","sourceAgent":"SRC","destinationAgent":"DEST","eventDescription":"Transfer progress","progressInformation":{"bytesSent":100}}`,
+		`{"transferId":"a1","sourceAgent":"SRC","destinationAgent":"DEST","eventDescription":"Transfer progress","progressInformation":{"bytesSent":250}}`,
+		`{"transferId":"a1","sourceAgent":"SRC","destinationAgent":"DEST","eventDescription":"Transfer completed","transferCompleted":{"resultCode":0,"retryCount":2,"time":"2022-01-01T00:00:20Z"}}`,
+		`2022-01-01 00:00:00!SYSTEM.FTE/Log!<?xml version="1.0" encoding="UTF-8"?><transaction version="6.00" ID="A1"><action time="2022-01-01T00:00:00Z">started</action><sourceAgent agent="SRC"/><destinationAgent agent="DEST"/></transaction>`,
+		`<?xml version="1.0" encoding="UTF-8"?><transaction version="6.00" ID="A1"><action time="2022-01-01T00:00:20Z">completed</action><sourceAgent agent="SRC"/><destinationAgent agent="DEST"/><status resultCode="0"/></transaction>`,
+		// Transfers only logged in capture0.log
+		`<?xml version="1.0" encoding="UTF-8"?><transaction version="6.00" ID="b1"><action time="2022-01-01T00:01:00Z">started</action><sourceAgent agent="SRC"/><destinationAgent agent="OTHER"/></transaction>`,
+		`<?xml version="1.0" encoding="UTF-8"?><transaction version="6.00" ID="b1"><action time="2022-01-01T00:01:02Z">progress</action><sourceAgent agent="SRC"/><destinationAgent agent="OTHER"/><transferSet bytesSent="4096" total="2"/></transaction>`,
+		`<?xml version="1.0" encoding="UTF-8"?><transaction version="6.00" ID="b1"><action time="2022-01-01T00:01:03Z">completed</action><sourceAgent agent="SRC"/><destinationAgent agent="OTHER"/><status resultCode="40"/><statistics><actualStartTime>2022-01-01T00:01:00Z</actualStartTime><retryCount>1</retryCount></statistics></transaction>`,
+		`<?xml version="1.0" encoding="UTF-8"?><transaction version="6.00" ID="b2"><action time="2022-01-01T00:02:00Z">started</action><sourceAgent agent="SRC"/><destinationAgent agent="OTHER"/></transaction>`,
+		// Resource monitor activity
+		`<?xml version="1.0" encoding="UTF-8"?><monitorLog version="6.00" monitorName="MON1"><action time="2022-01-01T00:03:00Z">triggerSatisfied</action></monitorLog>`,
+		`<?xml version="1.0" encoding="UTF-8"?><monitorLog version="6.00" monitorName="MON1"><action time="2022-01-01T00:03:00Z">start</action></monitorLog>`,
+		`Not a log entry`,
+	}
+	for _, entry := range entries {
+		metrics.recordLogEntry(entry)
+	}
+
+	var output bytes.Buffer
+	metrics.write(&output)
+	expected := []string{
+		`mqmft_agent_up{agent="SRC"} 0`,
+		`mqmft_transfers_started_total{source_agent="SRC",destination_agent="DEST"} 1`,
+		`mqmft_transfers_started_total{source_agent="SRC",destination_agent="OTHER"} 2`,
+		`mqmft_transfers_completed_total{source_agent="SRC",destination_agent="DEST",result="successful"} 1`,
+		`mqmft_transfers_completed_total{source_agent="SRC",destination_agent="OTHER",result="partially_successful"} 1`,
+		`mqmft_transfer_bytes_total{source_agent="SRC",destination_agent="DEST"} 250`,
+		`mqmft_transfer_bytes_total{source_agent="SRC",destination_agent="OTHER"} 4096`,
+		`mqmft_transfer_retries_total{source_agent="SRC",destination_agent="DEST"} 2`,
+		`mqmft_transfer_duration_seconds_bucket{source_agent="SRC",destination_agent="DEST",le="10"} 0`,
+		`mqmft_transfer_duration_seconds_bucket{source_agent="SRC",destination_agent="DEST",le="30"} 1`,
+		`mqmft_transfer_duration_seconds_sum{source_agent="SRC",destination_agent="OTHER"} 3`,
+		`mqmft_transfer_duration_seconds_count{source_agent="SRC",destination_agent="OTHER"} 1`,
+		`mqmft_monitor_triggers_total{monitor="MON1",action="triggerSatisfied"} 1`,
+		`mqmft_transfers_active 1`,
+		`# TYPE mqmft_log_publish_errors_total counter`,
+		`# TYPE mqmft_log_publish_dropped_total counter`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(output.String(), line+"\n") {
+			t.Errorf("Expected %q in metrics:\n%s", line, output.String())
+		}
+	}
+	if strings.Contains(output.String(), `action="start"`) {
+		t.Errorf("Unexpected monitor action in metrics:\n%s", output.String())
+	}
+}
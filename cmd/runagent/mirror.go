@@ -19,9 +19,14 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
+
+	"github.com/ibm-messaging/mq-container-mft/pkg/logger"
 )
 
 // waitForFile waits until the specified file exists
@@ -200,3 +205,138 @@ func mirrorLog(ctx context.Context, wg *sync.WaitGroup, path string, fromStart b
 	}()
 	return errorChannel, nil
 }
+
+type positionFunc func(msg string, position logger.LogPosition)
+
+// Identity of a log file, which stays the same across restarts until the file
+// is rotated
+func logFileIdentity(fi os.FileInfo) (uint64, uint64) {
+	if stat, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return uint64(stat.Dev), uint64(stat.Ino)
+	}
+	return 0, 0
+}
+
+// tailLogFrom tails the specified file from the checkpoint, passing each
+// complete line with the position following it. The file is read from the
+// start if there is no checkpoint or the checkpoint was recorded in another
+// file, so that no line is missed after a restart or rotation.
+func tailLogFrom(ctx context.Context, wg *sync.WaitGroup, path string, checkpoint *logger.LogPosition, pf positionFunc) chan error {
+	errorChannel := make(chan error, 1)
+	wg.Add(1)
+	go func() {
+		defer func() {
+			eventLog.Debugf("Finished monitoring %v", path)
+			wg.Done()
+		}()
+		fi, err := waitForFile(ctx, path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				eventLog.Error(err)
+				errorChannel <- err
+			}
+			return
+		}
+		f, err := os.OpenFile(path, os.O_RDONLY, 0)
+		if err != nil {
+			eventLog.Error(err)
+			errorChannel <- err
+			return
+		}
+		defer func() {
+			f.Close()
+		}()
+		fi, err = f.Stat()
+		if err != nil {
+			eventLog.Error(err)
+			errorChannel <- err
+			return
+		}
+
+		var position logger.LogPosition
+		position.Device, position.Inode = logFileIdentity(fi)
+		if checkpoint != nil && checkpoint.Device == position.Device && checkpoint.Inode == position.Inode &&
+			checkpoint.Offset <= fi.Size() {
+			eventLog.Debugf("Seeking offset %v in file %v", checkpoint.Offset, path)
+			if _, err = f.Seek(checkpoint.Offset, io.SeekStart); err != nil {
+				eventLog.Errorf("Unable to return to offset %v: %v", checkpoint.Offset, err)
+			} else {
+				position.Offset = checkpoint.Offset
+			}
+		}
+
+		reader := bufio.NewReader(f)
+		// Start of a line still being written
+		partial := ""
+		readLines := func() {
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					partial += line
+					return
+				}
+				line = partial + line
+				partial = ""
+				position.Offset += int64(len(line))
+				pf(strings.TrimRight(line, "\r\n"), position)
+			}
+		}
+		closing := false
+
+		for {
+			readLines()
+			newFI, err := waitForFile(ctx, path)
+			if err != nil {
+				eventLog.Error(err)
+				errorChannel <- err
+				return
+			}
+			if !os.SameFile(fi, newFI) {
+				eventLog.Debugf("Detected log rotation in file %v", path)
+				readLines()
+				if err = f.Close(); err != nil {
+					eventLog.Errorf("Unable to close mirror file handle: %v", err)
+				}
+				f, err = os.OpenFile(path, os.O_RDONLY, 0)
+				if err != nil {
+					eventLog.Error(err)
+					errorChannel <- err
+					return
+				}
+				if fi, err = f.Stat(); err != nil {
+					eventLog.Error(err)
+					errorChannel <- err
+					return
+				}
+				position = logger.LogPosition{}
+				position.Device, position.Inode = logFileIdentity(fi)
+				reader.Reset(f)
+				partial = ""
+				readLines()
+			} else if newFI.Size() < position.Offset+int64(len(partial)) {
+				eventLog.Debugf("Detected truncation of file %v", path)
+				if _, err = f.Seek(0, io.SeekStart); err != nil {
+					eventLog.Error(err)
+					errorChannel <- err
+					return
+				}
+				position.Offset = 0
+				reader.Reset(f)
+				partial = ""
+			}
+			select {
+			case <-ctx.Done():
+				eventLog.Debugf("Context cancelled for mirroring %v", path)
+				if closing {
+					eventLog.Debugf("Shutting down mirror for %v", path)
+					return
+				}
+				// Set a flag, to allow one more time through the loop
+				closing = true
+			default:
+				time.Sleep(500 * time.Millisecond)
+			}
+		}
+	}()
+	return errorChannel
+}
@@ -0,0 +1,102 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ibm-messaging/mq-container-mft/pkg/logger"
+)
+
+// Tail the file from the checkpoint until the expected number of lines is read
+func tailTestLog(t *testing.T, path string, checkpoint *logger.LogPosition, count int, write func()) ([]string, []logger.LogPosition) {
+	var lock sync.Mutex
+	var lines []string
+	var positions []logger.LogPosition
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	tailLogFrom(ctx, &wg, path, checkpoint, func(msg string, position logger.LogPosition) {
+		lock.Lock()
+		defer lock.Unlock()
+		lines = append(lines, msg)
+		positions = append(positions, position)
+	})
+	if write != nil {
+		write()
+	}
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		lock.Lock()
+		read := len(lines)
+		lock.Unlock()
+		if read >= count {
+			break
+		}
+	}
+	cancel()
+	wg.Wait()
+	return lines, positions
+}
+
+func TestTailLogFromCheckpoint(t *testing.T) {
+	savedEventLog := eventLog
+	defer func() { eventLog = savedEventLog }()
+	eventLog, _ = logger.NewLogger(ioutil.Discard, false, false, "SRC", "", "", -1)
+
+	path := filepath.Join(t.TempDir(), "transferlog0.json")
+	if err := os.WriteFile(path, []byte("entry1\nentry2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var checkpoint logger.LogPosition
+	checkpoint.Device, checkpoint.Inode = logFileIdentity(fi)
+	checkpoint.Offset = 7
+
+	// Lines after the checkpoint are read, and a line is only passed on once
+	// it is complete.
+	lines, positions := tailTestLog(t, path, &checkpoint, 2, func() {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		f.WriteString("ent")
+		time.Sleep(600 * time.Millisecond)
+		f.WriteString("ry3\n")
+	})
+	if !reflect.DeepEqual(lines, []string{"entry2", "entry3"}) {
+		t.Fatalf("Unexpected lines %v", lines)
+	}
+	if positions[1].Offset != 21 || positions[1].Inode != checkpoint.Inode {
+		t.Errorf("Unexpected position %v", positions[1])
+	}
+
+	// The whole file is read if the checkpoint was recorded in another file.
+	checkpoint.Inode++
+	lines, _ = tailTestLog(t, path, &checkpoint, 3, nil)
+	if !reflect.DeepEqual(lines, []string{"entry1", "entry2", "entry3"}) {
+		t.Errorf("Unexpected lines %v", lines)
+	}
+}
@@ -0,0 +1,418 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+/*
+ * Encoding of PKCS#12 key stores (RFC 7292).
+ *
+ * Certificates and private keys are encrypted with AES-256-CBC, with a key
+ * derived from the password by PBKDF2 with HMAC-SHA256 (PBES2 of RFC 8018), and
+ * the store is protected by an HMAC-SHA256. These are the algorithms of the
+ * PKCS#12 stores created by OpenSSL 3 and Java 20 by default, which OpenSSL
+ * 1.1.1 and Java 12 and later read, as do Java 8u301 and 11.0.12. The key of
+ * the MAC is derived as described in appendix B of RFC 7292, as stores protected
+ * by a MAC keyed by PBKDF2 can not be read by the Java runtimes of the agents.
+ * Trusted certificates carry the attribute
+ * Java uses to recognise trusted certificate entries of a PKCS#12 store. The
+ * tests check the stores against openssl, and against keytool where it is
+ * installed.
+ */
+
+var (
+	oidDataContentType          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidEncryptedDataContentType = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 6}
+	oidPKCS8ShroudedKeyBag      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 2}
+	oidCertBag                  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+	oidCertTypeX509Certificate  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}
+	oidFriendlyName             = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 20}
+	oidLocalKeyID               = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 21}
+	oidPBES2                    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2                   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA256           = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES256CBC                = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidSHA256                   = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	// Attribute of trusted certificate entries in Java, and its value
+	oidJavaTrustedKeyUsage = asn1.ObjectIdentifier{2, 16, 840, 1, 113894, 746875, 1, 1}
+	oidAnyExtendedKeyUsage = asn1.ObjectIdentifier{2, 5, 29, 37, 0}
+)
+
+// Iterations of the derivation of keys from the password
+const pkcs12Iterations = 2048
+
+// Length of the salts
+const pkcs12SaltLength = 16
+
+// Length of the keys of AES-256
+const pkcs12KeyLength = 32
+
+// Purpose of the key of the MAC in the derivation of RFC 7292
+const pkcs12MACMaterial byte = 3
+
+type pfxPdu struct {
+	Version  int
+	AuthSafe contentInfo
+	MacData  macData `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"tag:0,explicit,optional"`
+}
+
+type encryptedData struct {
+	Version              int
+	EncryptedContentInfo encryptedContentInfo
+}
+
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           []byte `asn1:"tag:0,optional"`
+}
+
+type safeBag struct {
+	ID         asn1.ObjectIdentifier
+	Value      asn1.RawValue     `asn1:"tag:0,explicit"`
+	Attributes []pkcs12Attribute `asn1:"set,optional"`
+}
+
+type pkcs12Attribute struct {
+	ID    asn1.ObjectIdentifier
+	Value asn1.RawValue `asn1:"set"`
+}
+
+type certBag struct {
+	ID   asn1.ObjectIdentifier
+	Data []byte `asn1:"tag:0,explicit"`
+}
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt       []byte
+	Iterations int
+	PRF        pkix.AlgorithmIdentifier
+}
+
+type macData struct {
+	Mac        digestInfo
+	MacSalt    []byte
+	Iterations int `asn1:"optional,default:1"`
+}
+
+type digestInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	Digest    []byte
+}
+
+// A certificate of a key store and the alias of its entry
+type aliasedCertificate struct {
+	alias string
+	cert  *x509.Certificate
+}
+
+// Entries of a key store
+type pkcs12Entries struct {
+	// Private key in PKCS#8 form, the alias of its entry and its certificate
+	// chain, starting with the certificate of the key
+	key      []byte
+	keyAlias string
+	chain    []*x509.Certificate
+	// Trusted certificates
+	trusted []aliasedCertificate
+}
+
+// Encode the entries as a PKCS#12 key store protected by a password
+func encodePKCS12(entries pkcs12Entries, password string) ([]byte, error) {
+	// The password is encoded as a null terminated BMPString
+	encodedPassword := append(bmpString(password), 0, 0)
+	var certBags []safeBag
+	var keyBags []safeBag
+
+	if len(entries.key) > 0 {
+		if len(entries.chain) == 0 {
+			return nil, errors.New("a private key must have a certificate")
+		}
+		localKeyID := sha1.Sum(entries.chain[0].Raw)
+		attributes := []pkcs12Attribute{
+			friendlyNameAttribute(entries.keyAlias),
+			localKeyIDAttribute(localKeyID[:]),
+		}
+		keyBag, err := shroudedKeyBag(entries.key, []byte(password), attributes)
+		if err != nil {
+			return nil, err
+		}
+		keyBags = append(keyBags, keyBag)
+		for i, cert := range entries.chain {
+			var bagAttributes []pkcs12Attribute
+			if i == 0 {
+				bagAttributes = attributes
+			}
+			bag, err := certificateBag(cert, bagAttributes)
+			if err != nil {
+				return nil, err
+			}
+			certBags = append(certBags, bag)
+		}
+	}
+	for _, trusted := range entries.trusted {
+		bag, err := certificateBag(trusted.cert, []pkcs12Attribute{
+			friendlyNameAttribute(trusted.alias),
+			trustedKeyUsageAttribute(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		certBags = append(certBags, bag)
+	}
+
+	var authSafe []contentInfo
+	if len(certBags) > 0 {
+		info, err := encryptedContent(certBags, []byte(password))
+		if err != nil {
+			return nil, err
+		}
+		authSafe = append(authSafe, info)
+	}
+	if len(keyBags) > 0 {
+		info, err := dataContent(keyBags)
+		if err != nil {
+			return nil, err
+		}
+		authSafe = append(authSafe, info)
+	}
+	encodedAuthSafe, err := asn1.Marshal(authSafe)
+	if err != nil {
+		return nil, err
+	}
+
+	pfx := pfxPdu{Version: 3}
+	pfx.AuthSafe.ContentType = oidDataContentType
+	if pfx.AuthSafe.Content, err = explicitContent(encodedAuthSafe); err != nil {
+		return nil, err
+	}
+	salt, err := randomBytes(pkcs12SaltLength)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, pkcs12MACKey(salt, encodedPassword, pkcs12Iterations))
+	mac.Write(encodedAuthSafe)
+	pfx.MacData = macData{
+		Mac: digestInfo{
+			Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue},
+			Digest:    mac.Sum(nil),
+		},
+		MacSalt:    salt,
+		Iterations: pkcs12Iterations,
+	}
+	return asn1.Marshal(pfx)
+}
+
+// Returns a bag holding a certificate
+func certificateBag(cert *x509.Certificate, attributes []pkcs12Attribute) (safeBag, error) {
+	encoded, err := asn1.Marshal(certBag{ID: oidCertTypeX509Certificate, Data: cert.Raw})
+	if err != nil {
+		return safeBag{}, err
+	}
+	return safeBag{ID: oidCertBag, Value: explicitValue(encoded), Attributes: attributes}, nil
+}
+
+// Returns a bag holding a PKCS#8 private key encrypted with the password
+func shroudedKeyBag(key []byte, password []byte, attributes []pkcs12Attribute) (safeBag, error) {
+	algorithm, encrypted, err := pbeEncrypt(key, password)
+	if err != nil {
+		return safeBag{}, err
+	}
+	encoded, err := asn1.Marshal(encryptedPrivateKeyInfo{Algorithm: algorithm, EncryptedData: encrypted})
+	if err != nil {
+		return safeBag{}, err
+	}
+	return safeBag{ID: oidPKCS8ShroudedKeyBag, Value: explicitValue(encoded), Attributes: attributes}, nil
+}
+
+// Returns content holding bags as they are
+func dataContent(bags []safeBag) (contentInfo, error) {
+	encodedBags, err := asn1.Marshal(bags)
+	if err != nil {
+		return contentInfo{}, err
+	}
+	content, err := explicitContent(encodedBags)
+	return contentInfo{ContentType: oidDataContentType, Content: content}, err
+}
+
+// Returns content holding bags encrypted with the password
+func encryptedContent(bags []safeBag, password []byte) (contentInfo, error) {
+	encodedBags, err := asn1.Marshal(bags)
+	if err != nil {
+		return contentInfo{}, err
+	}
+	algorithm, encrypted, err := pbeEncrypt(encodedBags, password)
+	if err != nil {
+		return contentInfo{}, err
+	}
+	encoded, err := asn1.Marshal(encryptedData{
+		EncryptedContentInfo: encryptedContentInfo{
+			ContentType:                oidDataContentType,
+			ContentEncryptionAlgorithm: algorithm,
+			EncryptedContent:           encrypted,
+		},
+	})
+	if err != nil {
+		return contentInfo{}, err
+	}
+	return contentInfo{ContentType: oidEncryptedDataContentType, Content: explicitValue(encoded)}, nil
+}
+
+// Wrap encoded data in an OCTET STRING tagged [0]
+func explicitContent(data []byte) (asn1.RawValue, error) {
+	encoded, err := asn1.Marshal(data)
+	return explicitValue(encoded), err
+}
+
+// Tag an encoded value [0]
+func explicitValue(encoded []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: encoded}
+}
+
+// Returns an attribute with a single encoded value
+func singleValueAttribute(id asn1.ObjectIdentifier, encoded []byte) pkcs12Attribute {
+	return pkcs12Attribute{ID: id, Value: asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: encoded}}
+}
+
+func friendlyNameAttribute(alias string) pkcs12Attribute {
+	encoded, _ := asn1.Marshal(asn1.RawValue{Tag: asn1.TagBMPString, Bytes: bmpString(alias)})
+	return singleValueAttribute(oidFriendlyName, encoded)
+}
+
+func localKeyIDAttribute(id []byte) pkcs12Attribute {
+	encoded, _ := asn1.Marshal(id)
+	return singleValueAttribute(oidLocalKeyID, encoded)
+}
+
+func trustedKeyUsageAttribute() pkcs12Attribute {
+	encoded, _ := asn1.Marshal(oidAnyExtendedKeyUsage)
+	return singleValueAttribute(oidJavaTrustedKeyUsage, encoded)
+}
+
+// Encrypt data with a key derived from the password by PBES2. Returns the
+// algorithm and its parameters as well.
+func pbeEncrypt(data []byte, password []byte) (pkix.AlgorithmIdentifier, []byte, error) {
+	salt, err := randomBytes(pkcs12SaltLength)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+	iv, err := randomBytes(aes.BlockSize)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+	kdfParams, err := asn1.Marshal(pbkdf2Params{
+		Salt:       salt,
+		Iterations: pkcs12Iterations,
+		PRF:        pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue},
+	})
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+	encodedIV, err := asn1.Marshal(iv)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdfParams}},
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: encodedIV}},
+	})
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+	block, err := aes.NewCipher(pbkdf2.Key(password, salt, pkcs12Iterations, pkcs12KeyLength, sha256.New))
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+
+	// Pad as described by PKCS#7
+	padding := block.BlockSize() - len(data)%block.BlockSize()
+	encrypted := append(append([]byte(nil), data...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, encrypted)
+	algorithm := pkix.AlgorithmIdentifier{
+		Algorithm:  oidPBES2,
+		Parameters: asn1.RawValue{FullBytes: params},
+	}
+	return algorithm, encrypted, nil
+}
+
+func randomBytes(size int) ([]byte, error) {
+	data := make([]byte, size)
+	_, err := rand.Read(data)
+	return data, err
+}
+
+// Returns the content of a string encoded as a BMPString
+func bmpString(s string) []byte {
+	encoded := make([]byte, 0, 2*len(s)+2)
+	for _, c := range utf16.Encode([]rune(s)) {
+		encoded = append(encoded, byte(c>>8), byte(c))
+	}
+	return encoded
+}
+
+// Derive the key of the MAC of a key store from the password with SHA-256, as
+// described in appendix B of RFC 7292. The key is the size of a digest, so it
+// is the first block of key material.
+func pkcs12MACKey(salt []byte, password []byte, iterations int) []byte {
+	// Concatenate copies of a value to a multiple of the block size
+	fill := func(value []byte) []byte {
+		if len(value) == 0 {
+			return nil
+		}
+		filled := make([]byte, sha256.BlockSize*((len(value)+sha256.BlockSize-1)/sha256.BlockSize))
+		for i := range filled {
+			filled[i] = value[i%len(value)]
+		}
+		return filled
+	}
+	input := bytes.Repeat([]byte{pkcs12MACMaterial}, sha256.BlockSize)
+	input = append(append(input, fill(salt)...), fill(password)...)
+	digest := sha256.Sum256(input)
+	for i := 1; i < iterations; i++ {
+		digest = sha256.Sum256(digest[:])
+	}
+	return digest[:]
+}
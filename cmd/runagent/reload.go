@@ -0,0 +1,431 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+/**
+* Reload of the agent configuration while the agent is running.
+*
+* The configuration file is checked for changes at a fixed interval, and on
+* SIGHUP. A changed configuration is validated like at startup and compared with
+* the running configuration of the agent:
+*  - Resource monitors are created and deleted, and ProtocolBridgeProperties.xml
+*    is rewritten, while the agent runs. A monitor whose definition file has
+*    been edited is deleted and created again.
+*  - Changes to any other attribute of the agent, like additionalProperties, are
+*    applied by stopping the agent, recreating its configuration and starting it.
+*  - Changes outside the agent, like coordinationQMgr, need a restart of the
+*    container and are only reported. The running values are kept as the
+*    applied configuration, so the changes are reported until they are applied.
+ */
+
+// Built in interval at which the configuration file is checked
+const CONFIG_RELOAD_DEFAULT_INTERVAL = 10 * time.Second
+
+// Agent attributes applied while the agent runs
+var configLiveBridgeAttributes = []string{"protocolServers", "defaultServer",
+	"maxActiveDestinationTransfers", "failTransferWhenCapacityReached"}
+
+// Agent attributes only used when the agent is started or the container ends.
+// The latest value is picked up at that point.
+var configDeferredAttributes = []string{"name", "cleanOnStart", "deleteOnTermination"}
+
+// Requests to reload the configuration, sent on SIGHUP
+var configReloadRequests = make(chan struct{}, 1)
+
+// Request a reload of the configuration without waiting for the next check
+func requestConfigReload() {
+	select {
+	case configReloadRequests <- struct{}{}:
+	default:
+		// A reload is already pending
+	}
+}
+
+// Differences between the running and the reloaded configuration
+type configChanges struct {
+	// Resource monitors to delete, including monitors that have changed
+	monitorsDeleted []string
+	// Resource monitors to create, including monitors that have changed
+	monitorsCreated []string
+	// Protocol bridge attributes have changed
+	bridgeChanged bool
+	// Agent attributes that need the agent to be restarted
+	agentRestartAttributes []string
+	// Attributes that need the container to be restarted
+	containerRestartAttributes []string
+}
+
+// Returns true if nothing needs to be applied
+func (c configChanges) empty() bool {
+	return len(c.monitorsDeleted) == 0 && len(c.monitorsCreated) == 0 && !c.bridgeChanged &&
+		len(c.agentRestartAttributes) == 0 && len(c.containerRestartAttributes) == 0
+}
+
+// Reloads the configuration of an agent
+type configReloader struct {
+	configFile       string
+	bfgDataPath      string
+	coordinationQMgr string
+	agentName        string
+	pingWaitTime     string
+
+	// Held while the applied configuration is read or replaced. Only the
+	// goroutine reloading the configuration replaces it.
+	lock sync.Mutex
+	// Resolved configuration currently applied
+	allAgentConfig string
+	agentConfig    string
+	// Resolved configuration last read from the file and applied, which may
+	// differ from the applied configuration by changes that need a restart of
+	// the container
+	loadedConfig string
+	// Digest of the definition file of each resource monitor when applied
+	monitorDigests map[string]string
+	// Last configuration that could not be applied, so that it is reported once
+	rejectedConfig string
+}
+
+func newConfigReloader(configFile string, bfgDataPath string, coordinationQMgr string, agentName string,
+	pingWaitTime string, allAgentConfig string, agentConfig string) *configReloader {
+	return &configReloader{
+		configFile:       configFile,
+		bfgDataPath:      bfgDataPath,
+		coordinationQMgr: coordinationQMgr,
+		agentName:        agentName,
+		pingWaitTime:     pingWaitTime,
+		allAgentConfig:   allAgentConfig,
+		agentConfig:      agentConfig,
+		loadedConfig:     allAgentConfig,
+		monitorDigests:   resourceMonitorDigests(agentConfig),
+	}
+}
+
+// Returns the configuration of the agent currently applied
+func (r *configReloader) currentAgentConfig() string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.agentConfig
+}
+
+// Returns the configuration of all agents and of the agent currently applied
+func (r *configReloader) currentConfig() (string, string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.allAgentConfig, r.agentConfig
+}
+
+// Check the configuration for changes until the context is cancelled
+func (r *configReloader) watch(ctx context.Context) {
+	interval := CONFIG_RELOAD_DEFAULT_INTERVAL
+	if value, ok := envNumber(MFT_AGENT_CONFIG_RELOAD_INTERVAL, 0); ok {
+		interval = time.Duration(value * float64(time.Second))
+	}
+
+	go func() {
+		// Without an interval the configuration is only reloaded on request.
+		var checks <-chan time.Time
+		if interval > 0 {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			checks = ticker.C
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-checks:
+				r.reload(ctx, false)
+			case <-configReloadRequests:
+				r.reload(ctx, true)
+			}
+		}
+	}()
+}
+
+// Read the configuration file and apply any changes. Problems with the same
+// configuration are only reported again if the reload was requested.
+func (r *configReloader) reload(ctx context.Context, requested bool) {
+	configFileData, err := utils.ReadConfigurationDataFromFile(r.configFile)
+	if err != nil {
+		r.reject(err.Error(), requested, err)
+		return
+	}
+	allAgentConfig, err := utils.ResolveConfigurationReferences(configFileData)
+	if err != nil {
+		r.reject(configFileData, requested, err)
+		return
+	}
+	// Changes that need a restart of the container are reported again when a
+	// reload is requested.
+	unchanged := allAgentConfig == r.loadedConfig && reflect.DeepEqual(resourceMonitorDigests(r.agentConfig), r.monitorDigests)
+	if unchanged && (!requested || allAgentConfig == r.allAgentConfig) {
+		if requested {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CFG_RELOAD_NO_CHANGES_0096, r.agentName, r.configFile))
+		}
+		return
+	}
+	if allAgentConfig == r.rejectedConfig && !requested {
+		return
+	}
+
+	utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CFG_RELOADING_0094, r.configFile, r.agentName))
+	if violations := ValidateConfigurationSchema(allAgentConfig); len(violations) > 0 {
+		for _, violation := range violations {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CFG_SCHEMA_VIOLATION_0080, violation))
+		}
+		r.reject(allAgentConfig, true, fmt.Errorf("%d schema violation(s) found", len(violations)))
+		return
+	}
+	agentConfig, found := findAgentConfig(allAgentConfig, r.agentName)
+	if !found {
+		r.reject(allAgentConfig, true, fmt.Errorf("configuration of agent %s not found", r.agentName))
+		return
+	}
+	if err := ValidateAgentAttributes(agentConfig); err != nil {
+		r.reject(allAgentConfig, true, err)
+		return
+	}
+
+	monitorDigests := resourceMonitorDigests(agentConfig)
+	changes := diffConfiguration(r.allAgentConfig, allAgentConfig, r.agentConfig, agentConfig, r.monitorDigests, monitorDigests)
+	if changes.empty() {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CFG_RELOAD_NO_CHANGES_0096, r.agentName, r.configFile))
+	} else if !r.apply(ctx, changes, agentConfig) {
+		// The agent could not be restarted. Supervision deals with the agent
+		// from here, so keep the configuration to avoid restarting again.
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CFG_RELOAD_FAILED_0100, r.agentName))
+	} else {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CFG_RELOADED_0099, r.agentName))
+	}
+	r.lock.Lock()
+	r.allAgentConfig = withRunningAttributes(r.allAgentConfig, allAgentConfig, changes.containerRestartAttributes)
+	r.agentConfig = agentConfig
+	r.lock.Unlock()
+	r.loadedConfig = allAgentConfig
+	r.monitorDigests = monitorDigests
+	r.rejectedConfig = TEXT_BLANK
+}
+
+// Report a configuration that could not be applied
+func (r *configReloader) reject(config string, report bool, err error) {
+	if config == r.rejectedConfig && !report {
+		return
+	}
+	r.rejectedConfig = config
+	utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CFG_RELOAD_REJECTED_0095, r.configFile, err))
+}
+
+// Apply the changes to the running agent. Returns false if the agent had to be
+// restarted and could not be.
+func (r *configReloader) apply(ctx context.Context, changes configChanges, agentConfig string) bool {
+	agentLifecycleLock.Lock()
+	defer agentLifecycleLock.Unlock()
+	// Nothing to do if the container is being stopped.
+	if isAgentStopRequested() {
+		return true
+	}
+
+	if len(changes.containerRestartAttributes) > 0 {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CFG_RELOAD_CONTAINER_RESTART_0097, strings.Join(changes.containerRestartAttributes, ", ")))
+	}
+
+	bridgeAgent := strings.EqualFold(gjson.Get(agentConfig, "type").String(), AGENT_TYPE_BRIDGE)
+	agentConfigPath := r.bfgDataPath + MFT_CONFIG_PATH_SUFFIX + r.coordinationQMgr + MFT_AGENTS_SLASH + r.agentName
+	applied := true
+	if len(changes.agentRestartAttributes) > 0 {
+		// Recreating the agent configuration also rewrites the sandbox and
+		// protocol bridge properties.
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CFG_RELOAD_AGENT_RESTART_0098, r.agentName, strings.Join(changes.agentRestartAttributes, ", ")))
+		applied = r.restartAgent(ctx, agentConfig)
+	} else if bridgeAgent && changes.bridgeChanged {
+		updateProtocolBridgePropertiesFile(agentConfigPath+MFT_PBA_PROPS_SLASH, agentConfig)
+	}
+
+	agentQMgr := gjson.Get(agentConfig, "qmgrName").String()
+	monitorFiles := resourceMonitorFiles(agentConfig)
+	for _, monitorName := range changes.monitorsDeleted {
+		deleteResourceMonitor(r.coordinationQMgr, r.agentName, agentQMgr, monitorName)
+	}
+	for _, monitorName := range changes.monitorsCreated {
+		createResourceMonitor(ctx, r.coordinationQMgr, r.agentName, agentQMgr, monitorName, monitorFiles[monitorName])
+	}
+	return applied
+}
+
+// Stop the agent once current transfers are complete, recreate its
+// configuration and start it again
+func (r *configReloader) restartAgent(ctx context.Context, agentConfig string) bool {
+	agentHealth.setRestarting(true)
+	defer agentHealth.setRestarting(false)
+	if interrupted, stopped := drainAgent(r.agentName, r.coordinationQMgr); !stopped {
+		result := runMFTCommand("fteStopAgent", "-p", r.coordinationQMgr, r.agentName, "-i")
+		if result.NotFound() {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_NOT_FOUND_0028, result.Err))
+			return false
+		} else if result.Err != nil {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_ERROR_0042, result.Stdout, result.Stderr))
+			return false
+		}
+		logInterruptedTransfers(r.agentName, interrupted)
+	}
+	utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGENT_STOPPED_0068, r.agentName))
+
+	return SetupAgent(ctx, agentConfig, r.bfgDataPath, r.coordinationQMgr) &&
+		StartAgent(ctx, r.agentName, r.coordinationQMgr) &&
+		PingAgent(ctx, r.coordinationQMgr, r.agentName, r.pingWaitTime)
+}
+
+// Returns the configuration of the named agent
+func findAgentConfig(allAgentConfig string, agentName string) (string, bool) {
+	for _, agent := range gjson.Get(allAgentConfig, "agents").Array() {
+		if strings.EqualFold(strings.Trim(agent.Get("name").String(), TEXT_TRIM), agentName) {
+			return agent.String(), true
+		}
+	}
+	return TEXT_BLANK, false
+}
+
+// Compare the running configuration with the reloaded one. Resource monitors
+// are compared by the digests of their definition files.
+func diffConfiguration(oldAllConfig string, newAllConfig string, oldAgentConfig string, newAgentConfig string,
+	oldMonitorDigests map[string]string, newMonitorDigests map[string]string) configChanges {
+	var changes configChanges
+	for _, key := range changedAttributes(oldAllConfig, newAllConfig) {
+		// Other agents are of no interest to this container.
+		if key != "agents" {
+			changes.containerRestartAttributes = append(changes.containerRestartAttributes, key)
+		}
+	}
+
+	for _, key := range changedAttributes(oldAgentConfig, newAgentConfig) {
+		switch {
+		case key == "resourceMonitors":
+			// Compared below, with the contents of the definition files.
+		case containsString(configLiveBridgeAttributes, key):
+			changes.bridgeChanged = true
+		case containsString(configDeferredAttributes, key):
+		default:
+			changes.agentRestartAttributes = append(changes.agentRestartAttributes, key)
+		}
+	}
+	changes.monitorsDeleted, changes.monitorsCreated = diffResourceMonitors(oldMonitorDigests, newMonitorDigests)
+	return changes
+}
+
+// Returns the configuration with the given attributes, which have not been
+// applied, set back to their running values
+func withRunningAttributes(runningConfig string, newConfig string, attributes []string) string {
+	config := newConfig
+	for _, key := range attributes {
+		if value := gjson.Get(runningConfig, key); value.Exists() {
+			config, _ = sjson.SetRaw(config, key, value.Raw)
+		} else {
+			config, _ = sjson.Delete(config, key)
+		}
+	}
+	return config
+}
+
+// Returns the sorted names of attributes of two JSON objects that differ
+func changedAttributes(oldConfig string, newConfig string) []string {
+	oldValues, _ := gjson.Parse(oldConfig).Value().(map[string]interface{})
+	newValues, _ := gjson.Parse(newConfig).Value().(map[string]interface{})
+	changed := make([]string, 0)
+	for key, value := range newValues {
+		if !reflect.DeepEqual(oldValues[key], value) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range oldValues {
+		if _, exists := newValues[key]; !exists {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// Returns the resource monitors to delete and to create. A monitor whose
+// definition file, or its contents, has changed is deleted and created again.
+func diffResourceMonitors(oldMonitorDigests map[string]string, newMonitorDigests map[string]string) ([]string, []string) {
+	deleted := make([]string, 0)
+	created := make([]string, 0)
+	for name, digest := range oldMonitorDigests {
+		if newDigest, exists := newMonitorDigests[name]; !exists || newDigest != digest {
+			deleted = append(deleted, name)
+		}
+	}
+	for name, digest := range newMonitorDigests {
+		if oldDigest, exists := oldMonitorDigests[name]; !exists || oldDigest != digest {
+			created = append(created, name)
+		}
+	}
+	sort.Strings(deleted)
+	sort.Strings(created)
+	return deleted, created
+}
+
+// Returns the definition file of each resource monitor of the agent with a
+// digest of its contents. The digest is blank if the file can not be read.
+func resourceMonitorDigests(agentConfig string) map[string]string {
+	digests := make(map[string]string)
+	for name, fileName := range resourceMonitorFiles(agentConfig) {
+		digest := TEXT_BLANK
+		// #nosec G304
+		if data, err := os.ReadFile(fileName); err == nil {
+			sum := sha256.Sum256(data)
+			digest = hex.EncodeToString(sum[:])
+		}
+		digests[name] = fileName + ":" + digest
+	}
+	return digests
+}
+
+// Returns the definition file of each resource monitor of the agent
+func resourceMonitorFiles(agentConfig string) map[string]string {
+	monitors := make(map[string]string)
+	gjson.Get(agentConfig, "resourceMonitors").ForEach(func(key, value gjson.Result) bool {
+		monitors[key.String()] = value.String()
+		return true
+	})
+	return monitors
+}
+
+func containsString(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,221 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
+	"github.com/tidwall/gjson"
+)
+
+const reloadTestConfig = `{
+	"coordinationQMgr":{"name":"COORDQM","host":"localhost"},
+	"commandQMgr":{"name":"CMDQM","host":"localhost"},
+	"agents":[{"name":"SRC","qmgrName":"AGENTQM","qmgrHost":"localhost",
+		"additionalProperties":{"enableQueueInputOutput":"true"},
+		"resourceMonitors":{"MON1":"/mon/mon1.xml","MON2":"/mon/mon2.xml"}}]
+}`
+
+// Returns a reloader for the given configuration, as if the agent had been
+// started with it
+func newTestConfigReloader(t *testing.T, configData string) *configReloader {
+	configFile := filepath.Join(t.TempDir(), "agentconfig.json")
+	if err := os.WriteFile(configFile, []byte(configData), 0644); err != nil {
+		t.Fatal(err)
+	}
+	agentConfig, _ := findAgentConfig(configData, "SRC")
+	resetAgentStopRequested(t)
+	return newConfigReloader(configFile, t.TempDir(), "COORDQM", "SRC", "1", configData, agentConfig)
+}
+
+func updateTestConfig(t *testing.T, reloader *configReloader, configData string) {
+	if err := os.WriteFile(reloader.configFile, []byte(configData), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiffConfiguration(t *testing.T) {
+	newConfig := strings.Replace(reloadTestConfig, `"MON1":"/mon/mon1.xml","MON2":"/mon/mon2.xml"`, `"MON1":"/mon/mon1v2.xml","MON3":"/mon/mon3.xml"`, 1)
+	newConfig = strings.Replace(newConfig, `"qmgrHost":"localhost",`, `"qmgrHost":"agentqm.example.com","deleteOnTermination":true,`, 1)
+	newConfig = strings.Replace(newConfig, `"commandQMgr":{"name":"CMDQM"`, `"commandQMgr":{"name":"CMDQM2"`, 1)
+	oldAgent, _ := findAgentConfig(reloadTestConfig, "SRC")
+	newAgent, _ := findAgentConfig(newConfig, "SRC")
+
+	changes := diffConfiguration(reloadTestConfig, newConfig, oldAgent, newAgent,
+		resourceMonitorDigests(oldAgent), resourceMonitorDigests(newAgent))
+	if !reflect.DeepEqual(changes.monitorsDeleted, []string{"MON1", "MON2"}) ||
+		!reflect.DeepEqual(changes.monitorsCreated, []string{"MON1", "MON3"}) {
+		t.Errorf("Unexpected monitor changes %v %v", changes.monitorsDeleted, changes.monitorsCreated)
+	}
+	if !reflect.DeepEqual(changes.agentRestartAttributes, []string{"qmgrHost"}) {
+		t.Errorf("Unexpected agent restart attributes %v", changes.agentRestartAttributes)
+	}
+	if !reflect.DeepEqual(changes.containerRestartAttributes, []string{"commandQMgr"}) {
+		t.Errorf("Unexpected container restart attributes %v", changes.containerRestartAttributes)
+	}
+
+	// Formatting alone is not a change
+	monitorDigests := resourceMonitorDigests(oldAgent)
+	if changes := diffConfiguration(reloadTestConfig, reloadTestConfig, oldAgent, strings.Replace(oldAgent, ",", " , ", -1),
+		monitorDigests, monitorDigests); !changes.empty() {
+		t.Errorf("Expected no changes, found %+v", changes)
+	}
+}
+
+// Monitors are created and deleted without restarting the agent
+func TestReloadResourceMonitors(t *testing.T) {
+	reloader := newTestConfigReloader(t, reloadTestConfig)
+	fake := useFakeCommandRunner(t)
+
+	updateTestConfig(t, reloader, strings.Replace(reloadTestConfig, `,"MON2":"/mon/mon2.xml"`, `,"MON3":"/mon/mon3.xml"`, 1))
+	reloader.reload(context.Background(), false)
+
+	if !reflect.DeepEqual(fake.commandNames(), []string{"fteDeleteMonitor", "fteCreateMonitor"}) {
+		t.Fatalf("Unexpected commands %v", fake.commandNames())
+	}
+	if args := strings.Join(fake.commandArgs("fteCreateMonitor"), " "); !strings.Contains(args, "-mn MON3 -ix /mon/mon3.xml") {
+		t.Errorf("Unexpected fteCreateMonitor arguments %s", args)
+	}
+	if args := strings.Join(fake.commandArgs("fteDeleteMonitor"), " "); !strings.Contains(args, "-ma SRC -mn MON2") {
+		t.Errorf("Unexpected fteDeleteMonitor arguments %s", args)
+	}
+
+	// Nothing more is done until the file changes again
+	reloader.reload(context.Background(), false)
+	if len(fake.commandNames()) != 2 {
+		t.Errorf("Unexpected commands %v", fake.commandNames())
+	}
+}
+
+// A monitor whose definition file is edited in place is created again
+func TestReloadEditedResourceMonitor(t *testing.T) {
+	monitorFile := filepath.Join(t.TempDir(), "mon1.xml")
+	if err := os.WriteFile(monitorFile, []byte("<monitor/>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reloader := newTestConfigReloader(t, strings.Replace(reloadTestConfig, "/mon/mon1.xml", monitorFile, 1))
+	fake := useFakeCommandRunner(t)
+
+	reloader.reload(context.Background(), false)
+	if len(fake.commandNames()) != 0 {
+		t.Fatalf("Unexpected commands %v", fake.commandNames())
+	}
+
+	if err := os.WriteFile(monitorFile, []byte("<monitor version=\"2\"/>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reloader.reload(context.Background(), false)
+	if !reflect.DeepEqual(fake.commandNames(), []string{"fteDeleteMonitor", "fteCreateMonitor"}) {
+		t.Fatalf("Unexpected commands %v", fake.commandNames())
+	}
+	if args := strings.Join(fake.commandArgs("fteCreateMonitor"), " "); !strings.Contains(args, "-mn MON1 -ix "+monitorFile) {
+		t.Errorf("Unexpected fteCreateMonitor arguments %s", args)
+	}
+}
+
+// Changes that need a restart of the container are not taken as applied, and
+// are reported again when a reload is requested
+func TestReloadContainerRestartAttributes(t *testing.T) {
+	reloader := newTestConfigReloader(t, reloadTestConfig)
+	fake := useFakeCommandRunner(t)
+	var lock sync.Mutex
+	reported := 0
+	utils.SetPrintLogHook(func(logTime time.Time, msg string) {
+		lock.Lock()
+		defer lock.Unlock()
+		if strings.Contains(msg, "MFTC0097W") {
+			reported++
+		}
+	})
+	defer utils.SetPrintLogHook(nil)
+
+	updateTestConfig(t, reloader, strings.Replace(reloadTestConfig, `"commandQMgr":{"name":"CMDQM"`, `"commandQMgr":{"name":"CMDQM2"`, 1))
+	reloader.reload(context.Background(), false)
+	reloader.reload(context.Background(), false)
+	reloader.reload(context.Background(), true)
+
+	if len(fake.commandNames()) != 0 {
+		t.Errorf("Unexpected commands %v", fake.commandNames())
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if reported != 2 {
+		t.Errorf("Expected the change to be reported twice, found %d", reported)
+	}
+	if allAgentConfig, _ := reloader.currentConfig(); gjson.Get(allAgentConfig, "commandQMgr.name").String() != "CMDQM" {
+		t.Errorf("Change that needs a container restart taken as applied: %s", allAgentConfig)
+	}
+}
+
+// Changed agent properties restart the agent with recreated configuration
+func TestReloadRestartsAgent(t *testing.T) {
+	reloader := newTestConfigReloader(t, reloadTestConfig)
+	useTestTransferTracker(t)
+	configDir := filepath.Join(reloader.bfgDataPath, "mqft/config/COORDQM")
+	fake := useFakeCommandRunner(t)
+	fake.handlers["fteCreateAgent"] = createFileHandler(t, filepath.Join(configDir, "agents/SRC/agent.properties"), "")
+	fake.handlers["ftePingAgent"] = func(args []string) CommandResult {
+		return CommandResult{Stdout: "BFGCL0793I: The agent SRC responded to the ping in 0.1 seconds.\n"}
+	}
+	// The applied configuration can be read while the agent is restarted
+	fake.handlers["fteStopAgent"] = func(args []string) CommandResult {
+		reloader.currentAgentConfig()
+		return CommandResult{}
+	}
+
+	updateTestConfig(t, reloader, strings.Replace(reloadTestConfig, `"enableQueueInputOutput":"true"`, `"enableQueueInputOutput":"false"`, 1))
+	reloader.reload(context.Background(), false)
+
+	expected := []string{"fteStopAgent", "fteCreateAgent", "fteObfuscate", "fteStartAgent", "ftePingAgent"}
+	if !reflect.DeepEqual(fake.commandNames(), expected) {
+		t.Fatalf("Expected commands %v, found %v", expected, fake.commandNames())
+	}
+	if args := fake.commandArgs("fteStopAgent"); reflect.DeepEqual(args[len(args)-1], "-i") {
+		t.Errorf("Agent stopped immediately %v", args)
+	}
+	properties, err := os.ReadFile(filepath.Join(configDir, "agents/SRC/agent.properties"))
+	if err != nil || !strings.Contains(string(properties), "enableQueueInputOutput=false") {
+		t.Errorf("agent.properties not updated: %s %v", properties, err)
+	}
+}
+
+// An invalid configuration is not applied
+func TestReloadInvalidConfiguration(t *testing.T) {
+	reloader := newTestConfigReloader(t, reloadTestConfig)
+	fake := useFakeCommandRunner(t)
+
+	invalidConfig := strings.Replace(reloadTestConfig, `"qmgrName":"AGENTQM",`, `"qmgrNmae":"AGENTQM",`, 1)
+	updateTestConfig(t, reloader, invalidConfig)
+	reloader.reload(context.Background(), false)
+
+	if len(fake.commandNames()) > 0 {
+		t.Errorf("Unexpected commands %v", fake.commandNames())
+	}
+	if reloader.currentAgentConfig() != reloader.agentConfig || strings.Contains(reloader.allAgentConfig, "qmgrNmae") {
+		t.Errorf("Invalid configuration applied")
+	}
+	if reloader.rejectedConfig != invalidConfig {
+		t.Errorf("Invalid configuration not recorded")
+	}
+}
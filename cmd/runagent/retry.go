@@ -0,0 +1,220 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
+	"github.com/tidwall/gjson"
+)
+
+/**
+* Timeouts and retries of setup steps that depend on queue managers being
+* reachable.
+*
+* Each step is retried with exponential backoff if the MFT command fails. Built in
+* defaults can be overridden with environment variables, which in turn can be
+* overridden by the retryPolicy attribute of the configuration file, either for
+* all steps through "default" or for a single step through the name of the step.
+ */
+
+// Names of the setup steps, as used in the retryPolicy attribute
+const STEP_COORDINATION = "coordination"
+const STEP_COMMANDS = "commands"
+const STEP_CREATE_AGENT = "createAgent"
+const STEP_START_AGENT = "startAgent"
+const STEP_PING_AGENT = "pingAgent"
+const STEP_CREATE_MONITOR = "createMonitor"
+const STEP_DEFAULT = "default"
+
+// Built in retry defaults
+const RETRY_DEFAULT_MAX_ATTEMPTS = 3
+const RETRY_DEFAULT_INITIAL_DELAY = 5 * time.Second
+const RETRY_DEFAULT_MAX_DELAY = 60 * time.Second
+const RETRY_DEFAULT_MULTIPLIER = 2.0
+const RETRY_DEFAULT_TIMEOUT = 300 * time.Second
+
+// Timeout and retry settings of a setup step
+type RetryPolicy struct {
+	// Number of times the command is run before giving up, including the first attempt
+	MaxAttempts int
+	// Delay before the first retry
+	InitialDelay time.Duration
+	// Upper limit of the delay between attempts
+	MaxDelay time.Duration
+	// Factor the delay is multiplied by after every attempt
+	Multiplier float64
+	// Time allowed for a single attempt. Zero means no limit.
+	Timeout time.Duration
+}
+
+// Retry policy of each step, loaded from configuration
+var retryPolicies = map[string]RetryPolicy{}
+
+// Waits for the delay before the next attempt. Returns false if the context
+// is cancelled first. Replaced in tests to avoid waiting.
+var retrySleep = func(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// Returns the built in policy with any overrides from environment variables
+func defaultRetryPolicy() RetryPolicy {
+	policy := RetryPolicy{
+		MaxAttempts:  RETRY_DEFAULT_MAX_ATTEMPTS,
+		InitialDelay: RETRY_DEFAULT_INITIAL_DELAY,
+		MaxDelay:     RETRY_DEFAULT_MAX_DELAY,
+		Multiplier:   RETRY_DEFAULT_MULTIPLIER,
+		Timeout:      RETRY_DEFAULT_TIMEOUT,
+	}
+
+	if value, ok := retryEnvValue(MFT_SETUP_RETRY_MAX_ATTEMPTS, 1); ok {
+		policy.MaxAttempts = int(value)
+	}
+	if value, ok := retryEnvValue(MFT_SETUP_RETRY_INITIAL_DELAY, 0); ok {
+		policy.InitialDelay = time.Duration(value * float64(time.Second))
+	}
+	if value, ok := retryEnvValue(MFT_SETUP_RETRY_MAX_DELAY, 0); ok {
+		policy.MaxDelay = time.Duration(value * float64(time.Second))
+	}
+	if value, ok := retryEnvValue(MFT_SETUP_RETRY_MULTIPLIER, 1); ok {
+		policy.Multiplier = value
+	}
+	if value, ok := retryEnvValue(MFT_SETUP_COMMAND_TIMEOUT, 0); ok {
+		policy.Timeout = time.Duration(value * float64(time.Second))
+	}
+	return policy
+}
+
+// Read a numeric environment variable controlling retries, restarts or
+// timeouts. Values below the minimum are ignored.
+func retryEnvValue(name string, minimum float64) (float64, bool) {
+	value, set, valid := lookupEnvNumber(name, minimum)
+	if set && !valid {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_ENV_RETRY_INVALID_0088, os.Getenv(name), name))
+	}
+	return value, set && valid
+}
+
+// Apply the settings of the given JSON object over the policy
+func (p RetryPolicy) merge(settings gjson.Result) RetryPolicy {
+	if !settings.IsObject() {
+		return p
+	}
+	if value := settings.Get("maxAttempts"); value.Exists() && value.Int() > 0 {
+		p.MaxAttempts = int(value.Int())
+	}
+	if value := settings.Get("initialDelay"); value.Exists() {
+		p.InitialDelay = time.Duration(value.Float() * float64(time.Second))
+	}
+	if value := settings.Get("maxDelay"); value.Exists() {
+		p.MaxDelay = time.Duration(value.Float() * float64(time.Second))
+	}
+	if value := settings.Get("multiplier"); value.Exists() && value.Float() >= 1 {
+		p.Multiplier = value.Float()
+	}
+	if value := settings.Get("timeout"); value.Exists() {
+		p.Timeout = time.Duration(value.Float() * float64(time.Second))
+	}
+	return p
+}
+
+// Load retry policies of all steps from environment and configuration file
+func LoadRetryPolicies(allAgentConfig string) {
+	defaultPolicy := defaultRetryPolicy().merge(gjson.Get(allAgentConfig, "retryPolicy."+STEP_DEFAULT))
+	retryPolicies = map[string]RetryPolicy{STEP_DEFAULT: defaultPolicy}
+	for _, step := range []string{STEP_COORDINATION, STEP_COMMANDS, STEP_CREATE_AGENT,
+		STEP_START_AGENT, STEP_PING_AGENT, STEP_CREATE_MONITOR} {
+		retryPolicies[step] = defaultPolicy.merge(gjson.Get(allAgentConfig, "retryPolicy."+step))
+		if logLevel >= LOG_LEVEL_VERBOSE {
+			utils.PrintLog(fmt.Sprintf("Retry policy of step %s: %+v", step, retryPolicies[step]))
+		}
+	}
+}
+
+// Returns the retry policy of a step
+func retryPolicyFor(step string) RetryPolicy {
+	if policy, ok := retryPolicies[step]; ok {
+		return policy
+	}
+	if policy, ok := retryPolicies[STEP_DEFAULT]; ok {
+		return policy
+	}
+	return defaultRetryPolicy()
+}
+
+// Delay before the next attempt, after the given number of failed attempts
+func (p RetryPolicy) delay(failedAttempts int) time.Duration {
+	delay := float64(p.InitialDelay)
+	for i := 1; i < failedAttempts && (p.MaxDelay <= 0 || delay < float64(p.MaxDelay)); i++ {
+		delay *= p.Multiplier
+	}
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	return time.Duration(delay)
+}
+
+// Run an MFT command of a setup step the agent can not run without, retrying as
+// per the policy of the step. Retries end when the context is cancelled, for
+// example when the container is stopped. Otherwise the reason of the final
+// failure is written to the termination log.
+func runMFTStep(ctx context.Context, step string, name string, args ...string) CommandResult {
+	result, attempts := retryMFTStep(ctx, step, name, args...)
+	if result.Err != nil && !result.NotFound() && ctx.Err() == nil {
+		logTerminationf(utils.MFT_CONT_STEP_FAILED_0087, step, attempts, commandFailureReason(result))
+	}
+	return result
+}
+
+// Run an MFT command of a setup step, retrying as per the policy of the step
+// until it succeeds, the attempts run out or the context is cancelled. Returns
+// the result of the last attempt and the number of attempts made.
+func retryMFTStep(ctx context.Context, step string, name string, args ...string) (CommandResult, int) {
+	policy := retryPolicyFor(step)
+	for attempt := 1; ; attempt++ {
+		result := commandRunner.Run(ctx, Command{Name: name, Args: args, Timeout: policy.Timeout, Trace: true})
+		// No point retrying a command that does not exist, or once stopped.
+		if result.Err == nil || result.NotFound() || ctx.Err() != nil || attempt >= policy.MaxAttempts {
+			return result, attempt
+		}
+		delay := policy.delay(attempt)
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_STEP_RETRY_0086, step, attempt, policy.MaxAttempts, commandFailureReason(result), delay))
+		if !retrySleep(ctx, delay) {
+			return result, attempt
+		}
+	}
+}
+
+// Returns the error of a failed command with its standard error output
+func commandFailureReason(result CommandResult) string {
+	reason := result.Err.Error()
+	if stderr := strings.TrimSpace(result.Stderr); len(stderr) > 0 {
+		reason += ": " + stderr
+	}
+	return reason
+}
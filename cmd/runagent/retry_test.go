@@ -0,0 +1,172 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Record retry delays instead of sleeping and capture the termination log
+func useTestRetrySleep(t *testing.T) *[]time.Duration {
+	delays := make([]time.Duration, 0)
+	previousSleep := retrySleep
+	previousPath := terminationLogPath
+	retrySleep = func(ctx context.Context, delay time.Duration) bool {
+		delays = append(delays, delay)
+		return ctx.Err() == nil
+	}
+	terminationLogPath = filepath.Join(t.TempDir(), "termination-log")
+	t.Cleanup(func() {
+		retrySleep = previousSleep
+		terminationLogPath = previousPath
+	})
+	return &delays
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{InitialDelay: time.Second, MaxDelay: 5 * time.Second, Multiplier: 2}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, delay := range expected {
+		if policy.delay(i+1) != delay {
+			t.Errorf("Expected delay %v after %d attempt(s), found %v", delay, i+1, policy.delay(i+1))
+		}
+	}
+}
+
+// Configuration file overrides environment variables, which override built in defaults
+func TestLoadRetryPolicies(t *testing.T) {
+	setTestEnv(t, MFT_SETUP_RETRY_MAX_ATTEMPTS, "4")
+	setTestEnv(t, MFT_SETUP_RETRY_MAX_DELAY, "notanumber")
+	LoadRetryPolicies(`{"retryPolicy":{"default":{"initialDelay":1},"coordination":{"maxAttempts":6,"timeout":"30"}}}`)
+
+	startPolicy := retryPolicyFor(STEP_START_AGENT)
+	if startPolicy.MaxAttempts != 4 || startPolicy.InitialDelay != time.Second || startPolicy.MaxDelay != RETRY_DEFAULT_MAX_DELAY ||
+		startPolicy.Timeout != RETRY_DEFAULT_TIMEOUT {
+		t.Errorf("Unexpected policy for %s: %+v", STEP_START_AGENT, startPolicy)
+	}
+	coordinationPolicy := retryPolicyFor(STEP_COORDINATION)
+	if coordinationPolicy.MaxAttempts != 6 || coordinationPolicy.InitialDelay != time.Second || coordinationPolicy.Timeout != 30*time.Second {
+		t.Errorf("Unexpected policy for %s: %+v", STEP_COORDINATION, coordinationPolicy)
+	}
+	LoadRetryPolicies("{}")
+}
+
+// A failed step is retried with backoff until it succeeds
+func TestRunMFTStepRetries(t *testing.T) {
+	delays := useTestRetrySleep(t)
+	LoadRetryPolicies(`{"retryPolicy":{"default":{"maxAttempts":5,"initialDelay":2,"timeout":10}}}`)
+	defer LoadRetryPolicies("{}")
+
+	attempts := 0
+	fake := useFakeCommandRunner(t)
+	fake.handlers["fteStartAgent"] = func(args []string) CommandResult {
+		attempts++
+		if attempts < 3 {
+			return CommandResult{ExitCode: 1, Stderr: "BFGCL0000E: unreachable", Err: errors.New("exit status 1")}
+		}
+		return CommandResult{}
+	}
+
+	if !StartAgent(context.Background(), "SRC", "COORDQM") {
+		t.Error("Expected agent start to be submitted")
+	}
+	if attempts != 3 || !reflect.DeepEqual(*delays, []time.Duration{2 * time.Second, 4 * time.Second}) {
+		t.Errorf("Expected 3 attempts with backoff, found %d attempts with delays %v", attempts, *delays)
+	}
+	if fake.calls[0].Timeout != 10*time.Second {
+		t.Errorf("Expected timeout of 10s, found %v", fake.calls[0].Timeout)
+	}
+}
+
+// The reason of the final failure is written to the termination log
+func TestRunMFTStepFinalFailure(t *testing.T) {
+	useTestRetrySleep(t)
+	LoadRetryPolicies(`{"retryPolicy":{"coordination":{"maxAttempts":2}}}`)
+	defer LoadRetryPolicies("{}")
+
+	fake := useFakeCommandRunner(t)
+	fake.handlers["fteSetupCoordination"] = func(args []string) CommandResult {
+		return CommandResult{ExitCode: 1, Stderr: "BFGCL0000E: unreachable", Err: errors.New("exit status 1")}
+	}
+
+	result := runMFTStep(context.Background(), STEP_COORDINATION, "fteSetupCoordination", "-coordinationQMgr", "COORDQM")
+	if result.Err == nil || len(fake.calls) != 2 {
+		t.Errorf("Expected 2 failed attempts, found %d %v", len(fake.calls), result.Err)
+	}
+	termination, err := ioutil.ReadFile(terminationLogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(termination), "coordination failed after 2 attempt(s)") ||
+		!strings.Contains(string(termination), "BFGCL0000E: unreachable") {
+		t.Errorf("Unexpected termination message %s", termination)
+	}
+}
+
+// A stopped container ends the retries without writing the termination log
+func TestRunMFTStepCancelled(t *testing.T) {
+	useTestRetrySleep(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fake := useFakeCommandRunner(t)
+	fake.handlers["fteStartAgent"] = func(args []string) CommandResult {
+		cancel()
+		return CommandResult{ExitCode: 1, Stderr: "BFGCL0000E: unreachable", Err: errors.New("exit status 1")}
+	}
+
+	if StartAgent(ctx, "SRC", "COORDQM") || len(fake.calls) != 1 {
+		t.Errorf("Expected a single failed attempt, found %d", len(fake.calls))
+	}
+	if _, err := os.Stat(terminationLogPath); !os.IsNotExist(err) {
+		t.Errorf("Expected no termination log, found %v", err)
+	}
+}
+
+// Resource monitor creation is retried, replacing any monitor created by an
+// earlier attempt, and its final failure does not end the container
+func TestCreateResourceMonitorRetries(t *testing.T) {
+	delays := useTestRetrySleep(t)
+	LoadRetryPolicies(`{"retryPolicy":{"createMonitor":{"maxAttempts":2,"initialDelay":1}}}`)
+	defer LoadRetryPolicies("{}")
+
+	fake := useFakeCommandRunner(t)
+	fake.handlers["fteCreateMonitor"] = func(args []string) CommandResult {
+		return CommandResult{ExitCode: 1, Stderr: "BFGCL0000E: unreachable", Err: errors.New("exit status 1")}
+	}
+
+	if err := createResourceMonitor(context.Background(), "COORDQM", "SRC", "AGENTQM", "MON1", "mon1.xml"); err != nil {
+		t.Error(err)
+	}
+	if len(fake.calls) != 2 || !reflect.DeepEqual(*delays, []time.Duration{time.Second}) {
+		t.Errorf("Expected 2 attempts, found %d with delays %v", len(fake.calls), *delays)
+	}
+	expected := []string{"-p", "COORDQM", "-mm", "AGENTQM", "-ma", "SRC", "-mn", "MON1", "-ix", "mon1.xml", "-f"}
+	if !reflect.DeepEqual(fake.commandArgs("fteCreateMonitor"), expected) {
+		t.Errorf("Expected arguments %v, found %v", expected, fake.commandArgs("fteCreateMonitor"))
+	}
+	if _, err := os.Stat(terminationLogPath); !os.IsNotExist(err) {
+		t.Errorf("Expected no termination log, found %v", err)
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ibm-messaging/mq-container-mft/pkg/logger"
 	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
 	"github.com/tidwall/gjson"
 )
@@ -50,10 +51,6 @@ var agentNameGlobal string
 * displayed on the console when container is being started.
  */
 func main() {
-	var bfgDataPath string
-	var allAgentConfig string
-	var e error
-
 	// By default minimal logging is enabled.
 	logLevel = LOG_LEVEL_INFO
 	// Determine the level of diagnostic information to be logged.
@@ -77,6 +74,11 @@ func main() {
 		utils.PrintLog(utils.MFT_CONT_DIAGNOSTIC_LEVEL_0001)
 	}
 
+	// Validate configuration offline if asked for, without setting up an agent.
+	if len(os.Args) > 1 && os.Args[1] == SUBCOMMAND_VALIDATE {
+		os.Exit(runValidate(os.Args[2:]))
+	}
+
 	// Print container image details
 	printImageInfo()
 
@@ -98,21 +100,39 @@ func main() {
 		os.Exit(MFT_CONT_SUCCESS_CODE_0)
 	}
 
+	os.Exit(runAgent(context.Background()))
+}
+
+/**
+* Configures and starts the agent, then waits till the container is stopped
+* or the supplied context is cancelled. Returns the exit code of the process.
+ */
+func runAgent(ctx context.Context) int {
+	var bfgDataPath string
+	var allAgentConfig string
+	var e error
+	var err error
+
 	// Determine we have the agent name specified in environment variable
 	agentNameEnv, agentNameSet := os.LookupEnv(MFT_AGENT_NAME)
 	if !agentNameSet {
 		utils.PrintLog(utils.MFT_CONT_ENV_AGENT_NAME_NOT_SPECIFIED_0006)
-		os.Exit(MFT_CONT_ERR_CODE_3)
+		return MFT_CONT_ERR_CODE_3
 	}
 	agentNameEnv = strings.Trim(agentNameEnv, TEXT_TRIM)
 	utils.PrintLog(fmt.Sprintf(utils.MFT_AGENT_NAME_CONFIGURE, agentNameEnv))
 	if len(agentNameEnv) == 0 {
 		utils.PrintLog(utils.MFT_CONT_ENV_AGENT_NAME_BLANK_0007)
-		os.Exit(MFT_CONT_ERR_CODE_4)
+		return MFT_CONT_ERR_CODE_4
 	}
 	// Copy the name of agent
 	agentNameGlobal = agentNameEnv
 
+	// Serve the health endpoints while the agent is set up and runs
+	agentHealth = newHealthState(agentNameEnv)
+	healthServer := startHealthServer(agentHealth)
+	defer stopHTTPServer(healthServer)
+
 	// Time to wait for agent to start. Default wait time is 10 seconds
 	delayTimeStatusCheck := time.Duration(10) * time.Second
 	timeWaitForAgentStartStr, timeWaitForAgentStartSet := os.LookupEnv(MFT_AGENT_START_WAIT_TIME)
@@ -154,7 +174,7 @@ func main() {
 			// Exit the creation if an error occurs
 			if err != nil {
 				utils.PrintLog(fmt.Sprintf("%v", err))
-				os.Exit(MFT_CONT_ERR_CODE_5)
+				return MFT_CONT_ERR_CODE_5
 			}
 		} else {
 			// Blank value was specified, hence use default
@@ -162,7 +182,7 @@ func main() {
 			bfgDataPath = utils.FIXED_BFG_DATAPATH
 			err = utils.CreatePath(bfgDataPath)
 			if err != nil {
-				os.Exit(MFT_CONT_ERR_CODE_6)
+				return MFT_CONT_ERR_CODE_6
 			}
 
 			// Set BFG_DATA environment variable so that we can run MFT commands.
@@ -173,7 +193,7 @@ func main() {
 		bfgDataPath = utils.FIXED_BFG_DATAPATH
 		err = utils.CreatePath(bfgDataPath)
 		if err != nil {
-			os.Exit(MFT_CONT_ERR_CODE_7)
+			return MFT_CONT_ERR_CODE_7
 		}
 
 		// Set BFG_DATA environment variable so that we can run MFT commands.
@@ -186,12 +206,12 @@ func main() {
 	bfgConfigFilePath, configFileSet := os.LookupEnv(MFT_AGENT_CONFIG_FILE)
 	if !configFileSet {
 		utils.PrintLog(utils.MFT_CONT_ENV_AGNT_CFG_FILE_NOT_SPECIFIED_0011)
-		os.Exit(MFT_CONT_ERR_CODE_8)
+		return MFT_CONT_ERR_CODE_8
 	}
 	bfgConfigFilePath = strings.Trim(bfgConfigFilePath, TEXT_TRIM)
 	if bfgConfigFilePath == TEXT_BLANK {
 		utils.PrintLog(utils.MFT_CONT_ENV_AGNT_CFG_FILE_BLANK_0012)
-		os.Exit(MFT_CONT_ERR_CODE_9)
+		return MFT_CONT_ERR_CODE_9
 	}
 
 	// Copy the JSON configuration file path
@@ -203,15 +223,41 @@ func main() {
 	if e != nil {
 		// Exit if we had any error when reading configuration file
 		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CFG_FILE_READ_0013, bfgConfigFilePath, e))
-		os.Exit(MFT_CONT_ERR_CODE_10)
+		return MFT_CONT_ERR_CODE_10
 	}
 
+	// Resolve references to environment variables and files, like secrets, so
+	// that the rest of setup sees the actual values. The data as read from the
+	// file is kept for logging so that secrets are not displayed.
+	configFileData := allAgentConfig
+	allAgentConfig, e = utils.ResolveConfigurationReferences(configFileData)
+	if e != nil {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CFG_REFERENCE_ERROR_0089, bfgConfigFilePath, e))
+		return MFT_CONT_ERR_CODE_25
+	}
+
+	// Validate the entire configuration file against the schema and report all
+	// problems before any MFT command is run.
+	schemaViolations := ValidateConfigurationSchema(allAgentConfig)
+	if len(schemaViolations) > 0 {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CFG_SCHEMA_INVALID_0079, bfgConfigFilePath, AGENT_CONFIG_SCHEMA_VERSION, len(schemaViolations)))
+		for _, violation := range schemaViolations {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CFG_SCHEMA_VIOLATION_0080, violation))
+		}
+		return MFT_CONT_ERR_CODE_25
+	} else if logLevel >= LOG_LEVEL_VERBOSE {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CFG_SCHEMA_VALID_0081, bfgConfigFilePath, AGENT_CONFIG_SCHEMA_VERSION))
+	}
+
+	// Timeouts and retries of setup steps
+	LoadRetryPolicies(allAgentConfig)
+
 	// Validate coordination queue manager attributes. Throw an error if minimum attributes
 	// are not available
 	errorCrd := ValidateCoordinationAttributes(allAgentConfig)
 	if errorCrd != nil {
 		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CFG_MISSING_ATTRIBS_0016, bfgConfigFilePath, errorCrd))
-		os.Exit(MFT_CONT_ERR_CODE_11)
+		return MFT_CONT_ERR_CODE_11
 	}
 
 	// Validate command queue manager attributes. Throw an error if minimum attributes are
@@ -219,10 +265,10 @@ func main() {
 	errorCmd := ValidateCommandAttributes(allAgentConfig)
 	if errorCmd != nil {
 		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CFG_MISSING_ATTRIBS_0016, bfgConfigFilePath, errorCmd))
-		os.Exit(MFT_CONT_ERR_CODE_12)
+		return MFT_CONT_ERR_CODE_12
 	}
 	if logLevel >= LOG_LEVEL_VERBOSE {
-		utils.PrintLog(fmt.Sprintf("All configurations in %s file: %v", bfgConfigFilePath, allAgentConfig))
+		utils.PrintLog(fmt.Sprintf("All configurations in %s file: %v", bfgConfigFilePath, configFileData))
 	}
 
 	// We may have multiple agent configurations defined in the JSON file. Iterate through all
@@ -233,7 +279,7 @@ func main() {
 	// Return an error if no agent configuration is supplied
 	if len(agentsJson) == 0 {
 		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_NO_AGENT_CONFIG_SUPPLIED, bfgConfigFilePath))
-		os.Exit(MFT_CONT_ERR_CODE_23)
+		return MFT_CONT_ERR_CODE_23
 	}
 
 	// Loop through the supplied JSON and identify configuration for agent name supplied
@@ -241,7 +287,7 @@ func main() {
 	for i := 0; i < len(agentsJson); i++ {
 		singleAgentConfig = agentsJson[i].String()
 		if logLevel >= LOG_LEVEL_VERBOSE {
-			utils.PrintLog(fmt.Sprintf(utils.MFT_AGENT_JSON_CONFIG, singleAgentConfig))
+			utils.PrintLog(fmt.Sprintf(utils.MFT_AGENT_JSON_CONFIG, gjson.Get(configFileData, fmt.Sprintf("agents.%d", i)).String()))
 		}
 		if gjson.Get(singleAgentConfig, "name").Exists() {
 			agentNameConfig := gjson.Get(singleAgentConfig, "name").String()
@@ -259,12 +305,12 @@ func main() {
 	// Exit if we did not find the configuration for specified agent
 	if !configurationFound {
 		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CFG_AGENT_CONFIG_MISSING_0019, agentNameEnv, bfgConfigFilePath))
-		os.Exit(MFT_CONT_ERR_CODE_13)
+		return MFT_CONT_ERR_CODE_13
 	} else {
 		err := ValidateAgentAttributes(singleAgentConfig)
 		if err != nil {
 			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CFG_AGENT_CONFIG_ERROR_0023, bfgConfigFilePath, err))
-			os.Exit(MFT_CONT_ERR_CODE_14)
+			return MFT_CONT_ERR_CODE_14
 		}
 	}
 
@@ -272,34 +318,38 @@ func main() {
 	coordinationQMgr := gjson.Get(allAgentConfig, "coordinationQMgr.name").String()
 
 	// Setup coordination configuration
-	coordinationCreated := SetupCoordination(allAgentConfig, bfgDataPath, agentNameEnv)
+	coordinationCreated := SetupCoordination(ctx, allAgentConfig, bfgDataPath, agentNameEnv)
 	if !coordinationCreated {
 		utils.PrintLog(utils.MFT_CONT_CORD_CFG_FAILED_0029)
-		os.Exit(MFT_CONT_ERR_CODE_15)
+		return MFT_CONT_ERR_CODE_15
 	}
 
 	// Setup command configuration
-	commandsCreated := SetupCommands(allAgentConfig, bfgDataPath, agentNameEnv)
+	commandsCreated := SetupCommands(ctx, allAgentConfig, bfgDataPath, agentNameEnv)
 	if !commandsCreated {
 		utils.PrintLog(utils.MFT_CONT_CMD_CFG_FAILED_0030)
-		os.Exit(MFT_CONT_ERR_CODE_16)
+		return MFT_CONT_ERR_CODE_16
 	}
 
 	// Create the specified agent configuration
-	setupAgentDone := SetupAgent(singleAgentConfig, bfgDataPath, coordinationQMgr)
+	setupAgentDone := SetupAgent(ctx, singleAgentConfig, bfgDataPath, coordinationQMgr)
 	if !setupAgentDone {
 		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_CFG_FAILED_0031, agentNameEnv))
-		os.Exit(MFT_CONT_ERR_CODE_17)
+		return MFT_CONT_ERR_CODE_17
 	}
 
 	// Clean agent if asked for before starting the agent
 	cleanAgent(singleAgentConfig, coordinationQMgr, agentNameEnv)
 
+	// Track transfers of the agent, so that they can complete when the agent is stopped.
+	agentTransfers = newTransferTracker(bfgDataPath + DIR_AGENT_LOGS + coordinationQMgr + DIR_AGENTS + agentNameEnv)
+	agentHealth.setAgentPath(bfgDataPath + DIR_AGENT_LOGS + coordinationQMgr + DIR_AGENTS + agentNameEnv)
+
 	// Submit request to start the agent.
-	startAgentDone := StartAgent(agentNameEnv, coordinationQMgr)
+	startAgentDone := StartAgent(ctx, agentNameEnv, coordinationQMgr)
 	if !startAgentDone {
 		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_START_FAILED_0032, agentNameEnv))
-		os.Exit(MFT_CONT_ERR_CODE_18)
+		return MFT_CONT_ERR_CODE_18
 	}
 
 	// Setup agent log mirroring.
@@ -309,12 +359,24 @@ func main() {
 		wg.Wait()
 	}()
 
-	ctxAgentLog, cancelMirrorAgentLog := context.WithCancel(context.Background())
+	ctxAgentLog, cancelMirrorAgentLog := context.WithCancel(ctx)
 	defer func() {
 		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_WAIT_MIRROR_STOP_0036, agentNameEnv))
 		cancelMirrorAgentLog()
 	}()
 
+	// Monitor the expiry of the certificates of the queue managers
+	agentCertificates = newCertificateMonitor(tlsCertificateDirectories(allAgentConfig, singleAgentConfig))
+
+	// Collect and serve metrics of the agent
+	metricsServer := startMetrics(ctxAgentLog, &wg, agentNameEnv,
+		bfgDataPath+DIR_AGENT_LOGS+coordinationQMgr+DIR_AGENTS+agentNameEnv)
+	defer stopHTTPServer(metricsServer)
+
+	// Export transfers and diagnostics to an OpenTelemetry collector
+	startTelemetry(ctxAgentLog, &wg, agentNameEnv, coordinationQMgr,
+		bfgDataPath+DIR_AGENT_LOGS+coordinationQMgr+DIR_AGENTS+agentNameEnv)
+
 	// Display the contents of agent's output0.log file on the console.
 	if logLevel >= LOG_LEVEL_VERBOSE {
 		agentLogPath := bfgDataPath + DIR_AGENT_LOGS + coordinationQMgr + DIR_AGENTS + agentNameEnv + "/logs/output0.log"
@@ -323,18 +385,18 @@ func main() {
 
 	// Verify that agent is ready to accept to requests
 	pingWaitTime := strconv.Itoa((int)(delayTimeStatusCheck / time.Second))
-	agentReady := PingAgent(coordinationQMgr, agentNameEnv, pingWaitTime)
+	agentReady := PingAgent(ctx, coordinationQMgr, agentNameEnv, pingWaitTime)
 	if !agentReady {
 		//if agent not started yet, wait for some time and then reissue fteListAgents commad
 		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_NOT_STARTED_0033, agentNameEnv, delayTimeStatusCheck/time.Second))
 		time.Sleep(delayTimeStatusCheck)
-		agentReady = PingAgent(coordinationQMgr, agentNameEnv, pingWaitTime)
+		agentReady = PingAgent(ctx, coordinationQMgr, agentNameEnv, pingWaitTime)
 		// Agent has not started, exit.
 		if !agentReady {
 			if logLevel >= LOG_LEVEL_INFO {
 				utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_FAILED_TO_START_0034, agentNameEnv))
 			}
-			os.Exit(MFT_CONT_ERR_CODE_21)
+			return MFT_CONT_ERR_CODE_21
 		}
 	}
 
@@ -347,8 +409,9 @@ func main() {
 		}
 		// There was an error or agent is not ready, then exit
 		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_NOT_READY, agentNameEnv))
-		os.Exit(MFT_CONT_ERR_CODE_21)
+		return MFT_CONT_ERR_CODE_21
 	}
+	agentHealth.setStarted(isReady)
 
 	// Mirror contents of capture log on the console
 	setupMirrorCaptureLogs(ctxAgentLog, &wg, bfgDataPath, coordinationQMgr, agentNameEnv)
@@ -366,7 +429,7 @@ func main() {
 		if gjson.Get(singleAgentConfig, "resourceMonitors").Exists() {
 			result := gjson.Get(singleAgentConfig, "resourceMonitors")
 			result.ForEach(func(key, value gjson.Result) bool {
-				createResourceMonitor(coordinationQMgr, agentNameEnv,
+				createResourceMonitor(ctxAgentLog, coordinationQMgr, agentNameEnv,
 					gjson.Get(singleAgentConfig, "qmgrName").String(),
 					key.String(),
 					value.String())
@@ -374,13 +437,29 @@ func main() {
 			})
 		}
 
-		// Setup a siganl handle and wait for till container is stopped.
+		// Setup a siganl handle and wait for till container is stopped or the
+		// agent ends and can not be restarted.
 		signalControl := signalHandler(agentNameEnv, coordinationQMgr)
-		<-signalControl
+		agentEnded := superviseAgent(ctxAgentLog, bfgDataPath, coordinationQMgr, agentNameEnv, pingWaitTime)
+
+		// Apply changes to the configuration file while the agent runs
+		reloader := newConfigReloader(bfgConfigFilePath, bfgDataPath, coordinationQMgr, agentNameEnv,
+			pingWaitTime, allAgentConfig, singleAgentConfig)
+		reloader.watch(ctxAgentLog)
+		// Create keystores again when the certificates of a queue manager change
+		agentCertificates.watch(ctxAgentLog, reloader)
+		exitCode := MFT_CONT_SUCCESS_CODE_0
+		select {
+		case <-signalControl:
+		case <-ctx.Done():
+			// Stopped by the caller rather than a signal.
+			stopAgent(agentNameEnv, coordinationQMgr)
+		case exitCode = <-agentEnded:
+		}
 		cancelMirrorAgentLog()
 
 		// Delete agent configuration on exit
-		deleteAgentOnExit := gjson.Get(singleAgentConfig, "deleteOnTermination").Bool()
+		deleteAgentOnExit := gjson.Get(reloader.currentAgentConfig(), "deleteOnTermination").Bool()
 		// Delete agent configuration if asked for
 		if deleteAgentOnExit {
 			deleteAgent(coordinationQMgr, agentNameEnv)
@@ -388,11 +467,11 @@ func main() {
 			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_CFG_DELETED_0039, agentNameEnv))
 		}
 
-		// Agent has ended. Return success
-		os.Exit(MFT_CONT_SUCCESS_CODE_0)
+		// Agent has ended. Return success unless the agent ended unexpectedly
+		return exitCode
 	} else {
 		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_START_FAILED_0040, agentNameEnv))
-		os.Exit(MFT_CONT_ERR_CODE_22)
+		return MFT_CONT_ERR_CODE_22
 	}
 }
 
@@ -454,25 +533,10 @@ func setupMirrorTransferLogs(ctxTransferLog context.Context, wg *sync.WaitGroup,
 					utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CFG_FILE_READ_0013, agentTransferLogEnv, e))
 					return
 				}
-				if gjson.Get(serverLogData, KEY_TYPE).Exists() {
-					logType := gjson.Get(serverLogData, KEY_TYPE).String()
-					if strings.EqualFold(strings.Trim(logType, TEXT_BLANK), LOG_SERVER_TYPE_DNA) {
-						if gjson.Get(serverLogData, KEY_URL_DNA).Exists() &&
-							gjson.Get(serverLogData, KEY_INJESTION_DNA).Exists() {
-							logDNAUrl := gjson.Get(serverLogData, KEY_URL_DNA).String()
-							logDNAKey := gjson.Get(serverLogData, KEY_INJESTION_DNA).String()
-							transferLogPath := bfgDataPath + DIR_AGENT_LOGS + coordinationQMgr + DIR_AGENTS + agentNameEnv + "/logs/transferlog0.json"
-							mirrorAgentLogs(ctxTransferLog, wg, "IBMMQMFT Agent "+agentNameEnv, transferLogPath, logDNAUrl, logDNAKey, LOG_TYPE_TRANSFER,
-								LOG_SERVER_TYPE_DNA_NUM)
-						}
-					} else if strings.EqualFold(strings.Trim(logType, TEXT_BLANK), LOG_SERVER_TYPE_ELK) {
-						if gjson.Get(serverLogData, KEY_URL_ELK).Exists() {
-							logUrlElk := gjson.Get(serverLogData, KEY_URL_ELK).String()
-							transferLogPath := bfgDataPath + DIR_AGENT_LOGS + coordinationQMgr + DIR_AGENTS + agentNameEnv + "/logs/transferlog0.json"
-							mirrorAgentLogs(ctxTransferLog, wg, agentNameEnv, transferLogPath, logUrlElk, "", LOG_TYPE_TRANSFER,
-								LOG_SERVER_TYPE_ELK_NUM)
-						}
-					}
+				destinations := transferLogDestinations(serverLogData, bfgDataPath, coordinationQMgr, agentNameEnv)
+				if len(destinations) > 0 {
+					transferLogPath := bfgDataPath + DIR_AGENT_LOGS + coordinationQMgr + DIR_AGENTS + agentNameEnv + "/logs/transferlog0.json"
+					mirrorTransferLogs(ctxTransferLog, wg, transferLogPath, destinations)
 				}
 			}
 		} else {
@@ -498,6 +562,127 @@ func mirrorAgentLogs(ctx context.Context, wg *sync.WaitGroup, agentName string,
 	return nil
 }
 
+// Publish the entries of the transfer log to each destination. The log is
+// read once, and each entry is pushed to the destinations whose filter selects
+// it and that have not published it before a restart.
+func mirrorTransferLogs(ctx context.Context, wg *sync.WaitGroup, logPathName string,
+	destinations []transferLogDestination) error {
+	publishers := make([]*logger.Logger, len(destinations))
+	checkpoints := make([]*logger.LogPosition, len(destinations))
+	var diagnostics *logger.Logger
+	for i, destination := range destinations {
+		_, err := configureLogger(destination.loggerName, destination.url, destination.key, LOG_TYPE_TRANSFER,
+			destination.serverType)
+		if err != nil {
+			logTermination(err)
+			for _, publisher := range publishers[:i] {
+				publisher.StopPublisher(TLOG_PUBLISH_STOP_TIMEOUT)
+			}
+			return err
+		}
+		publishers[i] = eventLog
+		publishers[i].SetServerOptions(destination.serverOptions)
+		publishers[i].StartPublisher(destination.options)
+		if destination.serverOptions.Syslog.Diagnostics && diagnostics == nil {
+			diagnostics = publishers[i]
+		}
+		if len(destination.options.SpoolDir) > 0 {
+			if position, found := logger.ReadCheckpoint(destination.options.SpoolDir); found {
+				checkpoints[i] = &position
+			}
+		}
+	}
+	// Messages logged by the container are sent to the first syslog server
+	// asking for them.
+	if diagnostics != nil {
+		eventLog = diagnostics
+	}
+
+	start := earliestCheckpoint(checkpoints)
+	if start != nil {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLOG_RESUMING_0115, logPathName, start.Offset))
+	}
+	var mirrorWg sync.WaitGroup
+	tailLogFrom(ctx, &mirrorWg, logPathName, start, func(msg string, position logger.LogPosition) {
+		for i, publisher := range publishers {
+			if publishedBefore(position, checkpoints[i]) || !destinations[i].filter.matches(msg) {
+				continue
+			}
+			publisher.PushTransferLogEntry(msg, position)
+		}
+	})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		mirrorWg.Wait()
+		for _, publisher := range publishers {
+			publisher.StopPublisher(TLOG_PUBLISH_STOP_TIMEOUT)
+		}
+	}()
+	return nil
+}
+
+// Returns the syslog options in the transfer log publish configuration. Returns
+// false if the protocol is not valid.
+func syslogServerOptions(serverLogData string) (logger.ServerOptions, bool) {
+	options := logger.SyslogOptions{
+		Network:     logger.SYSLOG_NETWORK_TCP,
+		Facility:    logger.SYSLOG_DEFAULT_FACILITY,
+		AppName:     gjson.Get(serverLogData, KEY_APP_NAME_SYSLOG).String(),
+		CACertFile:  gjson.Get(serverLogData, KEY_CA_CERT_SYSLOG).String(),
+		Diagnostics: gjson.Get(serverLogData, KEY_DIAGNOSTICS_SYSLOG).Bool(),
+	}
+	if protocol := gjson.Get(serverLogData, KEY_PROTOCOL_SYSLOG); protocol.Exists() {
+		options.Network = strings.ToLower(strings.Trim(protocol.String(), TEXT_TRIM))
+		if options.Network != logger.SYSLOG_NETWORK_UDP && options.Network != logger.SYSLOG_NETWORK_TCP &&
+			options.Network != logger.SYSLOG_NETWORK_TLS {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLOG_SYSLOG_PROTOCOL_0117, protocol.String()))
+			return logger.ServerOptions{}, false
+		}
+	}
+	if facility := gjson.Get(serverLogData, KEY_FACILITY_SYSLOG); facility.Exists() {
+		var err error
+		if options.Facility, err = logger.ParseSyslogFacility(facility.String()); err != nil {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLOG_SYSLOG_FACILITY_0116, facility.String()))
+		}
+	}
+	return logger.ServerOptions{Syslog: options}, true
+}
+
+// Returns the publishing options in the transfer log publish configuration,
+// using defaults for any not specified
+func transferLogPublisherOptions(serverLogData string, bfgDataPath string, agentName string) logger.PublisherOptions {
+	options := logger.DefaultPublisherOptions()
+	if result := gjson.Get(serverLogData, KEY_PUBLISHER+".spool"); !result.Exists() || result.Bool() {
+		options.SpoolDir = bfgDataPath + DIR_PUBLISH_SPOOL + agentName
+	}
+	if result := gjson.Get(serverLogData, KEY_PUBLISHER+".maxSpoolSize"); result.Exists() && result.Int() > 0 {
+		// Given in megabytes
+		options.MaxSpoolSize = result.Int() * 1024 * 1024
+	}
+	seconds := func(key string, value *time.Duration) {
+		if result := gjson.Get(serverLogData, KEY_PUBLISHER+"."+key); result.Exists() && result.Float() > 0 {
+			*value = time.Duration(result.Float() * float64(time.Second))
+		}
+	}
+	count := func(key string, value *int) {
+		if result := gjson.Get(serverLogData, KEY_PUBLISHER+"."+key); result.Exists() && result.Int() >= 0 {
+			*value = int(result.Int())
+		}
+	}
+	count("queueSize", &options.QueueSize)
+	count("batchSize", &options.BatchSize)
+	count("maxRetries", &options.MaxRetries)
+	seconds("flushInterval", &options.FlushInterval)
+	seconds("retryDelay", &options.RetryDelay)
+	seconds("maxRetryDelay", &options.MaxRetryDelay)
+	seconds("requestTimeout", &options.RequestTimeout)
+	if options.BatchSize < 1 {
+		options.BatchSize = 1
+	}
+	return options
+}
+
 // Display details of image and user
 func printImageInfo() {
 	// Print CPU architecture
@@ -16,13 +16,18 @@ limitations under the License.
 package main
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
+	"github.com/tidwall/gjson"
 )
 
 /*
@@ -88,10 +93,10 @@ func TestReadConfigurationDataFromFile(t *testing.T) {
 }
 
 // Test updating of agent properties file
-func TestupdateAgentProperties(t *testing.T) {
+func TestUpdateAgentPropertiesFromConfig(t *testing.T) {
 	configDataValid := "{\"dataPath\":\"/mqmft/mftdata\",\"monitoringInterval\":300,\"displayAgentLogs\":true,\"displayLineCount\":50,\"waitTimeToStart\":10,\"coordinationQMgr\":{\"name\":\"QUICKSTART\",\"host\":\"10.254.0.4\",\"port\":1414,\"channel\":\"MFT_HA_CHN\"},\"commandsQMgr\":{\"name\":\"QUICKSTART\",\"host\":\"10.254.0.4\",\"port\":1414,\"channel\":\"MFT_HA_CHN\"},\"agent\":{\"name\":\"KXAGNT\",\"type\":\"STANDARD\",\"qmgrName\":\"QUICKSTART\",\"qmgrHost\":\"10.254.0.4\",\"qmgrPort\":1414,\"qmgrChannel\":\"MFT_HA_CHN\",\"credentialsFile\":\"/usr/local/bin/MQMFTCredentials.xml\",\"protocolBridge\":{\"credentialsFile\":\"/usr/local/bin/ProtocolBridgeCredentials.xml\",\"serverType\":\"SFTP\",\"serverHost\":\"9.199.144.110\",\"serverTimezone\":\"\",\"serverPlatform\":\"UNIX\",\"serverLocale\":\"en-US\",\"serverFileEncoding\":\"UTF-8\",\"serverPort\":22,\"serverTrustStoreFile\":\"\",\"serverLimitedWrite\":\"\",\"serverListFormat\":\"\",\"serverUserId\":\"root\",\"serverPassword\":\"Kitt@n0or\"},\"additionalProperties\":{\"enableQueueInputOutput\":\"true\"}}"
 	initialProps := "agentQMgr=MFTQM\nagentQMgrPort=1414\nagentDesc=\nagentQMgrHost=localhost\nagentQMgrChannel=MFT_CHN\nagentName=SRC\ntrace=com.ibm.wmqfte=all"
-	compareTemplate := "agentQMgr=MFTQM\nagentQMgrPort=1414\nagentDesc=\nagentQMgrHost=localhost\nagentQMgrChannel=MFT_CHN\nagentName=SRC\ntrace=com.ibm.wmqfte=all\nenableQueueInputOutput=true"
+	compareTemplate := "agentQMgr=MFTQM\nagentQMgrPort=1414\nagentDesc=\nagentQMgrHost=localhost\nagentQMgrChannel=MFT_CHN\nagentName=SRC\ntrace=com.ibm.wmqfte=all\nlogCapture=true\nmaxRestartCount=0\nenableQueueInputOutput=true\nuserSandboxes=true"
 
 	agentProps, err := ioutil.TempFile("", t.Name())
 	if err != nil {
@@ -108,7 +113,7 @@ func TestupdateAgentProperties(t *testing.T) {
 	agentPropsF.Close()
 
 	// Update the agent.properties file with data from configuration file
-	UpdateAgentProperties(agentProps.Name(), configDataValid, "additionalProperties", false)
+	UpdateAgentProperties(agentProps.Name(), configDataValid, "agent.additionalProperties", false)
 
 	content, err := ioutil.ReadFile(agentProps.Name())
 	if err != nil {
@@ -125,3 +130,165 @@ func TestupdateAgentProperties(t *testing.T) {
 		t.Fatal("Properties file not updated correctly")
 	}
 }
+
+// Test reading configuration from a multi-document YAML file
+func TestReadConfigurationDataFromYAML(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "agentconfig.yaml")
+	yamlData := `coordinationQMgr:
+  name: QUICKSTART
+  host: 10.254.0.4
+  port: 1414
+commandQMgr:
+  name: QUICKSTART
+  host: 10.254.0.4
+agents:
+  - name: SRC
+    qmgrName: QUICKSTART
+    qmgrHost: 10.254.0.4
+---
+agents:
+  - name: SRC
+    qmgrPort: 1415
+    additionalProperties:
+      enableQueueInputOutput: "true"
+`
+	if err := ioutil.WriteFile(configFile, []byte(yamlData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	configData, err := utils.ReadConfigurationDataFromFile(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !gjson.Valid(configData) {
+		t.Fatalf("Expected JSON, found %s", configData)
+	}
+	if gjson.Get(configData, "coordinationQMgr.port").Int() != 1414 ||
+		gjson.Get(configData, "agents.#").Int() != 1 ||
+		gjson.Get(configData, "agents.0.qmgrHost").String() != "10.254.0.4" ||
+		gjson.Get(configData, "agents.0.qmgrPort").Int() != 1415 ||
+		gjson.Get(configData, "agents.0.additionalProperties.enableQueueInputOutput").String() != "true" {
+		t.Errorf("Documents not merged as expected: %s", configData)
+	}
+	if violations := ValidateConfigurationSchema(configData); len(violations) > 0 {
+		t.Errorf("Unexpected schema violations %v", violations)
+	}
+}
+
+// Test merging of a directory of JSON and YAML fragments
+func TestReadConfigurationDataFromDirectory(t *testing.T) {
+	configDir := t.TempDir()
+	fragments := map[string]string{
+		"00-coordination.json": `{"coordinationQMgr":{"name":"QM1","host":"qm1.example.com"},"commandQMgr":{"name":"QM1","host":"qm1.example.com"},
+			"agents":[{"name":"SRC","qmgrName":"QM1","qmgrHost":"localhost"}]}`,
+		"10-src.yaml": "agents:\n  - name: SRC\n    qmgrHost: qm1.example.com\n",
+		"20-dest.yml": "agents:\n  - name: DEST\n    qmgrName: QM1\n    qmgrHost: qm1.example.com\n",
+		"README.txt":  "Not a configuration file",
+	}
+	for fileName, data := range fragments {
+		if err := ioutil.WriteFile(filepath.Join(configDir, fileName), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	configData, err := utils.ReadConfigurationDataFromFile(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gjson.Get(configData, "agents.#").Int() != 2 ||
+		gjson.Get(configData, "agents.0.qmgrHost").String() != "qm1.example.com" ||
+		gjson.Get(configData, "agents.1.name").String() != "DEST" {
+		t.Errorf("Fragments not merged as expected: %s", configData)
+	}
+
+	// Errors identify the fragment in error
+	if err := ioutil.WriteFile(filepath.Join(configDir, "30-broken.yaml"), []byte("agents: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := utils.ReadConfigurationDataFromFile(configDir); err == nil || !strings.Contains(err.Error(), "30-broken.yaml") {
+		t.Errorf("Expected error for 30-broken.yaml, found %v", err)
+	}
+}
+
+// Test resolution of environment variable and file references
+func TestResolveConfigurationReferences(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "mqPassword")
+	if err := ioutil.WriteFile(secretFile, []byte("cGFzc3cwcmQ=\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	setTestEnv(t, "TEST_MFT_COORD_HOST", "qm1.example.com")
+	configData := fmt.Sprintf(`{"coordinationQMgr":{"name":"QM1","host":"${env:TEST_MFT_COORD_HOST}","port":1414,
+		"qmgrCredentials":{"mqUserId":"mftuser","mqPassword":"${file:%s}"}},
+		"agents":[{"name":"SRC","additionalProperties":{"trace":"$${literal}","url":"https://${env:TEST_MFT_COORD_HOST}/a?b&c"}}]}`, secretFile)
+
+	resolved, err := utils.ResolveConfigurationReferences(configData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"coordinationQMgr.host":                       "qm1.example.com",
+		"coordinationQMgr.port":                       "1414",
+		"coordinationQMgr.qmgrCredentials.mqPassword": "cGFzc3cwcmQ=",
+		"agents.0.additionalProperties.trace":         "${literal}",
+		"agents.0.additionalProperties.url":           "https://qm1.example.com/a?b&c",
+	}
+	for path, value := range expected {
+		if actual := gjson.Get(resolved, path).Raw; strings.Trim(actual, `"`) != value {
+			t.Errorf("Expected %s to be %s, found %s", path, value, actual)
+		}
+	}
+
+	// Data without references is returned as is
+	if resolved, _ := utils.ResolveConfigurationReferences(`{"a": 1}`); resolved != `{"a": 1}` {
+		t.Errorf("Data without references changed: %s", resolved)
+	}
+
+	// All unresolved references are reported with their path
+	_, err = utils.ResolveConfigurationReferences(`{"agents":[{"name":"${env:TEST_MFT_NOT_SET}","qmgrHost":"${file:/no/such/file}"}]}`)
+	var unresolved utils.UnresolvedReferencesError
+	if !errors.As(err, &unresolved) || len(unresolved) != 2 {
+		t.Fatalf("Expected two unresolved references, found %v", err)
+	}
+	if !strings.Contains(err.Error(), "$.agents[0].name: environment variable TEST_MFT_NOT_SET is not set") ||
+		!strings.Contains(err.Error(), "$.agents[0].qmgrHost: ") {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+// Values resolved from references are not displayed by the verbose log of the
+// configuration
+func TestResolvedReferencesNotLogged(t *testing.T) {
+	secret := `s3cr"et\pa55`
+	setTestEnv(t, "TEST_MFT_QMGR_PASSWORD", secret)
+	configFile := writeValidateTestConfig(t, strings.Replace(validateTestConfig,
+		`"mqPassword":"cGFzc3cwcmQ="`, `"mqPassword":"${env:TEST_MFT_QMGR_PASSWORD}"`, -1))
+
+	var lock sync.Mutex
+	var logged []string
+	utils.SetPrintLogHook(func(logTime time.Time, msg string) {
+		lock.Lock()
+		defer lock.Unlock()
+		logged = append(logged, msg)
+	})
+	defer utils.SetPrintLogHook(nil)
+	previousLogLevel := logLevel
+	logLevel = LOG_LEVEL_VERBOSE
+	defer func() { logLevel = previousLogLevel }()
+
+	ValidateAndRender(configFile, "SRC", t.TempDir(), "/mnt/mftdata")
+
+	lock.Lock()
+	defer lock.Unlock()
+	configurationLogged := false
+	for _, msg := range logged {
+		if strings.Contains(msg, secret) || strings.Contains(msg, `s3cr\"et\\pa55`) {
+			t.Errorf("Secret logged: %s", msg)
+		}
+		if strings.Contains(msg, `"mqPassword":"`+TEXT_REDACTED+`"`) {
+			configurationLogged = true
+		}
+	}
+	if !configurationLogged {
+		t.Errorf("Expected the configuration to be logged with the password redacted: %v", logged)
+	}
+}
@@ -16,10 +16,9 @@ limitations under the License.
 package main
 
 import (
-	"bytes"
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"os/signal"
 	"syscall"
 
@@ -37,9 +36,11 @@ func signalHandler(agentName string, coordinationQMgr string) chan int {
 	control := make(chan int)
 	// Use separate channels for the signals, to avoid SIGCHLD signals swamping
 	// the buffer, and preventing other signals.
-	stopSignals := make(chan os.Signal)
-	reapSignals := make(chan os.Signal)
+	stopSignals := make(chan os.Signal, 1)
+	reapSignals := make(chan os.Signal, 1)
+	reloadSignals := make(chan os.Signal, 1)
 	signal.Notify(stopSignals, syscall.SIGTERM, syscall.SIGINT)
+	signal.Notify(reloadSignals, syscall.SIGHUP)
 	go func() {
 		for {
 			select {
@@ -47,6 +48,7 @@ func signalHandler(agentName string, coordinationQMgr string) chan int {
 				utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_SIGNAL_RECD_0071, sig))
 				signal.Stop(reapSignals)
 				signal.Stop(stopSignals)
+				signal.Stop(reloadSignals)
 				// #nosec G104
 				stopAgent(agentName, coordinationQMgr)
 				// One final reap
@@ -54,6 +56,9 @@ func signalHandler(agentName string, coordinationQMgr string) chan int {
 				close(control)
 				// End the goroutine
 				return
+			case sig := <-reloadSignals:
+				utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_SIGNAL_RECD_0071, sig))
+				requestConfigReload()
 			case <-reapSignals:
 				if logLevel >= LOG_LEVEL_VERBOSE {
 					utils.PrintLog(utils.MFT_CONT_SIGNAL_CHILD_0069)
@@ -94,27 +99,28 @@ func reapZombies() {
 
 // Stops an agent when container stop is issued.
 func stopAgent(agentName string, coordinationQMgr string) {
-	var outb, errb bytes.Buffer
-	// Get the path of MFT fteStopAgent command.
-	cmdStopAgntPath, lookPathErr := exec.LookPath("fteStopAgent")
-	if lookPathErr != nil {
-		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_NOT_FOUND_0028, lookPathErr))
-		os.Exit(1)
+	// Stop supervision so that the agent is not restarted.
+	setAgentStopRequested()
+	// Give active transfers a chance to complete unless asked not to.
+	var interrupted []activeTransfer
+	if !isImmediateStopMode() {
+		var stopped bool
+		if interrupted, stopped = drainAgent(agentName, coordinationQMgr); stopped {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGENT_STOPPED_0068, agentName))
+			return
+		}
 	}
-	cmdStopAgnt := &exec.Cmd{
-		Path: cmdStopAgntPath,
-		Args: []string{cmdStopAgntPath, "-p", coordinationQMgr, agentName, "-i"},
+	defer logInterruptedTransfers(agentName, interrupted)
+	result := commandRunner.Run(context.Background(), Command{Name: "fteStopAgent",
+		Args: []string{"-p", coordinationQMgr, agentName, "-i"}})
+	if result.NotFound() {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_NOT_FOUND_0028, result.Err))
+		os.Exit(1)
 	}
-
-	outb.Reset()
-	errb.Reset()
-	cmdStopAgnt.Stdout = &outb
-	cmdStopAgnt.Stderr = &errb
-	err := cmdStopAgnt.Run()
-	if err != nil {
-		utils.PrintLog(fmt.Sprintf("An error occured when running fteStopAgent command. The error is: %s", err.Error()))
-		utils.PrintLog(fmt.Sprintf("Command: %s\n", outb.String()))
-		utils.PrintLog(fmt.Sprintf("Error %s\n", errb.String()))
+	if result.Err != nil {
+		utils.PrintLog(fmt.Sprintf("An error occured when running fteStopAgent command. The error is: %s", result.Err.Error()))
+		utils.PrintLog(fmt.Sprintf("Command: %s\n", result.Stdout))
+		utils.PrintLog(fmt.Sprintf("Error %s\n", result.Stderr))
 	} else {
 		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGENT_STOPPED_0068, agentName))
 	}
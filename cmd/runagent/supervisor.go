@@ -0,0 +1,278 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
+	"golang.org/x/sys/unix"
+)
+
+/**
+* Supervision of the agent JVM.
+*
+* The agent is created with maxRestartCount=0, so the agent process does not
+* restart itself. Once the agent has started, runagent watches the process ID in
+* agent.pid. If the agent ends other than through a container stop, the last
+* lines of output0.log are displayed and the agent is either restarted with
+* exponential backoff or the container ends with exit code 27.
+ */
+
+// Built in supervision defaults
+const AGENT_RESTART_DEFAULT_LIMIT = 0
+const AGENT_RESTART_DEFAULT_DELAY = 5 * time.Second
+const AGENT_RESTART_DEFAULT_MAX_DELAY = 60 * time.Second
+const AGENT_MONITOR_DEFAULT_INTERVAL = 5 * time.Second
+const AGENT_PING_DEFAULT_INTERVAL = 0 * time.Second
+
+// Number of lines of output0.log displayed when the agent ends
+const AGENT_ENDED_LOG_LINES = 20
+
+// Set when the agent is being stopped, so that it is not restarted
+var agentStopRequested int32
+
+// Held while the agent is checked or restarted, by supervision or by a reload
+// of the configuration
+var agentLifecycleLock sync.Mutex
+
+// Restart settings of the agent
+type agentRestartPolicy struct {
+	// Number of restarts allowed over the life of the container
+	limit int
+	// Interval at which the agent process is checked
+	interval time.Duration
+	// Interval at which a running agent is pinged to keep its readiness
+	// current. 0 means the agent is only pinged when it is started.
+	pingInterval time.Duration
+	// Delays between restarts, using the same backoff as setup steps
+	backoff RetryPolicy
+}
+
+// Returns the restart policy with any overrides from environment variables
+func loadAgentRestartPolicy() agentRestartPolicy {
+	policy := agentRestartPolicy{
+		limit:        AGENT_RESTART_DEFAULT_LIMIT,
+		interval:     AGENT_MONITOR_DEFAULT_INTERVAL,
+		pingInterval: AGENT_PING_DEFAULT_INTERVAL,
+		backoff: RetryPolicy{
+			InitialDelay: AGENT_RESTART_DEFAULT_DELAY,
+			MaxDelay:     AGENT_RESTART_DEFAULT_MAX_DELAY,
+			Multiplier:   RETRY_DEFAULT_MULTIPLIER,
+		},
+	}
+	if value, ok := retryEnvValue(MFT_AGENT_RESTART_LIMIT, 0); ok {
+		policy.limit = int(value)
+	}
+	if value, ok := retryEnvValue(MFT_AGENT_RESTART_DELAY, 0); ok {
+		policy.backoff.InitialDelay = time.Duration(value * float64(time.Second))
+	}
+	if value, ok := retryEnvValue(MFT_AGENT_RESTART_MAX_DELAY, 0); ok {
+		policy.backoff.MaxDelay = time.Duration(value * float64(time.Second))
+	}
+	if value, ok := retryEnvValue(MFT_AGENT_MONITOR_INTERVAL, 0.1); ok {
+		policy.interval = time.Duration(value * float64(time.Second))
+	}
+	if value, ok := envNumber(MFT_AGENT_PING_INTERVAL, 0); ok {
+		policy.pingInterval = time.Duration(value * float64(time.Second))
+	}
+	return policy
+}
+
+// Mark the agent as being stopped
+func setAgentStopRequested() {
+	atomic.StoreInt32(&agentStopRequested, 1)
+}
+
+func isAgentStopRequested() bool {
+	return atomic.LoadInt32(&agentStopRequested) == 1
+}
+
+// Watch the agent process until the context is cancelled. The exit code of the
+// container is sent on the returned channel if the agent ends and can not be
+// restarted. A restarted agent must respond to a ping within pingWaitTime seconds.
+// If a ping interval is set, a running agent is pinged again at that interval,
+// so that /readyz reports whether it still responds through the coordination
+// queue manager.
+func superviseAgent(ctx context.Context, bfgDataPath string, coordinationQMgr string, agentName string,
+	pingWaitTime string) <-chan int {
+	agentEnded := make(chan int, 1)
+	supervisor := &agentSupervisor{
+		policy:           loadAgentRestartPolicy(),
+		agentPath:        bfgDataPath + DIR_AGENT_LOGS + coordinationQMgr + DIR_AGENTS + agentName,
+		coordinationQMgr: coordinationQMgr,
+		agentName:        agentName,
+		pingWaitTime:     pingWaitTime,
+		lastPing:         time.Now(),
+	}
+
+	go func() {
+		ticker := time.NewTicker(supervisor.policy.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			exitCode, ended := supervisor.checkAgent(ctx)
+			if ended {
+				agentEnded <- exitCode
+				return
+			}
+			supervisor.pingIfDue(ctx)
+		}
+	}()
+	return agentEnded
+}
+
+// State of the supervision of an agent
+type agentSupervisor struct {
+	policy           agentRestartPolicy
+	agentPath        string
+	coordinationQMgr string
+	agentName        string
+	pingWaitTime     string
+	// Number of restarts so far
+	restarts int
+	// When the agent was last pinged
+	lastPing time.Time
+}
+
+// Restart the agent if it has ended. Returns the exit code of the container and
+// true if the agent has ended and could not be restarted. The lifecycle lock is
+// not held while waiting to restart the agent, so that a reload of the
+// configuration or a rotation of certificates is not held up by the backoff.
+func (s *agentSupervisor) checkAgent(ctx context.Context) (int, bool) {
+	if !s.agentEnded() {
+		return MFT_CONT_SUCCESS_CODE_0, false
+	}
+
+	agentHealth.setRestarting(true)
+	defer agentHealth.setRestarting(false)
+	utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_ENDED_0090, s.agentName, agentLogTail(s.agentPath+"/logs/output0.log")))
+	if s.restarts >= s.policy.limit {
+		logTerminationf(utils.MFT_CONT_AGNT_RESTART_FAILED_0093, s.agentName, s.restarts)
+		return MFT_CONT_ERR_CODE_27, true
+	}
+
+	s.restarts++
+	delay := s.policy.backoff.delay(s.restarts)
+	utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_RESTARTING_0091, s.agentName, delay, s.restarts, s.policy.limit))
+	select {
+	case <-ctx.Done():
+		return MFT_CONT_SUCCESS_CODE_0, false
+	case <-time.After(delay):
+	}
+
+	agentLifecycleLock.Lock()
+	defer agentLifecycleLock.Unlock()
+	// The container may have been stopped, or the agent started again by a
+	// reload of the configuration, while waiting.
+	if isAgentStopRequested() || isAgentProcessRunning(s.agentPath+"/agent.pid") {
+		return MFT_CONT_SUCCESS_CODE_0, false
+	}
+	if !StartAgent(ctx, s.agentName, s.coordinationQMgr) || !PingAgent(ctx, s.coordinationQMgr, s.agentName, s.pingWaitTime) {
+		logTerminationf(utils.MFT_CONT_AGNT_RESTART_FAILED_0093, s.agentName, s.restarts)
+		return MFT_CONT_ERR_CODE_27, true
+	}
+	s.lastPing = time.Now()
+	utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_AGNT_RESTARTED_0092, s.agentName))
+	return MFT_CONT_SUCCESS_CODE_0, false
+}
+
+// Returns true if the agent process has ended without being stopped by the
+// container. Configuration reload may be restarting the agent, so this waits
+// for the lifecycle lock.
+func (s *agentSupervisor) agentEnded() bool {
+	agentLifecycleLock.Lock()
+	defer agentLifecycleLock.Unlock()
+	return !isAgentStopRequested() && !isAgentProcessRunning(s.agentPath+"/agent.pid")
+}
+
+// Ping the running agent once the ping interval has passed and record the
+// result for /readyz. The ping is not retried, a failure only makes the agent
+// not ready until the next ping. The lifecycle lock is not held, so that a
+// slow ping does not hold up a reload of the configuration.
+func (s *agentSupervisor) pingIfDue(ctx context.Context) {
+	if s.policy.pingInterval <= 0 || time.Since(s.lastPing) < s.policy.pingInterval ||
+		isAgentStopRequested() || !isAgentProcessRunning(s.agentPath+"/agent.pid") {
+		return
+	}
+	s.lastPing = time.Now()
+	result := commandRunner.Run(ctx, Command{
+		Name:    "ftePingAgent",
+		Args:    []string{"-p", s.coordinationQMgr, s.agentName, "-w", s.pingWaitTime},
+		Timeout: retryPolicyFor(STEP_PING_AGENT).Timeout,
+		Trace:   true,
+	})
+	// A ping cut short by the container stopping says nothing about the agent.
+	if ctx.Err() != nil {
+		return
+	}
+	if result.Err != nil && logLevel >= LOG_LEVEL_VERBOSE {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CMD_ERROR_0042, result.Stdout, result.Stderr))
+	}
+	agentHealth.recordPing(agentResponded(result))
+}
+
+// Returns true if the process in the agent's pid file is running
+func isAgentProcessRunning(pidFileName string) bool {
+	agentPid, err := utils.GetAgentPid(pidFileName)
+	if err != nil {
+		return false
+	}
+	running, err := utils.IsAgentRunning(agentPid)
+	if err != nil || !running {
+		return false
+	}
+	// runagent adopts the agent JVM once fteStartAgent ends, so an ended agent
+	// remains a zombie, which still accepts signals, until it is reaped.
+	if isZombieProcess(agentPid) {
+		var ws unix.WaitStatus
+		// #nosec G104
+		unix.Wait4(int(agentPid), &ws, unix.WNOHANG, nil)
+		return false
+	}
+	return true
+}
+
+// Returns true if the process has ended but has not been reaped
+func isZombieProcess(pid int32) bool {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return false
+	}
+	// The state follows the command name, which is in brackets and may
+	// contain spaces.
+	fields := strings.Fields(string(stat[strings.LastIndex(string(stat), ")")+1:]))
+	return len(fields) > 0 && fields[0] == "Z"
+}
+
+// Returns the last lines of the agent's log file for display
+func agentLogTail(logFileName string) string {
+	lines, err := utils.ReadLastLines(logFileName, AGENT_ENDED_LOG_LINES)
+	if err != nil {
+		return err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
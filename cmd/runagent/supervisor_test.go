@@ -0,0 +1,229 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Returns a handler for fteStartAgent that starts a process standing in for the
+// agent JVM and writes its pid to agent.pid
+func startAgentProcessHandler(t *testing.T, agentPath string, started chan<- *exec.Cmd) func(args []string) CommandResult {
+	return func(args []string) CommandResult {
+		createFileHandler(t, filepath.Join(agentPath, "logs/output0.log"),
+			"BFGAG0059I: The agent SRC has been successfully initialized.\n")(args)
+		process := exec.Command("sleep", "30")
+		if err := process.Start(); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { process.Process.Kill() })
+		if err := os.WriteFile(filepath.Join(agentPath, "agent.pid"), []byte(strconv.Itoa(process.Process.Pid)), 0644); err != nil {
+			t.Error(err)
+		}
+		started <- process
+		return CommandResult{}
+	}
+}
+
+// Clear the stop request left by earlier tests that stopped the agent
+func resetAgentStopRequested(t *testing.T) {
+	atomic.StoreInt32(&agentStopRequested, 0)
+	t.Cleanup(func() { atomic.StoreInt32(&agentStopRequested, 0) })
+}
+
+// An agent that ends is restarted until the restart limit is reached
+func TestRunAgentRestartsAgent(t *testing.T) {
+	bfgDataPath := t.TempDir()
+	configFile := filepath.Join(t.TempDir(), "agentconfig.json")
+	configData := `{
+		"coordinationQMgr":{"name":"COORDQM","host":"localhost"},
+		"commandQMgr":{"name":"CMDQM","host":"localhost"},
+		"agents":[{"name":"SRC","qmgrName":"AGENTQM","qmgrHost":"localhost","deleteOnTermination":"true"}]
+	}`
+	if err := os.WriteFile(configFile, []byte(configData), 0644); err != nil {
+		t.Fatal(err)
+	}
+	setTestEnv(t, MFT_AGENT_NAME, "SRC")
+	setTestEnv(t, MFT_AGENT_CONFIG_FILE, configFile)
+	setTestEnv(t, BFG_DATA, bfgDataPath)
+	setTestEnv(t, MFT_AGENT_START_WAIT_TIME, "1")
+	setTestEnv(t, MFT_AGENT_RESTART_LIMIT, "1")
+	setTestEnv(t, MFT_AGENT_RESTART_DELAY, "0")
+	setTestEnv(t, MFT_AGENT_MONITOR_INTERVAL, "0.1")
+	previousTerminationLogPath := terminationLogPath
+	terminationLogPath = filepath.Join(t.TempDir(), "termination-log")
+	t.Cleanup(func() { terminationLogPath = previousTerminationLogPath })
+	resetAgentStopRequested(t)
+
+	configDir := filepath.Join(bfgDataPath, "mqft/config/COORDQM")
+	agentPath := filepath.Join(bfgDataPath, "mqft/logs/COORDQM/agents/SRC")
+	started := make(chan *exec.Cmd, 2)
+	fake := useFakeCommandRunner(t)
+	fake.handlers["fteSetupCoordination"] = createFileHandler(t, filepath.Join(configDir, "coordination.properties"), "")
+	fake.handlers["fteSetupCommands"] = createFileHandler(t, filepath.Join(configDir, "command.properties"), "")
+	fake.handlers["fteCreateAgent"] = createFileHandler(t, filepath.Join(configDir, "agents/SRC/agent.properties"), "")
+	fake.handlers["fteStartAgent"] = startAgentProcessHandler(t, agentPath, started)
+	fake.handlers["ftePingAgent"] = func(args []string) CommandResult {
+		return CommandResult{Stdout: "BFGCL0793I: The agent SRC responded to the ping in 0.1 seconds.\n"}
+	}
+
+	exitCode := make(chan int, 1)
+	go func() { exitCode <- runAgent(context.Background()) }()
+
+	// End the agent twice, the second time after it has been restarted.
+	for i := 0; i < 2; i++ {
+		select {
+		case process := <-started:
+			process.Process.Kill()
+		case <-time.After(10 * time.Second):
+			t.Fatalf("Agent not started, commands run: %v", fake.commandNames())
+		}
+	}
+
+	select {
+	case code := <-exitCode:
+		if code != MFT_CONT_ERR_CODE_27 {
+			t.Errorf("Expected exit code %d, found %d", MFT_CONT_ERR_CODE_27, code)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("Container did not end, commands run: %v", fake.commandNames())
+	}
+
+	expected := []string{"fteSetupCoordination", "fteObfuscate", "fteSetupCommands", "fteObfuscate",
+		"fteCreateAgent", "fteObfuscate", "fteStartAgent", "ftePingAgent", "fteStartAgent", "ftePingAgent", "fteDeleteAgent"}
+	if !reflect.DeepEqual(fake.commandNames(), expected) {
+		t.Errorf("Expected commands %v, found %v", expected, fake.commandNames())
+	}
+	if _, err := os.Stat(terminationLogPath); err != nil {
+		t.Errorf("Termination log not written: %v", err)
+	}
+}
+
+// The lifecycle lock is free while waiting to restart an agent that has ended
+func TestSupervisorReleasesLockWhileWaiting(t *testing.T) {
+	previousHealth := agentHealth
+	agentHealth = newHealthState("SRC")
+	t.Cleanup(func() { agentHealth = previousHealth })
+	agentHealth.setStarted(true)
+	resetAgentStopRequested(t)
+	fake := useFakeCommandRunner(t)
+	supervisor := &agentSupervisor{
+		policy:           agentRestartPolicy{limit: 1, backoff: RetryPolicy{InitialDelay: time.Hour, Multiplier: 1}},
+		agentPath:        t.TempDir(),
+		coordinationQMgr: "COORDQM",
+		agentName:        "SRC",
+		pingWaitTime:     "1",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ended := make(chan bool, 1)
+	go func() {
+		_, agentEnded := supervisor.checkAgent(ctx)
+		ended <- agentEnded
+	}()
+
+	// Wait until the restart is pending, then take the lock as a reload would.
+	for deadline := time.Now().Add(10 * time.Second); agentHealth.live().Checks["agentProcess"] != HEALTH_STATUS_RESTARTING; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("Restart of the agent not started")
+		}
+	}
+	locked := make(chan struct{})
+	go func() {
+		agentLifecycleLock.Lock()
+		agentLifecycleLock.Unlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Lifecycle lock held while waiting to restart the agent")
+	}
+
+	cancel()
+	if <-ended || len(fake.calls) != 0 {
+		t.Errorf("Expected the restart to be abandoned, commands run: %v", fake.commandNames())
+	}
+}
+
+// A running agent is pinged at the ping interval and the result kept for /readyz
+func TestSupervisorPingsRunningAgent(t *testing.T) {
+	agentPath := t.TempDir()
+	process := exec.Command("sleep", "30")
+	if err := process.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer process.Process.Kill()
+	if err := os.WriteFile(filepath.Join(agentPath, "agent.pid"), []byte(strconv.Itoa(process.Process.Pid)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	previousHealth := agentHealth
+	agentHealth = newHealthState("SRC")
+	t.Cleanup(func() { agentHealth = previousHealth })
+	agentHealth.setAgentPath(agentPath)
+	agentHealth.setStarted(true)
+	agentHealth.recordPing(true)
+	resetAgentStopRequested(t)
+
+	fake := useFakeCommandRunner(t)
+	fake.handlers["ftePingAgent"] = func(args []string) CommandResult {
+		return CommandResult{Stdout: "BFGCL0213I: no acknowledgement\n", Err: errors.New("exit status 2")}
+	}
+	supervisor := &agentSupervisor{
+		policy:           agentRestartPolicy{pingInterval: time.Hour},
+		agentPath:        agentPath,
+		coordinationQMgr: "COORDQM",
+		agentName:        "SRC",
+		pingWaitTime:     "1",
+		lastPing:         time.Now(),
+	}
+
+	// Not pinged before the interval has passed
+	supervisor.pingIfDue(context.Background())
+	if len(fake.calls) != 0 || agentHealth.ready().Status != HEALTH_STATUS_UP {
+		t.Fatalf("Unexpected ping, commands run: %v", fake.commandNames())
+	}
+
+	supervisor.lastPing = time.Now().Add(-time.Hour)
+	supervisor.pingIfDue(context.Background())
+	expected := []string{"-p", "COORDQM", "SRC", "-w", "1"}
+	if !reflect.DeepEqual(fake.commandArgs("ftePingAgent"), expected) {
+		t.Errorf("Expected arguments %v, found %v", expected, fake.commandArgs("ftePingAgent"))
+	}
+	if report := agentHealth.ready(); report.Checks["coordination"] != HEALTH_STATUS_DOWN {
+		t.Errorf("Expected the agent not to be ready after a failed ping, found %+v", report)
+	}
+}
+
+func TestAgentLogTail(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "output0.log")
+	if err := os.WriteFile(logFile, []byte("line1\nline2\nline3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if tail := agentLogTail(logFile); tail != "line1\nline2\nline3" {
+		t.Errorf("Unexpected tail %q", tail)
+	}
+}
@@ -0,0 +1,575 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/ibm-messaging/mq-container-mft/pkg/logger"
+	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
+	"github.com/tidwall/gjson"
+)
+
+/**
+ * Export of transfers as OpenTelemetry traces, and of container diagnostics as
+ * OpenTelemetry logs, over OTLP/HTTP with JSON encoding.
+ *
+ * Each transfer is a span, from its started to its completed event, with an
+ * event for each progress update and for each item reported in capture0.log.
+ * The trace ID is derived from the transfer ID, so the spans of the source and
+ * destination agents of a transfer belong to the same trace.
+ */
+
+// Paths of the OTLP/HTTP endpoints, relative to OTEL_EXPORTER_OTLP_ENDPOINT
+const OTEL_TRACES_PATH = "/v1/traces"
+const OTEL_LOGS_PATH = "/v1/logs"
+
+// The only OTLP protocol supported
+const OTEL_PROTOCOL_HTTP_JSON = "http/json"
+
+// Name of the service unless OTEL_SERVICE_NAME is set
+const OTEL_DEFAULT_SERVICE_NAME = "ibmmqmft-agent"
+
+// Name of the span of a transfer
+const OTEL_TRANSFER_SPAN_NAME = "mqmft.transfer"
+
+// Instrumentation scope of the spans and logs
+const OTEL_SCOPE_NAME = "github.com/ibm-messaging/mq-container-mft"
+
+// Interval between exports, and time allowed for an export request
+const OTEL_EXPORT_INTERVAL = 5 * time.Second
+const OTEL_EXPORT_TIMEOUT = 10 * time.Second
+
+// Maximum number of spans, and of log records, waiting to be exported
+const OTEL_MAX_QUEUED = 2048
+
+// Maximum number of transfers whose spans are being built. The span of the
+// transfer seen first is dropped when a new transfer would exceed it, as the
+// completion of a transfer may never be logged.
+const OTEL_MAX_ACTIVE_TRANSFERS = 1000
+
+// Kinds and status codes of spans
+const (
+	otelSpanKindInternal = 1
+	otelSpanKindProducer = 4
+	otelSpanKindConsumer = 5
+	otelStatusCodeOk     = 1
+	otelStatusCodeError  = 2
+)
+
+// Severity numbers of container diagnostics, by level
+var otelSeverities = map[string]int{
+	utils.LOG_LEVEL_INFO:  9,
+	utils.LOG_LEVEL_WARN:  13,
+	utils.LOG_LEVEL_ERROR: 17,
+}
+
+// Attribute of a span, event, log record or resource
+type otelKeyValue struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+func otelString(key string, value string) otelKeyValue {
+	return otelKeyValue{Key: key, Value: map[string]interface{}{"stringValue": value}}
+}
+
+func otelInt(key string, value int64) otelKeyValue {
+	// 64 bit integers are encoded as strings in JSON.
+	return otelKeyValue{Key: key, Value: map[string]interface{}{"intValue": strconv.FormatInt(value, 10)}}
+}
+
+type otelEvent struct {
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otelKeyValue `json:"attributes,omitempty"`
+}
+
+type otelStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otelSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otelKeyValue `json:"attributes,omitempty"`
+	Events            []otelEvent    `json:"events,omitempty"`
+	Status            otelStatus     `json:"status"`
+}
+
+type otelLogRecord struct {
+	TimeUnixNano         string                 `json:"timeUnixNano"`
+	ObservedTimeUnixNano string                 `json:"observedTimeUnixNano"`
+	SeverityNumber       int                    `json:"severityNumber"`
+	SeverityText         string                 `json:"severityText"`
+	Body                 map[string]interface{} `json:"body"`
+}
+
+func otelTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// Exports spans and log records in batches to an OpenTelemetry collector
+type otelExporter struct {
+	lock sync.Mutex
+	// Empty if the signal is not exported
+	tracesUrl string
+	logsUrl   string
+	headers   map[string]string
+	resource  []otelKeyValue
+	client    *http.Client
+	spans     []otelSpan
+	logs      []otelLogRecord
+	// Whether the last export of each signal failed, to report failures once
+	failing map[string]bool
+}
+
+// Queue a completed span for export, dropping it if the queue is full
+func (e *otelExporter) addSpan(span otelSpan) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if len(e.tracesUrl) > 0 && len(e.spans) < OTEL_MAX_QUEUED {
+		e.spans = append(e.spans, span)
+	}
+}
+
+// Queue a diagnostic message for export, dropping it if the queue is full
+func (e *otelExporter) addLog(logTime time.Time, msg string) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if len(e.logsUrl) > 0 && len(e.logs) < OTEL_MAX_QUEUED {
+		level := utils.MessageLevel(msg)
+		e.logs = append(e.logs, otelLogRecord{
+			TimeUnixNano:         otelTime(logTime),
+			ObservedTimeUnixNano: otelTime(time.Now()),
+			SeverityNumber:       otelSeverities[level],
+			SeverityText:         level,
+			Body:                 map[string]interface{}{"stringValue": msg},
+		})
+	}
+}
+
+// Export queued spans and log records every interval until the context is
+// cancelled, then export those still queued
+func (e *otelExporter) run(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(OTEL_EXPORT_INTERVAL)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				e.export()
+			case <-ctx.Done():
+				e.export()
+				return
+			}
+		}
+	}()
+}
+
+// Export the queued spans and log records. Those that fail to export with an
+// error worth retrying are queued again.
+func (e *otelExporter) export() {
+	e.lock.Lock()
+	spans, logs := e.spans, e.logs
+	e.spans, e.logs = nil, nil
+	e.lock.Unlock()
+
+	scope := map[string]interface{}{"name": OTEL_SCOPE_NAME}
+	if len(spans) > 0 {
+		request := map[string]interface{}{
+			"resourceSpans": []interface{}{map[string]interface{}{
+				"resource":   map[string]interface{}{"attributes": e.resource},
+				"scopeSpans": []interface{}{map[string]interface{}{"scope": scope, "spans": spans}},
+			}},
+		}
+		if !e.post("traces", e.tracesUrl, request) {
+			e.lock.Lock()
+			e.spans = append(spans, e.spans...)
+			if len(e.spans) > OTEL_MAX_QUEUED {
+				e.spans = e.spans[:OTEL_MAX_QUEUED]
+			}
+			e.lock.Unlock()
+		}
+	}
+	if len(logs) > 0 {
+		request := map[string]interface{}{
+			"resourceLogs": []interface{}{map[string]interface{}{
+				"resource":  map[string]interface{}{"attributes": e.resource},
+				"scopeLogs": []interface{}{map[string]interface{}{"scope": scope, "logRecords": logs}},
+			}},
+		}
+		if !e.post("logs", e.logsUrl, request) {
+			e.lock.Lock()
+			e.logs = append(logs, e.logs...)
+			if len(e.logs) > OTEL_MAX_QUEUED {
+				e.logs = e.logs[:OTEL_MAX_QUEUED]
+			}
+			e.lock.Unlock()
+		}
+	}
+}
+
+// Post an export request. Returns false if it failed and is worth retrying.
+func (e *otelExporter) post(signal string, exportUrl string, request interface{}) bool {
+	retryable, err := func() (bool, error) {
+		payload, err := json.Marshal(request)
+		if err != nil {
+			return false, err
+		}
+		httpRequest, err := http.NewRequest("POST", exportUrl, bytes.NewBuffer(payload))
+		if err != nil {
+			return false, err
+		}
+		httpRequest.Header.Set("Content-Type", "application/json")
+		for name, value := range e.headers {
+			httpRequest.Header.Set(name, value)
+		}
+		response, err := e.client.Do(httpRequest)
+		if err != nil {
+			return true, err
+		}
+		defer response.Body.Close()
+		body, _ := ioutil.ReadAll(io.LimitReader(response.Body, 1024))
+		if response.StatusCode >= 200 && response.StatusCode < 300 {
+			return false, nil
+		}
+		retryable := response.StatusCode == http.StatusTooManyRequests || response.StatusCode == http.StatusBadGateway ||
+			response.StatusCode == http.StatusServiceUnavailable || response.StatusCode == http.StatusGatewayTimeout
+		return retryable, fmt.Errorf("collector returned status %s: %s", response.Status, strings.TrimSpace(string(body)))
+	}()
+
+	e.lock.Lock()
+	reportFailure := err != nil && !e.failing[signal]
+	e.failing[signal] = err != nil
+	e.lock.Unlock()
+	if reportFailure {
+		// Printed after the lock is released, as the message is exported as well.
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_OTEL_EXPORT_FAILED_0119, signal, exportUrl, err))
+	}
+	return err == nil || !retryable
+}
+
+// Span of a transfer that has not completed
+type transferSpan struct {
+	span             otelSpan
+	sourceAgent      string
+	destinationAgent string
+	// Bytes sent so far, or -1 if not reported
+	bytesSent int64
+	// Order in which the transfer was first seen
+	sequence uint64
+}
+
+// Builds spans of the transfers of an agent from its transfer log entries
+type transferTracer struct {
+	lock      sync.Mutex
+	agentName string
+	exporter  *otelExporter
+	// Spans of transfers that have not completed, by transfer ID
+	spans map[string]*transferSpan
+	// Recently completed transfers, oldest first
+	completed      map[string]bool
+	completedOrder []string
+	// Sequence number of the last transfer seen
+	sequence uint64
+}
+
+func newTransferTracer(agentName string, exporter *otelExporter) *transferTracer {
+	return &transferTracer{
+		agentName: agentName,
+		exporter:  exporter,
+		spans:     make(map[string]*transferSpan),
+		completed: make(map[string]bool),
+	}
+}
+
+// Update the spans with an entry of transferlog0.json or capture0.log
+func (t *transferTracer) recordLogEntry(entry string) {
+	if gjson.Valid(entry) {
+		if event, ok := parseTransferLogJSON(entry); ok {
+			t.recordTransfer(event)
+		}
+		return
+	}
+	if doc := parseLogXML(entry); doc != nil {
+		if transaction := xmlquery.FindOne(doc, "//transaction"); transaction != nil {
+			if event, ok := parseTransferLogXML(transaction); ok {
+				t.recordTransfer(event)
+			}
+		}
+	}
+}
+
+func (t *transferTracer) recordTransfer(event transferEvent) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.completed[event.id] {
+		return
+	}
+	eventTime := event.time
+	if eventTime.IsZero() {
+		eventTime = time.Now()
+	}
+	transfer, exists := t.spans[event.id]
+	if !exists {
+		if len(t.spans) >= OTEL_MAX_ACTIVE_TRANSFERS {
+			t.dropOldestSpan()
+		}
+		t.sequence++
+		transfer = &transferSpan{bytesSent: -1, sequence: t.sequence, span: otelSpan{
+			TraceID:           transferTraceID(event.id),
+			SpanID:            newSpanID(),
+			Name:              OTEL_TRANSFER_SPAN_NAME,
+			StartTimeUnixNano: otelTime(eventTime),
+		}}
+		t.spans[event.id] = transfer
+	}
+	if len(event.sourceAgent) > 0 {
+		transfer.sourceAgent = event.sourceAgent
+	}
+	if len(event.destinationAgent) > 0 {
+		transfer.destinationAgent = event.destinationAgent
+	}
+	if event.bytesSent >= 0 {
+		transfer.bytesSent = event.bytesSent
+	}
+
+	span := &transfer.span
+	if event.kind == transferEventProgress && len(event.items) == 0 {
+		var attributes []otelKeyValue
+		if event.bytesSent >= 0 {
+			attributes = append(attributes, otelInt("mqmft.transfer.bytes_sent", event.bytesSent))
+		}
+		span.Events = append(span.Events, otelEvent{TimeUnixNano: otelTime(eventTime), Name: "progress", Attributes: attributes})
+	}
+	for _, item := range event.items {
+		attributes := []otelKeyValue{
+			otelString("mqmft.item.source", item.source),
+			otelString("mqmft.item.destination", item.destination),
+			otelInt("mqmft.item.result_code", item.resultCode),
+		}
+		if len(item.supplement) > 0 {
+			attributes = append(attributes, otelString("mqmft.item.supplement", item.supplement))
+		}
+		span.Events = append(span.Events, otelEvent{TimeUnixNano: otelTime(eventTime), Name: "item", Attributes: attributes})
+	}
+	if event.kind != transferEventCompleted {
+		return
+	}
+
+	if !exists && !event.startTime.IsZero() {
+		span.StartTimeUnixNano = otelTime(event.startTime)
+	}
+	span.EndTimeUnixNano = otelTime(eventTime)
+	span.Kind = otelSpanKindInternal
+	if strings.EqualFold(transfer.sourceAgent, t.agentName) {
+		span.Kind = otelSpanKindProducer
+	} else if strings.EqualFold(transfer.destinationAgent, t.agentName) {
+		span.Kind = otelSpanKindConsumer
+	}
+	span.Attributes = []otelKeyValue{
+		otelString("mqmft.transfer.id", event.id),
+		otelString("mqmft.source_agent", transfer.sourceAgent),
+		otelString("mqmft.destination_agent", transfer.destinationAgent),
+		otelString("mqmft.transfer.result", event.result),
+		otelInt("mqmft.transfer.retry_count", event.retryCount),
+	}
+	if transfer.bytesSent >= 0 {
+		span.Attributes = append(span.Attributes, otelInt("mqmft.transfer.bytes_sent", transfer.bytesSent))
+	}
+	if event.result == TRANSFER_RESULT_SUCCESSFUL || event.result == TRANSFER_RESULT_PARTIALLY_SUCCESSFUL {
+		span.Status = otelStatus{Code: otelStatusCodeOk}
+	} else {
+		span.Status = otelStatus{Code: otelStatusCodeError, Message: event.result}
+	}
+	t.exporter.addSpan(*span)
+
+	delete(t.spans, event.id)
+	t.completed[event.id] = true
+	t.completedOrder = append(t.completedOrder, event.id)
+	if len(t.completedOrder) > METRICS_COMPLETED_TRANSFERS_KEPT {
+		delete(t.completed, t.completedOrder[0])
+		t.completedOrder = t.completedOrder[1:]
+	}
+}
+
+// Drop the span of the transfer seen first
+func (t *transferTracer) dropOldestSpan() {
+	oldestID := ""
+	var oldest uint64
+	for id, transfer := range t.spans {
+		if len(oldestID) == 0 || transfer.sequence < oldest {
+			oldestID, oldest = id, transfer.sequence
+		}
+	}
+	delete(t.spans, oldestID)
+}
+
+// Returns the trace ID of a transfer, which is the same in every agent
+func transferTraceID(transferID string) string {
+	sum := sha256.Sum256([]byte(strings.ToUpper(transferID)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Returns a random span ID
+func newSpanID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// Returns the endpoint of a signal, or an empty string if it is not exported
+func getOtelEndpoint(exporterVar string, endpointVar string, path string) string {
+	if strings.EqualFold(strings.Trim(os.Getenv(exporterVar), TEXT_TRIM), "none") {
+		return ""
+	}
+	if endpoint := strings.Trim(os.Getenv(endpointVar), TEXT_TRIM); len(endpoint) > 0 {
+		return endpoint
+	}
+	if endpoint := strings.Trim(os.Getenv(OTEL_EXPORTER_OTLP_ENDPOINT), TEXT_TRIM); len(endpoint) > 0 {
+		return strings.TrimRight(endpoint, "/") + path
+	}
+	return ""
+}
+
+// Returns the key value pairs of a list like OTEL_EXPORTER_OTLP_HEADERS
+func parseOtelList(list string) map[string]string {
+	pairs := make(map[string]string)
+	for _, pair := range strings.Split(list, ",") {
+		keyValue := strings.SplitN(pair, "=", 2)
+		if len(keyValue) != 2 || len(strings.TrimSpace(keyValue[0])) == 0 {
+			continue
+		}
+		value, err := url.QueryUnescape(strings.TrimSpace(keyValue[1]))
+		if err != nil {
+			value = strings.TrimSpace(keyValue[1])
+		}
+		pairs[strings.TrimSpace(keyValue[0])] = value
+	}
+	return pairs
+}
+
+// Returns the exporter configured by the OpenTelemetry environment variables,
+// or nil if export is disabled
+func newOtelExporter(agentName string, coordinationQMgr string) *otelExporter {
+	if strings.EqualFold(strings.Trim(os.Getenv(OTEL_SDK_DISABLED), TEXT_TRIM), "true") {
+		return nil
+	}
+	exporter := &otelExporter{
+		tracesUrl: getOtelEndpoint(OTEL_TRACES_EXPORTER, OTEL_EXPORTER_OTLP_TRACES_ENDPOINT, OTEL_TRACES_PATH),
+		logsUrl:   getOtelEndpoint(OTEL_LOGS_EXPORTER, OTEL_EXPORTER_OTLP_LOGS_ENDPOINT, OTEL_LOGS_PATH),
+		headers:   parseOtelList(os.Getenv(OTEL_EXPORTER_OTLP_HEADERS)),
+		client:    &http.Client{Timeout: OTEL_EXPORT_TIMEOUT},
+		failing:   make(map[string]bool),
+	}
+	if len(exporter.tracesUrl) == 0 && len(exporter.logsUrl) == 0 {
+		return nil
+	}
+	if protocol := strings.Trim(os.Getenv(OTEL_EXPORTER_OTLP_PROTOCOL), TEXT_TRIM); len(protocol) > 0 &&
+		protocol != OTEL_PROTOCOL_HTTP_JSON {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_OTEL_PROTOCOL_0120, protocol))
+	}
+
+	serviceName := strings.Trim(os.Getenv(OTEL_SERVICE_NAME), TEXT_TRIM)
+	if len(serviceName) == 0 {
+		serviceName = OTEL_DEFAULT_SERVICE_NAME
+	}
+	resource := map[string]string{
+		"service.name":            serviceName,
+		"service.instance.id":     agentName,
+		"mqmft.agent":             agentName,
+		"mqmft.coordination_qmgr": coordinationQMgr,
+	}
+	if hostName, err := os.Hostname(); err == nil {
+		resource["host.name"] = hostName
+	}
+	// Attributes set in the environment take precedence, except for the
+	// service name which is set by its own variable.
+	for key, value := range parseOtelList(os.Getenv(OTEL_RESOURCE_ATTRIBUTES)) {
+		if key != "service.name" || len(os.Getenv(OTEL_SERVICE_NAME)) == 0 {
+			resource[key] = value
+		}
+	}
+	keys := make([]string, 0, len(resource))
+	for key := range resource {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		exporter.resource = append(exporter.resource, otelString(key, resource[key]))
+	}
+	return exporter
+}
+
+// Export the transfers of the agent as traces and the container diagnostics
+// as logs until the context is cancelled, if an OpenTelemetry endpoint is set
+func startTelemetry(ctx context.Context, wg *sync.WaitGroup, agentName string, coordinationQMgr string, agentPath string) {
+	exporter := newOtelExporter(agentName, coordinationQMgr)
+	if exporter == nil {
+		return
+	}
+	exporter.run(ctx, wg)
+
+	if len(exporter.logsUrl) > 0 {
+		utils.SetPrintLogHook(exporter.addLog)
+		go func() {
+			<-ctx.Done()
+			utils.SetPrintLogHook(nil)
+		}()
+	}
+	if len(exporter.tracesUrl) > 0 {
+		// The log mirror reports through the event logger.
+		if eventLog == nil {
+			eventLog, _ = logger.NewLogger(os.Stdout, getDebug(), utils.IsJSONLogFormat(), agentName, "", "", -1)
+		}
+		tracer := newTransferTracer(agentName, exporter)
+		for _, logFile := range []string{agentPath + "/logs/transferlog0.json", agentPath + "/logs/capture0.log"} {
+			if _, err := mirrorLog(ctx, wg, logFile, false, func(entry string) bool {
+				tracer.recordLogEntry(entry)
+				return false
+			}); err != nil {
+				utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_OTEL_EXPORT_FAILED_0119, "traces", exporter.tracesUrl, err))
+			}
+		}
+	}
+	utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_OTEL_EXPORTING_0118, agentName, strings.Trim(exporter.tracesUrl+" "+exporter.logsUrl, " ")))
+}
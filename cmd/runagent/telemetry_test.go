@@ -0,0 +1,124 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
+	"github.com/tidwall/gjson"
+)
+
+func TestTransferTelemetry(t *testing.T) {
+	var lock sync.Mutex
+	requests := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		lock.Lock()
+		defer lock.Unlock()
+		if r.Header.Get("X-Api-Key") != "secret key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		requests[r.URL.Path] = string(body)
+	}))
+	defer server.Close()
+	setTestEnv(t, OTEL_SDK_DISABLED, "")
+	setTestEnv(t, OTEL_EXPORTER_OTLP_ENDPOINT, server.URL+"/")
+	setTestEnv(t, OTEL_EXPORTER_OTLP_HEADERS, "X-Api-Key=secret%20key")
+	setTestEnv(t, OTEL_RESOURCE_ATTRIBUTES, "deployment.environment=test")
+	setTestEnv(t, OTEL_SERVICE_NAME, "")
+
+	exporter := newOtelExporter("SRC", "QM1")
+	if exporter == nil {
+		t.Fatal("Export not enabled")
+	}
+	tracer := newTransferTracer("SRC", exporter)
+	for _, entry := range []string{
+		`{"transferId":"a1","sourceAgent":"SRC","destinationAgent":"DEST","eventDescription":"Transfer started","time":"2022-01-01T00:00:00Z"}`,
+		`<?xml version="1.0" encoding="UTF-8"?><transaction version="6.00" ID="A1"><action time="2022-01-01T00:00:05Z">progress</action>` +
+			`<sourceAgent agent="SRC"/><destinationAgent agent="DEST"/><transferSet bytesSent="10" total="2">` +
+			`<item mode="binary"><source><file size="10">/in/a.txt</file></source><destination><file size="10">/out/a.txt</file></destination><status resultCode="0"/></item>` +
+			`<item mode="binary"><source><file size="10">/in/b.txt</file></source><destination><file>/out/b.txt</file></destination><status resultCode="1"><supplement>BFGIO0001E</supplement></status></item>` +
+			`</transferSet></transaction>`,
+		`{"transferId":"a1","sourceAgent":"SRC","destinationAgent":"DEST","eventDescription":"Transfer completed","transferCompleted":{"resultCode":40,"time":"2022-01-01T00:00:10Z"}}`,
+		// Completion of a transfer already completed
+		`<?xml version="1.0" encoding="UTF-8"?><transaction version="6.00" ID="A1"><action time="2022-01-01T00:00:10Z">completed</action><status resultCode="40"/></transaction>`,
+	} {
+		tracer.recordLogEntry(entry)
+	}
+	exporter.addLog(time.Unix(1, 0), "Agent SRC started.")
+	exporter.addLog(time.Unix(2, 0), fmt.Sprintf(utils.MFT_CONT_AGNT_ENDED_0090, "SRC", ""))
+	exporter.export()
+
+	lock.Lock()
+	traces, logs := requests[OTEL_TRACES_PATH], requests[OTEL_LOGS_PATH]
+	lock.Unlock()
+	spans := gjson.Get(traces, "resourceSpans.0.scopeSpans.0.spans")
+	if len(spans.Array()) != 1 {
+		t.Fatalf("Unexpected traces %s", traces)
+	}
+	span := spans.Array()[0]
+	if span.Get("traceId").String() != transferTraceID("A1") || span.Get("kind").Int() != otelSpanKindProducer ||
+		span.Get("startTimeUnixNano").String() != "1640995200000000000" ||
+		span.Get("endTimeUnixNano").String() != "1640995210000000000" || span.Get("status.code").Int() != otelStatusCodeOk {
+		t.Errorf("Unexpected span %s", span.Raw)
+	}
+	if span.Get(`attributes.#(key=="mqmft.transfer.result").value.stringValue`).String() != TRANSFER_RESULT_PARTIALLY_SUCCESSFUL ||
+		span.Get(`attributes.#(key=="mqmft.destination_agent").value.stringValue`).String() != "DEST" ||
+		span.Get(`attributes.#(key=="mqmft.transfer.bytes_sent").value.intValue`).String() != "10" {
+		t.Errorf("Unexpected attributes %s", span.Get("attributes").Raw)
+	}
+	events := span.Get("events").Array()
+	if len(events) != 2 || events[1].Get(`attributes.#(key=="mqmft.item.supplement").value.stringValue`).String() != "BFGIO0001E" {
+		t.Errorf("Unexpected events %s", span.Get("events").Raw)
+	}
+	resource := gjson.Get(traces, "resourceSpans.0.resource.attributes")
+	if resource.Get(`#(key=="service.name").value.stringValue`).String() != OTEL_DEFAULT_SERVICE_NAME ||
+		resource.Get(`#(key=="mqmft.coordination_qmgr").value.stringValue`).String() != "QM1" ||
+		resource.Get(`#(key=="deployment.environment").value.stringValue`).String() != "test" {
+		t.Errorf("Unexpected resource %s", resource.Raw)
+	}
+	records := gjson.Get(logs, "resourceLogs.0.scopeLogs.0.logRecords").Array()
+	if len(records) != 2 || records[0].Get("body.stringValue").String() != "Agent SRC started." ||
+		records[0].Get("severityNumber").Int() != 9 || records[0].Get("severityText").String() != utils.LOG_LEVEL_INFO {
+		t.Errorf("Unexpected logs %s", logs)
+	}
+	// The severity of a container message follows its ID
+	if len(records) == 2 && (records[1].Get("severityNumber").Int() != 17 || records[1].Get("severityText").String() != utils.LOG_LEVEL_ERROR) {
+		t.Errorf("Unexpected severity of %s", records[1].Raw)
+	}
+}
+
+// Spans of transfers that never complete are dropped, oldest first
+func TestTransferTracerDropsOldestSpan(t *testing.T) {
+	tracer := newTransferTracer("SRC", &otelExporter{})
+	for i := 0; i <= OTEL_MAX_ACTIVE_TRANSFERS; i++ {
+		tracer.recordTransfer(transferEvent{id: fmt.Sprintf("t%d", i), kind: transferEventProgress, bytesSent: -1})
+	}
+	if len(tracer.spans) != OTEL_MAX_ACTIVE_TRANSFERS {
+		t.Errorf("Expected %d spans, found %d", OTEL_MAX_ACTIVE_TRANSFERS, len(tracer.spans))
+	}
+	if _, exists := tracer.spans["t0"]; exists {
+		t.Error("Expected the span of the first transfer to be dropped")
+	}
+}
@@ -17,28 +17,47 @@ package main
 
 import (
 	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
 	"errors"
 	"fmt"
-	"math/rand"
+	"math/big"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
 )
 
-/**
-* Create keystore and include user specified certificates
- */
-func CreateKeyStore(keyStoreDir string, keyStoreFile string, certFilePath string, certStorePassword string) error {
-	// First verify if the certificate file exists
-	if !utils.DoesFileExist(certFilePath) {
-		errorMsg := "Certificate file " + certFilePath + " does not exist"
-		return errors.New(errorMsg)
-	}
+// Extensions of files holding certificates
+var certificateFileExtensions = []string{".crt", ".pem", ".cer"}
+
+// A certificate and the file it was read from
+type certificateFile struct {
+	cert *x509.Certificate
+	file string
+}
 
+// A certificate of a truststore and its alias
+type trustedCertificate struct {
+	certificateFile
+	alias string
+}
+
+// Certificates and private key read from a file
+type pemContents struct {
+	certs []certificateFile
+	// Private key in PKCS#8 form, and its public key
+	key       []byte
+	publicKey crypto.PublicKey
+}
+
+// Write a keystore, replacing the keystore of the same name if any
+func writeKeyStore(keyStoreDir string, keyStoreFile string, keyStoreData []byte, source string) error {
 	keyStorePathFinal := filepath.Join(keyStoreDir, keyStoreFile)
 	// Delete if keystore already exists
 	finfo, err := os.Lstat(keyStoreDir)
@@ -69,64 +88,330 @@ func CreateKeyStore(keyStoreDir string, keyStoreFile string, certFilePath string
 		return errCreateDataPath
 	}
 
-	// A new keystore will be created if it does not exist.
-	var outb, errb bytes.Buffer
-	cmdKeyToolPath, lookPathErr := exec.LookPath("keytool")
-	if lookPathErr == nil {
-		var cmdArgs []string
-		cmdArgs = append(cmdArgs, cmdKeyToolPath,
-			"-importcert",
-			"-trustcacerts",
-			"-keystore", keyStorePathFinal,
-			"-storetype", "pkcs12",
-			"-storepass", certStorePassword,
-			"-noprompt",
-			"-v",
-			"-alias", "agentstore",
-			"-file", certFilePath)
-
-		cmdKeyTool := &exec.Cmd{
-			Path: cmdKeyToolPath,
-			Args: cmdArgs,
-		}
-
-		cmdKeyTool.Stdout = &outb
-		cmdKeyTool.Stderr = &errb
-		// Execute the keytool command. Log an error an exit in case of any error.
-		if err := cmdKeyTool.Run(); err != nil {
-			errorMsg := fmt.Sprintf("Error occurred while creating keystore. Command Output: %v Error Output: %vError: %v",
-				outb.String(), errb.String(), err.Error())
-			return errors.New(errorMsg)
-		} else {
-			if logLevel >= LOG_LEVEL_VERBOSE {
-				utils.PrintLog(fmt.Sprintf("Created keystore. Output: %v %v", outb.String(), errb.String()))
+	if err := os.WriteFile(keyStorePathFinal, keyStoreData, 0600); err != nil {
+		return fmt.Errorf(utils.MFT_FAILED_WRITE_DATA, keyStorePathFinal, err)
+	}
+	if logLevel >= LOG_LEVEL_VERBOSE {
+		utils.PrintLog(fmt.Sprintf("Created keystore %v from %v", keyStorePathFinal, source))
+	}
+
+	// Change the permisions on the keystore
+	err = os.Chmod(keyStorePathFinal, 0600)
+	if err != nil {
+		errorMsg := fmt.Sprintf(utils.MFT_FAILED_PERMISSION_KEYSTORE, keyStorePathFinal, err)
+		return errors.New(errorMsg)
+	}
+	return nil
+}
+
+// Create truststore for the configuration being generated, and list the
+// certificates it trusts. In dry run mode the truststore is not written.
+func prepareTrustStore(keyStoreDir string, keyStoreFile string, settings tlsSettings, certStorePassword string, dryRun bool) error {
+	trustStoreData, trusted, err := buildTrustStore(settings, certStorePassword)
+	if err != nil {
+		return err
+	}
+	sources := strings.Join(settings.trustSources(), ", ")
+	if !dryRun {
+		if err := writeKeyStore(keyStoreDir, keyStoreFile, trustStoreData, sources); err != nil {
+			return err
+		}
+	}
+	utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLS_TRUSTSTORE_CREATED_0155, keyStoreFile, len(trusted), sources))
+	for _, c := range trusted {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLS_TRUSTED_CERTIFICATE_0156, c.alias, c.cert.Subject, c.cert.Issuer,
+			c.cert.NotAfter.UTC().Format(time.RFC3339), c.file))
+	}
+	return nil
+}
+
+// Build the contents of the truststore for the certificates to trust of a
+// queue manager. Returns the certificates of the truststore with their aliases.
+func buildTrustStore(settings tlsSettings, certStorePassword string) ([]byte, []trustedCertificate, error) {
+	certs, err := settings.readTrustedCertificates()
+	if err != nil {
+		return nil, nil, err
+	}
+	trusted := trustedCertificates(certs)
+	if len(trusted) == 0 {
+		return nil, nil, fmt.Errorf(utils.MFT_CONT_TLS_NO_TRUSTED_CERTIFICATES_0154, strings.Join(settings.trustSources(), ", "))
+	}
+	var entries pkcs12Entries
+	for _, c := range trusted {
+		entries.trusted = append(entries.trusted, aliasedCertificate{alias: c.alias, cert: c.cert})
+	}
+	data, err := encodePKCS12(entries, certStorePassword)
+	return data, trusted, err
+}
+
+// Encode a keystore holding a private key and the chain of its certificate,
+// which are searched for among the candidates. The alias of the key is the
+// label given, or is made from its certificate.
+func encodeKeyStore(source string, searched string, contents pemContents, candidates []certificateFile,
+	label string, certStorePassword string) ([]byte, error) {
+	leaf, err := keyCertificate(source, searched, contents, candidates)
+	if err != nil {
+		return nil, err
+	}
+	var entries pkcs12Entries
+	for _, c := range certificateChain(leaf, candidates) {
+		if err := checkCertificateValidity(c); err != nil {
+			return nil, err
+		}
+		entries.chain = append(entries.chain, c.cert)
+	}
+	entries.key = contents.key
+	entries.keyAlias = strings.Trim(label, TEXT_TRIM)
+	if len(entries.keyAlias) == 0 {
+		entries.keyAlias = certificateAlias(leaf.cert, make(map[string]bool))
+	}
+	return encodePKCS12(entries, certStorePassword)
+}
+
+// Returns the certificates of a truststore with unique aliases, in the order
+// given. A certificate given more than once is returned once. Certificates
+// that have expired or are not valid yet are left out with a warning, so that
+// an old certificate of a CA bundle does not prevent the others being trusted.
+func trustedCertificates(certs []certificateFile) []trustedCertificate {
+	var trusted []trustedCertificate
+	aliases := make(map[string]bool)
+	for i, c := range certs {
+		duplicate := false
+		for _, previous := range certs[:i] {
+			duplicate = duplicate || previous.cert.Equal(c.cert)
+		}
+		if duplicate {
+			continue
+		}
+		if err := checkCertificateValidity(c); err != nil {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLS_CERT_NOT_TRUSTED_0166, c.cert.Subject, c.file,
+				c.cert.NotBefore.UTC().Format(time.RFC3339), c.cert.NotAfter.UTC().Format(time.RFC3339)))
+			continue
+		}
+		trusted = append(trusted, trustedCertificate{certificateFile: c, alias: certificateAlias(c.cert, aliases)})
+	}
+	return trusted
+}
+
+// Read the certificates and private key of a file. Certificates of a file
+// that is not in PEM format are read as DER.
+func readPEMFile(file string) (pemContents, error) {
+	// #nosec G304
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return pemContents{}, fmt.Errorf(utils.MFT_FAILED_OPEN_FILE, file, err)
+	}
+	return parsePEMData(data, file)
+}
+
+// Parse the certificates and private key of data read from a source, in PEM
+// format or as DER certificates
+func parsePEMData(data []byte, file string) (pemContents, error) {
+	var contents pemContents
+	var err error
+	rest := data
+	var block *pem.Block
+	foundPEM := false
+	for {
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		foundPEM = true
+		switch block.Type {
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return contents, fmt.Errorf(utils.MFT_CONT_TLS_CERT_INVALID_0146, len(contents.certs)+1, file, err)
+			}
+			contents.certs = append(contents.certs, certificateFile{cert: cert, file: file})
+		case "PRIVATE KEY", "RSA PRIVATE KEY", "EC PRIVATE KEY", "ENCRYPTED PRIVATE KEY":
+			if len(contents.key) > 0 {
+				return contents, fmt.Errorf(utils.MFT_CONT_TLS_MULTIPLE_KEYS_0153, file)
+			}
+			if block.Type == "ENCRYPTED PRIVATE KEY" || strings.Contains(block.Headers["Proc-Type"], "ENCRYPTED") {
+				return contents, fmt.Errorf(utils.MFT_CONT_TLS_KEY_ENCRYPTED_0148, file)
+			}
+			if contents.key, contents.publicKey, err = parsePrivateKey(block); err != nil {
+				return contents, fmt.Errorf(utils.MFT_CONT_TLS_KEY_INVALID_0147, file, err)
 			}
 		}
+	}
+	if !foundPEM {
+		certs, err := x509.ParseCertificates(data)
+		if err != nil {
+			return contents, fmt.Errorf(utils.MFT_CONT_TLS_CERT_INVALID_0146, 1, file, err)
+		}
+		for _, cert := range certs {
+			contents.certs = append(contents.certs, certificateFile{cert: cert, file: file})
+		}
+	}
+	return contents, nil
+}
+
+// Parse a private key in PKCS#1, SEC 1 or PKCS#8 form. Returns the key in
+// PKCS#8 form and its public key.
+func parsePrivateKey(block *pem.Block) ([]byte, crypto.PublicKey, error) {
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("keys of type %T are not supported", key)
+	}
+	if block.Type != "PRIVATE KEY" {
+		encoded, err := x509.MarshalPKCS8PrivateKey(key)
+		return encoded, signer.Public(), err
+	}
+	return block.Bytes, signer.Public(), nil
+}
 
-		// Change the permisions on the keystore
-		err := os.Chmod(keyStorePathFinal, 0600)
+// Read the certificates of the certificate files of a directory, except a
+// file already read. Files that can not be read are ignored.
+func readCertificateDir(dir string, except string) []certificateFile {
+	var certs []certificateFile
+	for _, file := range getCertificateFiles(dir) {
+		if file == filepath.Join(dir, filepath.Base(except)) {
+			continue
+		}
+		contents, err := readPEMFile(file)
 		if err != nil {
-			errorMsg := fmt.Sprintf(utils.MFT_FAILED_PERMISSION_KEYSTORE, keyStorePathFinal, err)
-			return errors.New(errorMsg)
+			if logLevel >= LOG_LEVEL_VERBOSE {
+				utils.PrintLog(err.Error())
+			}
+			continue
+		}
+		certs = append(certs, contents.certs...)
+	}
+	return certs
+}
+
+// Returns the certificate files of a directory, sorted by name
+func getCertificateFiles(certDir string) []string {
+	var files []string
+	fileList, err := os.ReadDir(certDir)
+	if err != nil {
+		return nil
+	}
+	for _, fileInfo := range fileList {
+		if !fileInfo.IsDir() && hasCertificateExtension(fileInfo.Name()) {
+			files = append(files, filepath.Join(certDir, fileInfo.Name()))
+		}
+	}
+	return files
+}
+
+// Returns true if the name of a file has the extension of a certificate file
+func hasCertificateExtension(name string) bool {
+	for _, extension := range certificateFileExtensions {
+		if strings.EqualFold(filepath.Ext(name), extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the certificate of the private key of a file, among the candidates
+// searched for in the places given
+func keyCertificate(file string, searched string, contents pemContents, candidates []certificateFile) (certificateFile, error) {
+	publicKey, ok := contents.publicKey.(interface{ Equal(crypto.PublicKey) bool })
+	if ok {
+		for _, c := range candidates {
+			if publicKey.Equal(c.cert.PublicKey) {
+				return c, nil
+			}
+		}
+	}
+	if len(contents.certs) > 0 {
+		return certificateFile{}, fmt.Errorf(utils.MFT_CONT_TLS_KEY_MISMATCH_0149, file, contents.certs[0].cert.Subject)
+	}
+	return certificateFile{}, fmt.Errorf(utils.MFT_CONT_TLS_KEY_CERT_NOT_FOUND_0150, file, searched)
+}
+
+// Returns the chain of a certificate, starting with the certificate and
+// followed by each of its issuers found
+func certificateChain(leaf certificateFile, candidates []certificateFile) []certificateFile {
+	chain := []certificateFile{leaf}
+	current := leaf.cert
+	for len(chain) <= len(candidates) {
+		if bytes.Equal(current.RawIssuer, current.RawSubject) {
+			break
+		}
+		var issuer *certificateFile
+		for i, c := range candidates {
+			if bytes.Equal(c.cert.RawSubject, current.RawIssuer) && !c.cert.Equal(current) &&
+				current.CheckSignatureFrom(c.cert) == nil {
+				issuer = &candidates[i]
+				break
+			}
+		}
+		if issuer == nil {
+			break
 		}
+		chain = append(chain, *issuer)
+		current = issuer.cert
+	}
+	return chain
+}
+
+// Returns an error if a certificate has expired or is not valid yet
+func checkCertificateValidity(c certificateFile) error {
+	now := time.Now()
+	if now.After(c.cert.NotAfter) {
+		return fmt.Errorf(utils.MFT_CONT_TLS_CERT_EXPIRED_0151, c.cert.Subject, c.file, c.cert.NotAfter.UTC().Format(time.RFC3339))
+	}
+	if now.Before(c.cert.NotBefore) {
+		return fmt.Errorf(utils.MFT_CONT_TLS_CERT_NOT_YET_VALID_0152, c.cert.Subject, c.file, c.cert.NotBefore.UTC().Format(time.RFC3339))
 	}
 	return nil
 }
 
-// Generates a random 12 character password from the characters a-z, A-Z, 0-9
-func generateRandomPassword() string {
-	rand.Seed(time.Now().Unix())
-	validChars := "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
-	validcharArray := []byte(validChars)
-	password := ""
-	for i := 0; i < 12; i++ {
-		password = password + string(validcharArray[rand.Intn(len(validcharArray))])
+// Returns the alias of the entry of a certificate, made from its common name
+// and unique among the aliases already used
+func certificateAlias(cert *x509.Certificate, used map[string]bool) string {
+	name := cert.Subject.CommonName
+	if len(strings.Trim(name, TEXT_TRIM)) == 0 && len(cert.Subject.Organization) > 0 {
+		name = cert.Subject.Organization[0]
+	}
+	name = strings.ToLower(strings.Trim(name, TEXT_TRIM))
+	if len(name) == 0 {
+		name = "certificate"
 	}
+	alias := name
+	for i := 2; used[alias]; i++ {
+		alias = name + "-" + strconv.Itoa(i)
+	}
+	used[alias] = true
+	return alias
+}
 
-	return password
+// Generates a random 12 character password from the characters a-z, A-Z, 0-9,
+// chosen by the cryptographically secure random number generator of the system
+func generateRandomPassword() (string, error) {
+	validChars := "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	password := make([]byte, 12)
+	for i := range password {
+		index, err := rand.Int(rand.Reader, big.NewInt(int64(len(validChars))))
+		if err != nil {
+			return "", err
+		}
+		password[i] = validChars[index.Int64()]
+	}
+	return string(password), nil
 }
 
-// Search the specified directory for certificate files
+// Search the specified directory for the first file of a type, such as a
+// private key file
 func getKeyFile(keysDir string, fileType string) string {
 	fileList, err := os.ReadDir(keysDir)
 	if err == nil && len(fileList) > 0 {
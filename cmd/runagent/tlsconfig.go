@@ -0,0 +1,223 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
+	"github.com/tidwall/gjson"
+)
+
+/**
+* TLS settings of the connections to the coordination, command and agent queue
+* managers.
+*
+* Settings are read from the tls attribute of coordinationQMgr, commandQMgr and
+* each agent, so that agents sharing a configuration file can each have their
+* own. The cipherspec environment variables override the cipherSpec attribute.
+* TLS is enabled for a queue manager when it has a cipherspec.
+*
+* The truststore of a queue manager holds the certificates of the certificate
+* files of its directory, of its CA bundle and its inline certificates. Its
+* keystore holds the private key of the inline privateKey attribute, or else of
+* the .key file of its directory.
+ */
+
+// TLS settings of the connection to a queue manager
+type tlsSettings struct {
+	// Path of the tls attribute, used to name inline values in messages
+	path       string
+	cipherSpec string
+	peerName   string
+	// Directory of certificate and private key files
+	certificateDir string
+	// File of CA certificates to trust
+	caBundle string
+	// Certificates to trust, and private key with its certificate, in PEM
+	// format. The values may be base64 encoded.
+	certificates string
+	privateKey   string
+	// Alias of the private key in the keystore
+	certificateLabel string
+}
+
+// Returns the TLS settings of a queue manager from its tls attribute. The
+// cipherspec set in the environment variable overrides the attribute.
+func loadTLSSettings(tls gjson.Result, path string, cipherEnv string, defaultCertDir string) tlsSettings {
+	attribute := func(name string) string {
+		return strings.Trim(tls.Get(name).String(), TEXT_TRIM)
+	}
+	settings := tlsSettings{
+		path:             path,
+		cipherSpec:       attribute("cipherSpec"),
+		peerName:         attribute("peerName"),
+		certificateDir:   attribute("certificateDirectory"),
+		caBundle:         attribute("caBundle"),
+		certificates:     attribute("certificates"),
+		privateKey:       attribute("privateKey"),
+		certificateLabel: attribute("certificateLabel"),
+	}
+	if len(settings.certificateDir) == 0 {
+		settings.certificateDir = defaultCertDir
+	}
+	if cipherName, cipherSet := os.LookupEnv(cipherEnv); cipherSet && len(strings.Trim(cipherName, TEXT_TRIM)) > 0 {
+		settings.cipherSpec = strings.Trim(cipherName, TEXT_TRIM)
+	}
+	return settings
+}
+
+// Returns the TLS settings of the coordination queue manager
+func coordinationTLSSettings(allAgentConfig string) tlsSettings {
+	return loadTLSSettings(gjson.Get(allAgentConfig, "coordinationQMgr.tls"), "coordinationQMgr.tls",
+		MFT_COORD_QMGR_CIPHER, coordinationQMCertPath)
+}
+
+// Returns the TLS settings of the command queue manager
+func commandTLSSettings(allAgentConfig string) tlsSettings {
+	return loadTLSSettings(gjson.Get(allAgentConfig, "commandQMgr.tls"), "commandQMgr.tls",
+		MFT_CMD_QMGR_CIPHER, commandQMCertPath)
+}
+
+// Returns the TLS settings of the queue manager of an agent
+func agentTLSSettings(agentConfig string) tlsSettings {
+	path := fmt.Sprintf("agents[%s].tls", gjson.Get(agentConfig, "name").String())
+	return loadTLSSettings(gjson.Get(agentConfig, "tls"), path, MFT_AGENT_QMGR_CIPHER, agentQMCertPath)
+}
+
+// Returns true if TLS is enabled for the queue manager
+func (s tlsSettings) enabled() bool {
+	return len(s.cipherSpec) > 0
+}
+
+// Returns the places the certificates to trust are read from
+func (s tlsSettings) trustSources() []string {
+	sources := []string{s.certificateDir}
+	if len(s.caBundle) > 0 {
+		sources = append(sources, s.caBundle)
+	}
+	if len(s.certificates) > 0 {
+		sources = append(sources, s.path+".certificates")
+	}
+	return sources
+}
+
+// Returns true if there are certificates to trust
+func (s tlsSettings) hasTrustedCertificates() bool {
+	return len(getCertificateFiles(s.certificateDir)) > 0 || len(s.caBundle) > 0 || len(s.certificates) > 0
+}
+
+// Returns the certificates to trust, in the order of the certificate files of
+// the directory, the CA bundle and the inline certificates
+func (s tlsSettings) readTrustedCertificates() ([]certificateFile, error) {
+	var certs []certificateFile
+	for _, file := range getCertificateFiles(s.certificateDir) {
+		contents, err := readPEMFile(file)
+		if err != nil {
+			return certs, err
+		}
+		certs = append(certs, contents.certs...)
+	}
+	additional, err := s.readAdditionalCertificates()
+	return append(certs, additional...), err
+}
+
+// Returns the certificates of the CA bundle and the inline certificates
+func (s tlsSettings) readAdditionalCertificates() ([]certificateFile, error) {
+	var certs []certificateFile
+	if len(s.caBundle) > 0 {
+		contents, err := readPEMFile(s.caBundle)
+		if err != nil {
+			return certs, err
+		}
+		certs = append(certs, contents.certs...)
+	}
+	if len(s.certificates) > 0 {
+		contents, err := parseInlinePEM(s.certificates, s.path+".certificates")
+		if err != nil {
+			return certs, err
+		}
+		certs = append(certs, contents.certs...)
+	}
+	return certs, nil
+}
+
+// Returns the inline private key attribute, or the private key file of the
+// directory. Returns a blank string if there is no private key.
+func (s tlsSettings) keySource() string {
+	if len(s.privateKey) > 0 {
+		return s.path + ".privateKey"
+	}
+	return getKeyFile(s.certificateDir, ".key")
+}
+
+// Returns true if there is a private key for mutual TLS
+func (s tlsSettings) hasPrivateKey() bool {
+	return len(s.keySource()) > 0
+}
+
+// Returns the private key and the certificates supplied with it
+func (s tlsSettings) readPrivateKey() (pemContents, error) {
+	if len(s.privateKey) > 0 {
+		return parseInlinePEM(s.privateKey, s.keySource())
+	}
+	return readPEMFile(s.keySource())
+}
+
+// Parse the certificates and private key of an inline attribute, in PEM format
+// or base64 encoded
+func parseInlinePEM(value string, source string) (pemContents, error) {
+	if !strings.Contains(value, "-----BEGIN") {
+		if decoded, err := Base64Decode(value); err == nil {
+			value = decoded
+		}
+	}
+	return parsePEMData([]byte(value), source)
+}
+
+// Build the contents of the keystore holding the private key of a queue
+// manager and its certificate chain
+func buildClientKeyStore(settings tlsSettings, certStorePassword string) ([]byte, error) {
+	contents, err := settings.readPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	source := settings.keySource()
+	if len(contents.key) == 0 {
+		return nil, fmt.Errorf(utils.MFT_CONT_TLS_NO_PRIVATE_KEY_0164, source)
+	}
+	// The certificate of the key and its issuers may be supplied with the key
+	// or with the certificates to trust
+	candidates := append([]certificateFile(nil), contents.certs...)
+	candidates = append(candidates, readCertificateDir(settings.certificateDir, source)...)
+	additional, _ := settings.readAdditionalCertificates()
+	candidates = append(candidates, additional...)
+	return encodeKeyStore(source, strings.Join(settings.trustSources(), ", "), contents, candidates,
+		settings.certificateLabel, certStorePassword)
+}
+
+// Create keystore holding the private key of a queue manager for the
+// configuration being generated. In dry run mode the keystore is built to
+// verify the private key, but is not written.
+func prepareClientKeyStore(keyStoreDir string, keyStoreFile string, settings tlsSettings, certStorePassword string, dryRun bool) error {
+	keyStoreData, err := buildClientKeyStore(settings, certStorePassword)
+	if err != nil || dryRun {
+		return err
+	}
+	return writeKeyStore(keyStoreDir, keyStoreFile, keyStoreData, settings.keySource())
+}
@@ -0,0 +1,105 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tidwall/sjson"
+)
+
+// The cipherspec environment variable overrides the configuration
+func TestLoadTLSSettings(t *testing.T) {
+	agentConfig := `{"name":"SRC","tls":{"cipherSpec":"TLS_AES_128_GCM_SHA256","peerName":"CN=QM1, O=IBM",
+		"caBundle":"/etc/certs/ca.pem","certificateLabel":"src"}}`
+	setTestEnv(t, MFT_AGENT_QMGR_CIPHER, "")
+	settings := agentTLSSettings(agentConfig)
+	if !settings.enabled() || settings.cipherSpec != "TLS_AES_128_GCM_SHA256" || settings.peerName != "CN=QM1, O=IBM" ||
+		settings.caBundle != "/etc/certs/ca.pem" || settings.certificateLabel != "src" || settings.path != "agents[SRC].tls" {
+		t.Errorf("Unexpected settings %+v", settings)
+	}
+	if settings.certificateDir != agentQMCertPath {
+		t.Errorf("Expected the default certificate directory, got %s", settings.certificateDir)
+	}
+
+	setTestEnv(t, MFT_AGENT_QMGR_CIPHER, "ECDHE_RSA_AES_256_CBC_SHA384")
+	if settings := agentTLSSettings(agentConfig); settings.cipherSpec != "ECDHE_RSA_AES_256_CBC_SHA384" {
+		t.Errorf("Expected the cipherspec of the environment variable, got %s", settings.cipherSpec)
+	}
+
+	setTestEnv(t, MFT_COORD_QMGR_CIPHER, "")
+	if coordinationTLSSettings(`{"coordinationQMgr":{"name":"QM1"}}`).enabled() {
+		t.Error("Expected TLS to be disabled without a cipherspec")
+	}
+}
+
+// Keystores are built from the inline certificates and private key
+func TestBuildClientKeyStoreInline(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := newTestCertificate(t, "Test CA", time.Now().Add(24*time.Hour), nil, nil)
+	client, clientKey := newTestCertificate(t, "MFT Client", time.Now().Add(24*time.Hour), ca, caKey)
+	qm, _ := newTestCertificate(t, "QM1", time.Now().Add(24*time.Hour), ca, caKey)
+	writePEMFile(t, filepath.Join(dir, "ca.pem"), certificateBlock(ca))
+
+	tls, _ := sjson.Set(`{}`, "caBundle", filepath.Join(dir, "ca.pem"))
+	tls, _ = sjson.Set(tls, "certificates", string(pem.EncodeToMemory(certificateBlock(qm))))
+	// The private key may be base64 encoded, and its certificate supplied with it
+	keyPEM := append(pem.EncodeToMemory(ecKeyBlock(t, clientKey)), pem.EncodeToMemory(certificateBlock(client))...)
+	tls, _ = sjson.Set(tls, "privateKey", base64.StdEncoding.EncodeToString(keyPEM))
+	tls, _ = sjson.Set(tls, "certificateLabel", "mft-src")
+	agentConfig, _ := sjson.SetRaw(`{"name":"SRC"}`, "tls", tls)
+	settings := agentTLSSettings(agentConfig)
+	settings.certificateDir = t.TempDir()
+
+	if !settings.hasTrustedCertificates() || !settings.hasPrivateKey() {
+		t.Fatalf("Expected certificates and a private key in %+v", settings)
+	}
+	trustStore, trusted, err := buildTrustStore(settings, "passw0rd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trusted) != 2 || !trusted[0].cert.Equal(ca) || !trusted[1].cert.Equal(qm) {
+		t.Errorf("Unexpected trusted certificates %v", trusted)
+	}
+	if certBags, _ := decodeTestPKCS12(t, trustStore, "passw0rd"); len(certBags) != 2 {
+		t.Errorf("Expected 2 certificates in the truststore, got %d", len(certBags))
+	}
+
+	keyStore, err := buildClientKeyStore(settings, "passw0rd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	certBags, keys := decodeTestPKCS12(t, keyStore, "passw0rd")
+	if len(certBags) != 2 || len(keys) != 1 {
+		t.Fatalf("Expected 2 certificates and 1 key, got %d and %d", len(certBags), len(keys))
+	}
+	leaf, alias := decodeTestCertBag(t, certBags[0])
+	issuer, _ := decodeTestCertBag(t, certBags[1])
+	if !leaf.Equal(client) || alias != "mft-src" || !issuer.Equal(ca) {
+		t.Errorf("Unexpected chain %v (%s), %v", leaf.Subject, alias, issuer.Subject)
+	}
+
+	// An inline value without a private key
+	settings.privateKey = string(pem.EncodeToMemory(certificateBlock(client)))
+	if _, err := buildClientKeyStore(settings, "passw0rd"); err == nil || !strings.HasPrefix(err.Error(), "MFTC0164E") {
+		t.Errorf("Expected MFTC0164E, got %v", err)
+	}
+}
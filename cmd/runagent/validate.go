@@ -0,0 +1,258 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
+	flag "github.com/spf13/pflag"
+	"github.com/tidwall/gjson"
+)
+
+/**
+* Offline validation of agent configuration.
+*
+* runagent validate --config <file> --agent <name> --output <dir>
+*
+* Runs all validation done at container start and renders the configuration files
+* that would be generated for the agent into the output directory, without running
+* any MFT command or writing keystores. Secrets are redacted from the rendered files.
+ */
+
+// Name of the subcommand
+const SUBCOMMAND_VALIDATE = "validate"
+
+// Name of the report written to output directory
+const VALIDATION_REPORT_FILE = "validation-report.json"
+
+// A problem found in the configuration
+type ConfigProblem struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Result of validating a configuration
+type ValidationReport struct {
+	ConfigFile      string          `json:"configFile"`
+	AgentName       string          `json:"agentName"`
+	SchemaVersion   string          `json:"schemaVersion"`
+	OutputDirectory string          `json:"outputDirectory"`
+	Valid           bool            `json:"valid"`
+	Files           []string        `json:"files"`
+	Problems        []ConfigProblem `json:"problems"`
+}
+
+// Entry point for the validate subcommand. Returns the exit code of the process.
+func runValidate(args []string) int {
+	var configFile string
+	var agentName string
+	var outputDir string
+	var bfgDataPath string
+
+	flags := flag.NewFlagSet(SUBCOMMAND_VALIDATE, flag.ContinueOnError)
+	flags.StringVar(&configFile, "config", os.Getenv(MFT_AGENT_CONFIG_FILE), "Agent configuration file")
+	flags.StringVar(&agentName, "agent", os.Getenv(MFT_AGENT_NAME), "Name of the agent to validate")
+	flags.StringVar(&outputDir, "output", "", "Directory where configuration files are rendered")
+	flags.StringVar(&bfgDataPath, "bfgdata", utils.FIXED_BFG_DATAPATH, "BFG_DATA path referenced in rendered files")
+	if err := flags.Parse(args); err != nil {
+		return MFT_CONT_ERR_CODE_26
+	}
+
+	configFile = strings.Trim(configFile, TEXT_TRIM)
+	agentName = strings.Trim(agentName, TEXT_TRIM)
+	outputDir = strings.Trim(outputDir, TEXT_TRIM)
+	if configFile == TEXT_BLANK || agentName == TEXT_BLANK || outputDir == TEXT_BLANK {
+		utils.PrintLog(utils.MFT_CONT_VALIDATE_USAGE_0082)
+		flags.PrintDefaults()
+		return MFT_CONT_ERR_CODE_26
+	}
+
+	report := ValidateAndRender(configFile, agentName, outputDir, bfgDataPath)
+	for _, problem := range report.Problems {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_CFG_SCHEMA_VIOLATION_0080, problem.Path+": "+problem.Message))
+	}
+
+	reportData, _ := json.MarshalIndent(report, "", "  ")
+	reportFile := filepath.Join(outputDir, VALIDATION_REPORT_FILE)
+	if err := utils.WriteData(reportFile, string(reportData)+"\n"); err != nil {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_FAILED_WRITE_DATA, reportFile, err))
+	}
+
+	if !report.Valid {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_VALIDATE_FAILED_0083, configFile, agentName, len(report.Problems), reportFile))
+		return MFT_CONT_ERR_CODE_25
+	}
+	utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_VALIDATE_PASSED_0084, configFile, agentName, outputDir))
+	return MFT_CONT_SUCCESS_CODE_0
+}
+
+// Validate configuration for the given agent and render the configuration files
+// into output directory.
+func ValidateAndRender(configFile string, agentName string, outputDir string, bfgDataPath string) ValidationReport {
+	report := ValidationReport{
+		ConfigFile:      configFile,
+		AgentName:       agentName,
+		SchemaVersion:   AGENT_CONFIG_SCHEMA_VERSION,
+		OutputDirectory: outputDir,
+		Files:           make([]string, 0),
+		Problems:        make([]ConfigProblem, 0),
+	}
+
+	if err := utils.CreatePath(outputDir); err != nil {
+		report.Problems = append(report.Problems, ConfigProblem{Path: outputDir, Message: err.Error()})
+		return report
+	}
+
+	jsonAgentConfigFilePath = configFile
+	allAgentConfig, err := utils.ReadConfigurationDataFromFile(configFile)
+	if err != nil {
+		report.Problems = append(report.Problems, ConfigProblem{Path: "$", Message: err.Error()})
+		return report
+	}
+
+	// References that cannot be resolved are reported, but the rest of the
+	// configuration is still checked with the references left in place.
+	resolvedConfig, err := utils.ResolveConfigurationReferences(allAgentConfig)
+	var unresolved utils.UnresolvedReferencesError
+	if errors.As(err, &unresolved) {
+		for _, reference := range unresolved {
+			report.Problems = append(report.Problems, ConfigProblem{Path: reference.Path, Message: reference.Message})
+		}
+	} else if err == nil {
+		allAgentConfig = resolvedConfig
+	}
+
+	for _, violation := range ValidateConfigurationSchema(allAgentConfig) {
+		report.Problems = append(report.Problems, ConfigProblem{Path: violation.Path, Message: violation.Message})
+	}
+	// Nothing more can be checked if the document could not be parsed.
+	if !gjson.Valid(allAgentConfig) {
+		return report
+	}
+
+	if err := ValidateCoordinationAttributes(allAgentConfig); err != nil {
+		report.Problems = append(report.Problems, ConfigProblem{Path: "$.coordinationQMgr", Message: err.Error()})
+	}
+	if err := ValidateCommandAttributes(allAgentConfig); err != nil {
+		report.Problems = append(report.Problems, ConfigProblem{Path: "$.commandQMgr", Message: err.Error()})
+	}
+
+	agentIndex := -1
+	agentsJson := gjson.Get(allAgentConfig, "agents").Array()
+	for i := 0; i < len(agentsJson); i++ {
+		if strings.EqualFold(strings.Trim(gjson.Get(agentsJson[i].String(), "name").String(), TEXT_TRIM), agentName) {
+			agentIndex = i
+			break
+		}
+	}
+	if agentIndex < 0 {
+		report.Problems = append(report.Problems, ConfigProblem{Path: "$.agents",
+			Message: fmt.Sprintf(utils.MFT_CONT_CFG_AGENT_CONFIG_MISSING_0019, agentName, configFile)})
+		return report
+	}
+
+	agentPath := fmt.Sprintf("$.agents[%d]", agentIndex)
+	singleAgentConfig := agentsJson[agentIndex].String()
+	if err := ValidateAgentAttributes(singleAgentConfig); err != nil {
+		report.Problems = append(report.Problems, ConfigProblem{Path: agentPath, Message: err.Error()})
+	}
+
+	standardAgent := !strings.EqualFold(strings.Trim(gjson.Get(singleAgentConfig, "type").String(), TEXT_TRIM), AGENT_TYPE_BRIDGE)
+	if !standardAgent {
+		report.Problems = append(report.Problems, validateProtocolServers(singleAgentConfig, agentPath)...)
+	}
+
+	// Render the configuration files even if there are problems so that they can be reviewed.
+	coordinationQMgr := gjson.Get(allAgentConfig, "coordinationQMgr.name").String()
+	agentConfigDir := filepath.Join(outputDir, MFT_CONFIG_PATH_SUFFIX, coordinationQMgr, MFT_AGENTS_SLASH, gjson.Get(singleAgentConfig, "name").String())
+	if err := utils.CreatePath(agentConfigDir); err != nil {
+		report.Problems = append(report.Problems, ConfigProblem{Path: agentConfigDir, Message: err.Error()})
+		return report
+	}
+
+	coordinationConfigDir := filepath.Join(outputDir, MFT_CONFIG_PATH_SUFFIX, coordinationQMgr)
+	// The properties files are created by MFT commands at runtime, so create empty
+	// ones here to hold the additions.
+	for _, propertiesFile := range []string{
+		filepath.Join(coordinationConfigDir, MFT_CORD_PROPS_SLASH),
+		filepath.Join(coordinationConfigDir, MFT_CMD_PROPS_SLASH),
+		filepath.Join(agentConfigDir, MFT_AGENT_PROPS_SLASH)} {
+		if err := utils.WriteData(propertiesFile, TEXT_BLANK); err != nil {
+			report.Problems = append(report.Problems, ConfigProblem{Path: propertiesFile, Message: err.Error()})
+			return report
+		}
+	}
+
+	if !ConfigureCoordination(allAgentConfig, bfgDataPath, outputDir, true) {
+		report.Problems = append(report.Problems, ConfigProblem{Path: "$.coordinationQMgr", Message: utils.MFT_CONT_CORD_CFG_FAILED_0029})
+	}
+	if !ConfigureCommands(allAgentConfig, bfgDataPath, outputDir, true) {
+		report.Problems = append(report.Problems, ConfigProblem{Path: "$.commandQMgr", Message: utils.MFT_CONT_CMD_CFG_FAILED_0030})
+	}
+	if !ConfigureAgent(singleAgentConfig, bfgDataPath, outputDir, coordinationQMgr, standardAgent, true) {
+		report.Problems = append(report.Problems, ConfigProblem{Path: agentPath,
+			Message: fmt.Sprintf(utils.MFT_CONT_AGNT_CFG_FAILED_0031, agentName)})
+	}
+
+	filepath.Walk(outputDir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && info.Name() != VALIDATION_REPORT_FILE {
+			relPath, _ := filepath.Rel(outputDir, path)
+			report.Files = append(report.Files, relPath)
+		}
+		return nil
+	})
+
+	report.Valid = len(report.Problems) == 0
+	return report
+}
+
+// Check the protocol servers of a bridge agent have enough information to create the agent
+func validateProtocolServers(agentConfig string, agentPath string) []ConfigProblem {
+	problems := make([]ConfigProblem, 0)
+	protocolServers := gjson.Get(agentConfig, "protocolServers").Array()
+	if len(protocolServers) == 0 {
+		problems = append(problems, ConfigProblem{Path: agentPath + ".protocolServers", Message: utils.MFT_CONT_BRIDGE_NOT_ENOUGH_INFO})
+		return problems
+	}
+
+	// The first server is used for creating the agent.
+	if _, ok := updateBridgeParameters(protocolServers[0].String(), []string{}); !ok {
+		problems = append(problems, ConfigProblem{Path: agentPath + ".protocolServers[0]", Message: utils.MFT_CONT_BRIDGE_NOT_ENOUGH_INFO})
+	}
+
+	// Default server must be one of the servers defined
+	if gjson.Get(agentConfig, "defaultServer").Exists() {
+		defaultServer := gjson.Get(agentConfig, "defaultServer").String()
+		found := false
+		for _, server := range protocolServers {
+			if gjson.Get(server.String(), "name").String() == defaultServer {
+				found = true
+				break
+			}
+		}
+		if !found {
+			problems = append(problems, ConfigProblem{Path: agentPath + ".defaultServer",
+				Message: fmt.Sprintf(utils.MFT_CONT_BRIDGE_DEFAULT_SERVER_UNKNOWN_0085, defaultServer)})
+		}
+	}
+	return problems
+}
@@ -0,0 +1,162 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const validateTestConfig = `{
+	"coordinationQMgr":{"name":"COORDQM","host":"localhost","port":1414,
+		"qmgrCredentials":{"mqUserId":"mftuser","mqPassword":"cGFzc3cwcmQ="},
+		"additionalProperties":{"coordinationQMgrStandby":"standby(1414)"}},
+	"commandQMgr":{"name":"CMDQM","host":"localhost","port":1414},
+	"agents":[{
+		"name":"SRC",
+		"type":"STANDARD",
+		"qmgrName":"AGENTQM",
+		"qmgrHost":"localhost",
+		"qmgrCredentials":{"mqUserId":"mftuser","mqPassword":"cGFzc3cwcmQ="},
+		"additionalProperties":{"enableQueueInputOutput":"true"}
+	},{
+		"name":"BRIDGE",
+		"type":"BRIDGE",
+		"qmgrName":"AGENTQM",
+		"qmgrHost":"localhost",
+		"defaultServer":"unknownServer",
+		"protocolServers":[{"name":"ftp","type":"FTP","host":"ftp.example.com","platform":"UNIX","fileEncoding":"UTF8"}]
+	}]
+}`
+
+func writeValidateTestConfig(t *testing.T, configData string) string {
+	configFile := filepath.Join(t.TempDir(), "agentconfig.json")
+	if err := ioutil.WriteFile(configFile, []byte(configData), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return configFile
+}
+
+// Valid configuration is rendered to output directory with secrets redacted
+func TestValidateAndRenderStandardAgent(t *testing.T) {
+	configFile := writeValidateTestConfig(t, validateTestConfig)
+	outputDir := t.TempDir()
+
+	report := ValidateAndRender(configFile, "SRC", outputDir, "/mnt/mftdata")
+	if !report.Valid {
+		t.Fatalf("Expected configuration to be valid, problems: %v", report.Problems)
+	}
+
+	coordProps, err := ioutil.ReadFile(filepath.Join(outputDir, "mqft/config/COORDQM/coordination.properties"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(coordProps), "coordinationQMgrStandby=standby(1414)") ||
+		!strings.Contains(string(coordProps), "coordinationQMgrAuthenticationCredentialsFile=/mnt/mftdata/mqft/config/COORDQM/coordcredentials.xml") {
+		t.Errorf("Unexpected coordination properties: %s", coordProps)
+	}
+
+	agentProps, err := ioutil.ReadFile(filepath.Join(outputDir, "mqft/config/COORDQM/agents/SRC/agent.properties"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(agentProps), "enableQueueInputOutput=true") {
+		t.Errorf("Unexpected agent properties: %s", agentProps)
+	}
+
+	for _, credFile := range []string{"mqft/config/COORDQM/coordcredentials.xml", "mqft/config/COORDQM/agents/SRC/agentcredentials.xml"} {
+		credentials, err := ioutil.ReadFile(filepath.Join(outputDir, credFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(credentials), "cGFzc3cwcmQ=") || !strings.Contains(string(credentials), TEXT_REDACTED) {
+			t.Errorf("Password not redacted in %s: %s", credFile, credentials)
+		}
+	}
+
+	if !fileInReport(report, "mqft/config/COORDQM/agents/SRC/UserSandboxes.xml") {
+		t.Errorf("UserSandboxes.xml not rendered: %v", report.Files)
+	}
+}
+
+// Problems in bridge agent configuration are reported with JSON path
+func TestValidateAndRenderBridgeAgentProblems(t *testing.T) {
+	configFile := writeValidateTestConfig(t, validateTestConfig)
+	outputDir := t.TempDir()
+
+	report := ValidateAndRender(configFile, "BRIDGE", outputDir, "/mnt/mftdata")
+	if report.Valid {
+		t.Fatal("Expected configuration to be invalid")
+	}
+
+	// FTP servers need timeZone and locale, and the default server does not exist.
+	expectedPaths := []string{"$.agents[1].protocolServers[0]", "$.agents[1].defaultServer"}
+	for _, path := range expectedPaths {
+		found := false
+		for _, problem := range report.Problems {
+			if problem.Path == path {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Problem for %s not reported: %v", path, report.Problems)
+		}
+	}
+
+	if !fileInReport(report, "mqft/config/COORDQM/agents/BRIDGE/ProtocolBridgeProperties.xml") {
+		t.Errorf("ProtocolBridgeProperties.xml not rendered: %v", report.Files)
+	}
+}
+
+// Unknown agent and schema violations are reported
+func TestValidateAndRenderUnknownAgent(t *testing.T) {
+	configFile := writeValidateTestConfig(t, strings.Replace(validateTestConfig, "commandQMgr", "commandsQMgr", 1))
+
+	report := ValidateAndRender(configFile, "DEST", t.TempDir(), "/mnt/mftdata")
+	if report.Valid || len(report.Problems) < 3 {
+		t.Errorf("Expected schema, command queue manager and agent problems: %v", report.Problems)
+	}
+	if report.Problems[len(report.Problems)-1].Path != "$.agents" {
+		t.Errorf("Missing agent not reported: %v", report.Problems)
+	}
+}
+
+// Unresolved references are reported and the rest of the configuration is still checked
+func TestValidateAndRenderUnresolvedReferences(t *testing.T) {
+	configFile := writeValidateTestConfig(t, strings.Replace(validateTestConfig,
+		`"qmgrHost":"localhost",
+		"qmgrCredentials"`, `"qmgrHost":"${env:TEST_MFT_NOT_SET}",
+		"qmgrCredentials"`, 1))
+
+	report := ValidateAndRender(configFile, "SRC", t.TempDir(), "/mnt/mftdata")
+	if report.Valid || len(report.Problems) != 1 || report.Problems[0].Path != "$.agents[0].qmgrHost" {
+		t.Errorf("Expected unresolved reference to be reported: %v", report.Problems)
+	}
+	if !fileInReport(report, "mqft/config/COORDQM/agents/SRC/agent.properties") {
+		t.Errorf("Agent configuration not rendered: %v", report.Files)
+	}
+}
+
+func fileInReport(report ValidationReport, fileName string) bool {
+	for _, file := range report.Files {
+		if file == fileName {
+			return true
+		}
+	}
+	return false
+}
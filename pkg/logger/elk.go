@@ -0,0 +1,180 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
+	"github.com/tidwall/gjson"
+)
+
+/*
+ * Publication of transfer logs to Elasticsearch using the bulk API.
+ *
+ * A batch of entries is indexed by one request. Elasticsearch reports the
+ * result of each entry separately, so entries it rejects are dropped while
+ * those it could not index for the time being are retried.
+ */
+
+// Path of the bulk API
+const ELK_BULK_PATH = "/_bulk"
+
+// Index of the entries unless configured
+const ELK_DEFAULT_INDEX = "ibmmqmft"
+
+// Maximum length of a bulk response read. The response describes every entry.
+const elkMaxResponseLength = 4 * 1024 * 1024
+
+// ElkOptions controls how transfer log entries are indexed by Elasticsearch
+type ElkOptions struct {
+	// Pattern of the name of the index of an entry. {agent} is replaced by
+	// the name of the logger, and {yyyy}, {MM}, {dd} and {HH} by the year,
+	// month, day and hour of the entry in UTC. For example
+	// "ibmmqmft-{agent}-{yyyy}.{MM}.{dd}".
+	Index string
+	// Credentials for basic authentication
+	Username string
+	Password string
+	// Encoded API key, used instead of basic authentication if set
+	APIKey string
+	// File of the CA certificates trusted to verify the server. The system's
+	// certificates are trusted if empty.
+	CACertFile string
+}
+
+// Document indexed for a transfer log entry
+type elkDocument struct {
+	Timestamp   string         `json:"@timestamp"`
+	TransferLog elkTransferLog `json:"transferLog"`
+}
+
+type elkTransferLog struct {
+	HostName  string          `json:"hostName"`
+	AgentName string          `json:"agentName"`
+	Level     string          `json:"level"`
+	MetaData  json.RawMessage `json:"metaData"`
+}
+
+// Returns the name of the index of an entry logged at a time
+func (l *Logger) elkIndex(eventTime time.Time) string {
+	pattern := l.serverOptions.Elk.Index
+	if len(pattern) == 0 {
+		pattern = ELK_DEFAULT_INDEX
+	}
+	eventTime = eventTime.UTC()
+	index := strings.NewReplacer(
+		"{agent}", l.serverName,
+		"{yyyy}", eventTime.Format("2006"),
+		"{MM}", eventTime.Format("01"),
+		"{dd}", eventTime.Format("02"),
+		"{HH}", eventTime.Format("15"),
+	).Replace(pattern)
+	// Names of indices must be lower case.
+	return strings.ToLower(index)
+}
+
+// Build a bulk request that indexes a batch of entries. Returns the index of
+// each entry as well.
+func (l *Logger) elkRequest(batch []string) (*http.Request, []string, error) {
+	var payload bytes.Buffer
+	encoder := json.NewEncoder(&payload)
+	indices := make([]string, len(batch))
+	for i, msg := range batch {
+		eventTime := getEventTime(msg)
+		indices[i] = l.elkIndex(eventTime)
+		action := map[string]interface{}{"index": map[string]string{"_index": indices[i]}}
+		if err := encoder.Encode(action); err != nil {
+			return nil, nil, err
+		}
+		err := encoder.Encode(elkDocument{
+			Timestamp: eventTime.UTC().Format(time.RFC3339Nano),
+			TransferLog: elkTransferLog{
+				HostName:  l.host,
+				AgentName: l.serverName,
+				Level:     getLogLevel(msg),
+				MetaData:  json.RawMessage(msg),
+			},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	request, err := http.NewRequest("POST", strings.TrimRight(l.logUrl, "/")+ELK_BULK_PATH, &payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-ndjson")
+	options := l.serverOptions.Elk
+	if len(options.APIKey) > 0 {
+		request.Header.Set("Authorization", "ApiKey "+options.APIKey)
+	} else if len(options.Username) > 0 {
+		request.SetBasicAuth(options.Username, options.Password)
+	}
+	return request, indices, nil
+}
+
+// Index a batch of entries in Elasticsearch. Entries rejected by the server
+// are reported and dropped. Returns the entries that may be indexed by
+// trying again.
+func (l *Logger) sendElk(batch []string) ([]string, error) {
+	request, indices, err := l.elkRequest(batch)
+	body, err := l.postForResponse(request, err, elkMaxResponseLength)
+	if err != nil {
+		return batch, err
+	}
+	response := gjson.ParseBytes(body)
+	if !response.Get("errors").Bool() {
+		return nil, nil
+	}
+
+	// Items of the response are in the order of the entries of the request.
+	var unpublished []string
+	var cause string
+	for i, item := range response.Get("items").Array() {
+		if i >= len(batch) {
+			break
+		}
+		result := item.Get("index")
+		status := int(result.Get("status").Int())
+		if status >= 200 && status < 300 {
+			continue
+		}
+		reason := result.Get("error.type").String() + ": " + result.Get("error.reason").String()
+		if isRetryableStatus(status) {
+			unpublished = append(unpublished, batch[i])
+			cause = reason
+			continue
+		}
+		atomic.AddUint64(&droppedEntries, 1)
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLOG_ELK_REJECTED_0121,
+			gjson.Get(batch[i], "transferId").String(), indices[i], status, reason))
+	}
+	if len(unpublished) > 0 {
+		return unpublished, &publishError{
+			err:       fmt.Errorf("%d of %d entries were not indexed: %s", len(unpublished), len(batch), cause),
+			retryable: true,
+		}
+	}
+	return nil, nil
+}
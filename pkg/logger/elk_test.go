@@ -0,0 +1,111 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Entries are indexed with the bulk API. Entries rejected by the server are
+// dropped, and those it could not index are sent again.
+func TestElkBulkPublisher(t *testing.T) {
+	var lock sync.Mutex
+	var paths, authorizations []string
+	var requests [][]map[string]interface{}
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var lines []map[string]interface{}
+		decoder := json.NewDecoder(r.Body)
+		for {
+			var line map[string]interface{}
+			if err := decoder.Decode(&line); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Errorf("Invalid bulk request: %v", err)
+				break
+			}
+			lines = append(lines, line)
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		paths = append(paths, r.URL.Path)
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		requests = append(requests, lines)
+		if len(requests) == 1 {
+			w.Write([]byte(`{"errors":true,"items":[` +
+				`{"index":{"status":201}},` +
+				`{"index":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse"}}},` +
+				`{"index":{"status":429,"error":{"type":"es_rejected_execution_exception","reason":"queue full"}}}]}`))
+		} else {
+			w.Write([]byte(`{"errors":false,"items":[{"index":{"status":201}}]}`))
+		}
+	}))
+	defer httpServer.Close()
+	l, err := NewLogger(new(bytes.Buffer), false, false, "SRC", httpServer.URL+"/", "", LOG_SERVER_TYPE_ELK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.SetServerOptions(ServerOptions{Elk: ElkOptions{Index: "mft-{agent}-{yyyy}.{MM}.{dd}", APIKey: "a2V5"}})
+	l.StartPublisher(testPublisherOptions())
+	droppedBefore := DroppedEntries()
+
+	l.PushToLogToServer(`{"transferId":"a1","eventDescription":"Transfer started","time":"2022-01-02T03:04:05.000Z"}`)
+	l.PushToLogToServer(`{"transferId":"a2","eventDescription":"Transfer started","time":"2022-01-02T03:04:05.000Z"}`)
+	l.PushToLogToServer(`{"transferId":"a3","eventDescription":"Transfer started","time":"2022-01-02T03:04:05.000Z"}`)
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		lock.Lock()
+		count := len(requests)
+		lock.Unlock()
+		if count >= 2 {
+			break
+		}
+	}
+	l.StopPublisher(5 * time.Second)
+
+	lock.Lock()
+	defer lock.Unlock()
+	if len(requests) != 2 || len(requests[0]) != 6 || len(requests[1]) != 2 {
+		t.Fatalf("Unexpected requests %v", requests)
+	}
+	if paths[0] != ELK_BULK_PATH || authorizations[0] != "ApiKey a2V5" {
+		t.Errorf("Unexpected request to %s with authorization %q", paths[0], authorizations[0])
+	}
+	action, ok := requests[0][0]["index"].(map[string]interface{})
+	if !ok || action["_index"] != "mft-src-2022.01.02" {
+		t.Errorf("Unexpected action %v", requests[0][0])
+	}
+	document := requests[0][1]
+	if document["@timestamp"] != "2022-01-02T03:04:05Z" {
+		t.Errorf("Unexpected document %v", document)
+	}
+	if transferLog, ok := document["transferLog"].(map[string]interface{}); !ok || transferLog["agentName"] != "SRC" {
+		t.Errorf("Unexpected document %v", document)
+	}
+	// Only the entry the server was too busy to index is sent again.
+	retried, _ := requests[1][1]["transferLog"].(map[string]interface{})
+	if metaData, _ := retried["metaData"].(map[string]interface{}); metaData["transferId"] != "a3" {
+		t.Errorf("Unexpected retried document %v", requests[1][1])
+	}
+	if DroppedEntries() != droppedBefore+1 {
+		t.Errorf("Expected one dropped entry, found %d", DroppedEntries()-droppedBefore)
+	}
+}
@@ -18,11 +18,8 @@ limitations under the License.
 package logger
 
 import (
-	"bytes"
 	"fmt"
 	"io"
-	"io/ioutil"
-	"net/http"
 	"os"
 	"os/user"
 	"strconv"
@@ -36,25 +33,35 @@ import (
 
 // timestampFormat matches the format used by MQ messages (includes milliseconds)
 const timestampFormat string = "2006-01-02T15:04:05.000Z07:00"
-const debugLevel string = "DEBUG"
-const infoLevel string = "INFO"
-const errorLevel string = "ERROR"
+const debugLevel string = utils.LOG_LEVEL_DEBUG
+const infoLevel string = utils.LOG_LEVEL_INFO
+const errorLevel string = utils.LOG_LEVEL_ERROR
 
 // A Logger is used to log messages to stdout
 type Logger struct {
-	mutex           sync.Mutex
-	writer          io.Writer
-	debug           bool
-	json            bool
-	processName     string
-	pid             string
-	serverName      string
-	host            string
-	userName        string
-	logUrl          string
-	logKey          string
-	logServerType   int16
-	logPubsDisabled bool
+	mutex         sync.Mutex
+	writer        io.Writer
+	debug         bool
+	json          bool
+	processName   string
+	pid           string
+	serverName    string
+	host          string
+	userName      string
+	logUrl        string
+	logKey        string
+	logServerType int16
+	serverOptions ServerOptions
+	publisher     *publisher
+	syslog        syslogConnection
+}
+
+// ServerOptions holds the settings specific to the type of log server
+type ServerOptions struct {
+	Splunk SplunkOptions
+	Loki   LokiOptions
+	Syslog SyslogOptions
+	Elk    ElkOptions
 }
 
 // NewLogger creates a new logger
@@ -72,40 +79,44 @@ func NewLogger(writer io.Writer, debug bool, json bool, serverName string, dnaUr
 		userName = user.Username
 	}
 	return &Logger{
-		mutex:           sync.Mutex{},
-		writer:          writer,
-		debug:           debug,
-		json:            json,
-		processName:     os.Args[0],
-		pid:             strconv.Itoa(os.Getpid()),
-		serverName:      serverName,
-		host:            hostname,
-		userName:        userName,
-		logUrl:          dnaUrl,
-		logKey:          dnaKey,
-		logServerType:   logServType,
-		logPubsDisabled: false,
+		mutex:         sync.Mutex{},
+		writer:        writer,
+		debug:         debug,
+		json:          json,
+		processName:   os.Args[0],
+		pid:           strconv.Itoa(os.Getpid()),
+		serverName:    serverName,
+		host:          hostname,
+		userName:      userName,
+		logUrl:        dnaUrl,
+		logKey:        dnaKey,
+		logServerType: logServType,
 	}, nil
 }
 
-func (l *Logger) format(entry map[string]interface{}) (string, error) {
-	//	if l.json {
-	//		b, err := json.Marshal(entry)
-	//		if err != nil {
-	//			return "", err
-	//		}
-	//		return string(b), err
-	//	}
+// SetServerOptions sets the options specific to the type of log server
+func (l *Logger) SetServerOptions(options ServerOptions) {
+	l.serverOptions = options
+}
+
+func (l *Logger) format(level string, entry map[string]interface{}) (string, error) {
+	if l.json {
+		return utils.FormatJSONLog(time.Now(), level, l.serverName, fmt.Sprint(entry["message"])), nil
+	}
 	return fmt.Sprintf("%v\n", entry["message"]), nil
 }
 
 // log logs a message at the specified level.  The message is enriched with
 // additional fields.
 func (l *Logger) log(level string, msg string) {
+	// Blank lines carry nothing worth a JSON entry.
+	if l.json && len(strings.TrimSpace(msg)) == 0 {
+		return
+	}
 	entry := map[string]interface{}{
 		"message": fmt.Sprint(msg),
 	}
-	s, err := l.format(entry)
+	s, err := l.format(level, entry)
 	l.mutex.Lock()
 	if err != nil {
 		// TODO: Fix this
@@ -117,6 +128,7 @@ func (l *Logger) log(level string, msg string) {
 		fmt.Fprint(l.writer, s)
 	}
 	l.mutex.Unlock()
+	l.pushDiagnostic(level, msg)
 }
 
 // Debug logs a line as debug
@@ -169,77 +181,10 @@ func (l *Logger) Fatalf(format string, args ...interface{}) {
 	l.log("FATAL", fmt.Sprintf(format, args...))
 }
 
-/*
-  Function to publish transfer log to a server
-*/
-func (l *Logger) PushToLogToServer(msg string) {
-	// Don't do anything if log publication has been disabled
-	// This can happen if an error occured when an attempt was
-	// made to publish logs to server earlier.
-	if l.logPubsDisabled == true {
-		return
-	}
-
-	// Return if this is not a valid JSON
-	if !gjson.Valid(msg) {
-		return
-	}
-
-	// Simply return if The JSON does not contain eventDescription.
-	if !gjson.Get(msg, "eventDescription").Exists() {
-		return
-	}
-
-	if l.logServerType == 1 {
-		l.PushToLogToLogDNA(msg)
-	} else if l.logServerType == 2 {
-		l.PushLogToELK(msg)
-	}
-}
-
-/*
-  Function to publish transfer log to logDNA server
-*/
-func (l *Logger) PushToLogToLogDNA(msg string) {
-	logDNALevel := getLogLevel(msg)
-	// Build the payload to publish to server
-	lineText := gjson.Get(msg, "transferId").String() + " " + gjson.Get(msg, "eventDescription").String()
-	msgToPush := "{\"lines\":[{"
-	msgToPush += "\"app\":\"" + l.serverName + "\","
-	msgToPush += "\"level\":\"" + logDNALevel + "\","
-	msgToPush += "\"line\":\"" + lineText + "\","
-	msgToPush += "\"meta\":" + msg + "}]}"
-	responseBody := bytes.NewBufferString(msgToPush)
-	logDNAUrl := l.logUrl + "?hostname=" + l.host + "&now=" + strconv.FormatInt(int64(time.Now().Nanosecond()), 10)
-	reqPOST, errRes := http.NewRequest("POST", logDNAUrl, responseBody)
-	if errRes != nil {
-		// There was an error creating HTTP request. So return
-		utils.PrintLog(fmt.Sprintf("An error occured while creating HTTP request to %s. The error is: %v\n", logDNAUrl, errRes))
-		l.logPubsDisabled = true
-		return
-	}
-
-	// All is well, so set the required headers for logDNA
-	reqPOST.Header.Set("Accept", "application/json")
-	reqPOST.Header.Set("Content-Type", "application/json")
-	reqPOST.Header.Set("apikey", l.logKey)
-
-	logDNAClient := &http.Client{}
-	respDNA, errDNA := logDNAClient.Do(reqPOST)
-	if errDNA != nil {
-		utils.PrintLog(fmt.Sprintf("An error occured while publishing transfer logs to %s. The error is: %v\n", logDNAUrl, errDNA))
-		l.logPubsDisabled = true
-		return
-	}
-	defer respDNA.Body.Close()
-
-	//Read the response body
-	_, err := ioutil.ReadAll(respDNA.Body)
-	if err != nil {
-		utils.PrintLog(fmt.Sprintf("An error occurred while reading response from server %s. The error is: %v\n", logDNAUrl, err))
-		l.logPubsDisabled = true
-		return
-	}
+// TransferLogLevel returns the level of a transfer log entry: INFO, WARN or
+// ERROR
+func TransferLogLevel(msg string) string {
+	return getLogLevel(msg)
 }
 
 // Generate a logDNA type level using the transfer log
@@ -295,55 +240,14 @@ func getLogLevel(msg string) string {
 	return level
 }
 
-/**
-  Push data a ELK server
-*/
-func (l *Logger) PushLogToELK(msg string) {
-	//elkURL := "localhost:9200/ibmmqmft/tlog"
-
-	// Return if this is not a valid JSON
-	if !gjson.Valid(msg) {
-		return
-	}
-
-	// Simply return if The JSON does not contain eventDescription.
-	if !gjson.Get(msg, "eventDescription").Exists() {
-		return
-	}
-
-	elkLevel := getLogLevel(msg)
-	// Build the payload to publish to server
-	//lineText := gjson.Get(msg, "transferId").String() + " " + gjson.Get(msg, "eventDescription").String()
-	msgToPush := "{\"transferLog\":{"
-	msgToPush += "\"hostName\":\"" + l.host + "\","
-	msgToPush += "\"level\":\"" + elkLevel + "\","
-	msgToPush += "\"metaData\":" + msg + "}]}"
-	responseBody := bytes.NewBufferString(msgToPush)
-	logUrl := l.logUrl + "/ibmmqmft/" + l.serverName
-	reqPOST, errRes := http.NewRequest("POST", strings.ToLower(logUrl), responseBody)
-	if errRes != nil {
-		// There was an error creating HTTP request. So return
-		utils.PrintLog(fmt.Sprintf("An error occured while creating HTTP request to %s. The error is: %v\n", logUrl, errRes))
-		l.logPubsDisabled = true
-		return
-	}
-
-	// All is well, so set the required headers for ELK
-	reqPOST.Header.Set("Content-Type", "application/json")
-	logHTTPClient := &http.Client{}
-	respPost, errPost := logHTTPClient.Do(reqPOST)
-	if errPost != nil {
-		utils.PrintLog(fmt.Sprintf("An error occured while publishing transfer logs to %s. The error is: %v\n", logUrl, errPost))
-		l.logPubsDisabled = true
-		return
-	}
-	defer respPost.Body.Close()
-
-	//Read the response body
-	_, err := ioutil.ReadAll(respPost.Body)
-	if err != nil {
-		utils.PrintLog(fmt.Sprintf("An error occurred while reading response from server %s. The error is: %v\n", logUrl, err))
-		l.logPubsDisabled = true
-		return
+// Returns the time of a transfer log entry, or the current time if it has none
+func getEventTime(msg string) time.Time {
+	for _, path := range []string{"time", "transferStarted.time", "transferCompleted.time", "progressInformation.time"} {
+		if value := gjson.Get(msg, path); value.Exists() {
+			if eventTime, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(value.String())); err == nil {
+				return eventTime
+			}
+		}
 	}
+	return time.Now()
 }
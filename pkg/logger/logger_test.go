@@ -21,6 +21,8 @@ import (
 	"encoding/json"
 	"strings"
 	"testing"
+
+	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
 )
 
 func TestJSONLogger(t *testing.T) {
@@ -53,3 +55,41 @@ func TestSimpleLogger(t *testing.T) {
 		t.Errorf("Expected log output to contain %v; got %v", s, buf.String())
 	}
 }
+
+func TestJSONLoggerMessageID(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l, err := NewLogger(buf, false, true, "SRC", "", "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Printf(utils.MFT_CONT_RUNTIME_NAME_0005, "docker")
+	l.Print("")
+	l.Errorf("Not a message of the container")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 entries, got %v", buf.String())
+	}
+	var e struct {
+		Level       string
+		MessageID   string
+		MessageName string
+		AgentName   string
+		Message     string
+		Fields      map[string]string
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.MessageID != "MFTC0005I" || e.MessageName != "MFT_CONT_RUNTIME_NAME_0005" || e.Level != "INFO" || e.Fields["runtime"] != "docker" ||
+		e.Message != "MFTC0005I: Container Runtime: docker." {
+		t.Errorf("Unexpected entry %v", lines[0])
+	}
+	e.MessageID, e.MessageName = "", ""
+	if err := json.Unmarshal([]byte(lines[1]), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.MessageID != "" || e.MessageName != "" || e.Level != "ERROR" {
+		t.Errorf("Unexpected entry %v", lines[1])
+	}
+}
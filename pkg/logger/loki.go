@@ -0,0 +1,114 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+/*
+ * Publication of transfer logs to the push API of Grafana Loki.
+ */
+
+// Path of the Loki push API
+const LOKI_PUSH_PATH = "/loki/api/v1/push"
+
+// LokiOptions controls the labels and authentication of entries pushed to Loki
+type LokiOptions struct {
+	// Coordination queue manager of the agent, added as a label
+	CoordinationQMgr string
+	// Credentials for basic authentication. Not sent if the user name is empty.
+	Username string
+	Password string
+	// Tenant of the entries in a multi-tenant Loki. Not sent if empty.
+	TenantID string
+}
+
+// Build a request that pushes a batch of entries to Loki. Entries with the same
+// labels are pushed as one stream.
+func (l *Logger) lokiRequest(batch []string) (*http.Request, error) {
+	type lokiEntry struct {
+		timestamp int64
+		line      string
+	}
+	type lokiStream struct {
+		Stream  map[string]string `json:"stream"`
+		Values  [][2]string       `json:"values"`
+		entries []lokiEntry
+	}
+	options := l.serverOptions.Loki
+	streams := make(map[string]*lokiStream)
+	var keys []string
+	for _, msg := range batch {
+		labels := map[string]string{
+			"agent":             l.serverName,
+			"coordination_qmgr": options.CoordinationQMgr,
+			"transfer_id":       gjson.Get(msg, "transferId").String(),
+			"level":             getLogLevel(msg),
+		}
+		key := labels["transfer_id"] + "/" + labels["level"]
+		stream, found := streams[key]
+		if !found {
+			stream = &lokiStream{Stream: labels}
+			streams[key] = stream
+			keys = append(keys, key)
+		}
+		stream.entries = append(stream.entries, lokiEntry{timestamp: getEventTime(msg).UnixNano(), line: msg})
+	}
+
+	push := struct {
+		Streams []*lokiStream `json:"streams"`
+	}{}
+	for _, key := range keys {
+		stream := streams[key]
+		// Entries of a stream must be in time order.
+		sort.SliceStable(stream.entries, func(i, j int) bool {
+			return stream.entries[i].timestamp < stream.entries[j].timestamp
+		})
+		for _, entry := range stream.entries {
+			stream.Values = append(stream.Values, [2]string{strconv.FormatInt(entry.timestamp, 10), entry.line})
+		}
+		push.Streams = append(push.Streams, stream)
+	}
+	payload, err := json.Marshal(push)
+	if err != nil {
+		return nil, err
+	}
+
+	lokiUrl := strings.TrimRight(l.logUrl, "/")
+	if !strings.HasSuffix(lokiUrl, LOKI_PUSH_PATH) {
+		lokiUrl += LOKI_PUSH_PATH
+	}
+	request, err := http.NewRequest("POST", lokiUrl, bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if len(options.Username) > 0 {
+		request.SetBasicAuth(options.Username, options.Password)
+	}
+	if len(options.TenantID) > 0 {
+		request.Header.Set("X-Scope-OrgID", options.TenantID)
+	}
+	return request, nil
+}
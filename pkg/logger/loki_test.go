@@ -0,0 +1,76 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLokiPublisher(t *testing.T) {
+	var request *http.Request
+	var push struct {
+		Streams []struct {
+			Stream map[string]string
+			Values [][2]string
+		}
+	}
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		if err := json.NewDecoder(r.Body).Decode(&push); err != nil {
+			t.Errorf("Invalid push request: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer httpServer.Close()
+	l, err := NewLogger(new(bytes.Buffer), false, false, "SRC", httpServer.URL, "", LOG_SERVER_TYPE_LOKI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.SetServerOptions(ServerOptions{Loki: LokiOptions{CoordinationQMgr: "QM1", Username: "user", Password: "secret", TenantID: "mft"}})
+	l.StartPublisher(testPublisherOptions())
+
+	l.PushToLogToServer(`{"transferId":"a1","eventDescription":"Transfer progress","time":"2022-01-01T00:00:02Z"}`)
+	l.PushToLogToServer(`{"transferId":"a1","eventDescription":"Transfer started","time":"2022-01-01T00:00:01Z"}`)
+	l.PushToLogToServer(`{"transferId":"a2","eventDescription":"Transfer started","time":"2022-01-01T00:00:03Z"}`)
+	l.StopPublisher(5 * time.Second)
+
+	if request == nil {
+		t.Fatal("Nothing pushed to Loki")
+	}
+	username, password, _ := request.BasicAuth()
+	if request.URL.Path != LOKI_PUSH_PATH || username != "user" || password != "secret" ||
+		request.Header.Get("X-Scope-OrgID") != "mft" {
+		t.Errorf("Unexpected request to %s with headers %v", request.URL.Path, request.Header)
+	}
+	if len(push.Streams) != 2 {
+		t.Fatalf("Unexpected streams %v", push.Streams)
+	}
+	stream := push.Streams[0]
+	expectedLabels := map[string]string{"agent": "SRC", "coordination_qmgr": "QM1", "transfer_id": "a1", "level": "INFO"}
+	for name, value := range expectedLabels {
+		if stream.Stream[name] != value {
+			t.Errorf("Expected label %s=%s in %v", name, value, stream.Stream)
+		}
+	}
+	if len(stream.Values) != 2 || stream.Values[0][0] != "1640995201000000000" || stream.Values[1][0] != "1640995202000000000" {
+		t.Errorf("Unexpected values %v", stream.Values)
+	}
+}
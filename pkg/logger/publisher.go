@@ -0,0 +1,535 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logger
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ibm-messaging/mq-container-mft/pkg/utils"
+	"github.com/tidwall/gjson"
+)
+
+/*
+ * Publication of transfer logs to a log server.
+ *
+ * Entries are queued by the log mirror and published in batches by a separate
+ * goroutine, so that a slow or unavailable server does not hold up the mirror.
+ * Failed requests are retried with exponential backoff. When a spool directory
+ * is given, entries that can not be queued or published are spooled to disk
+ * and published from there once the server is available again. Otherwise, or
+ * when the spool is full, they are dropped and counted.
+ */
+
+// Types of log server
+const LOG_SERVER_TYPE_DNA = 1
+const LOG_SERVER_TYPE_ELK = 2
+const LOG_SERVER_TYPE_SPLUNK = 3
+const LOG_SERVER_TYPE_LOKI = 4
+const LOG_SERVER_TYPE_SYSLOG = 5
+
+// Number of failed attempts to publish transfer log entries
+var publishErrors uint64
+
+// Number of transfer log entries that were not published
+var droppedEntries uint64
+
+// PublishErrors returns the number of failed attempts to publish transfer log
+// entries to a server
+func PublishErrors() uint64 {
+	return atomic.LoadUint64(&publishErrors)
+}
+
+// DroppedEntries returns the number of transfer log entries that were not
+// published, because the server rejected them or they could not be spooled
+func DroppedEntries() uint64 {
+	return atomic.LoadUint64(&droppedEntries)
+}
+
+// PublisherOptions controls the queueing, batching and retrying of transfer
+// log entries published to a server
+type PublisherOptions struct {
+	// Number of entries held while waiting to be published
+	QueueSize int
+	// Maximum number of entries published in one batch
+	BatchSize int
+	// Time after which a partial batch is published
+	FlushInterval time.Duration
+	// Number of times a failed batch is retried
+	MaxRetries int
+	// Delay before the first retry. The delay doubles after every retry.
+	RetryDelay time.Duration
+	// Maximum delay between retries
+	MaxRetryDelay time.Duration
+	// Time allowed for a request to the server
+	RequestTimeout time.Duration
+	// Directory in which the checkpoint and the entries not yet published are
+	// kept. Entries are not spooled if empty.
+	SpoolDir string
+	// Maximum size in bytes of the spooled entries
+	MaxSpoolSize int64
+}
+
+// DefaultPublisherOptions returns the options used unless overridden
+func DefaultPublisherOptions() PublisherOptions {
+	return PublisherOptions{
+		QueueSize:      1000,
+		BatchSize:      50,
+		FlushInterval:  5 * time.Second,
+		MaxRetries:     5,
+		RetryDelay:     time.Second,
+		MaxRetryDelay:  60 * time.Second,
+		RequestTimeout: 30 * time.Second,
+		MaxSpoolSize:   100 * 1024 * 1024,
+	}
+}
+
+// Publishes queued entries of one logger
+type publisher struct {
+	// Entries dropped since the last report. First for atomic access.
+	unreported uint64
+	options    PublisherOptions
+	client     *http.Client
+	queue      chan queuedEntry
+	// Nil if entries are not spooled
+	spool *spool
+	// Messages logged by the container waiting to be sent to syslog. Nil
+	// unless diagnostics are sent.
+	diagnostics chan string
+	// Closed when the publisher is asked to stop
+	stopping chan struct{}
+	// Closed once the queue has been published
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// Entry waiting to be published, with its position in the transfer log if known
+type queuedEntry struct {
+	msg      string
+	position *LogPosition
+}
+
+// Error returned by a request to the server, with whether it is worth retrying
+type publishError struct {
+	err       error
+	retryable bool
+}
+
+func (e *publishError) Error() string {
+	return e.err.Error()
+}
+
+// StartPublisher starts publishing transfer log entries to the server of the
+// logger in the background
+func (l *Logger) StartPublisher(options PublisherOptions) {
+	client := &http.Client{Timeout: options.RequestTimeout}
+	if l.logServerType == LOG_SERVER_TYPE_ELK && len(l.serverOptions.Elk.CACertFile) > 0 {
+		tlsConfig, err := newTLSConfig(l.serverOptions.Elk.CACertFile)
+		if err != nil {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLOG_CA_CERT_FAILED_0122, l.serverOptions.Elk.CACertFile, err))
+		} else {
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			transport.TLSClientConfig = tlsConfig
+			client.Transport = transport
+		}
+	}
+	publisher := &publisher{
+		options:  options,
+		client:   client,
+		queue:    make(chan queuedEntry, options.QueueSize),
+		stopping: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if len(options.SpoolDir) > 0 {
+		spool, err := openSpool(options.SpoolDir, options.MaxSpoolSize)
+		if err != nil {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLOG_SPOOL_OPEN_FAILED_0113, options.SpoolDir, err))
+		} else {
+			publisher.spool = spool
+		}
+	}
+	if l.logServerType == LOG_SERVER_TYPE_SYSLOG && l.serverOptions.Syslog.Diagnostics {
+		publisher.diagnostics = make(chan string, syslogDiagnosticsQueueSize)
+	}
+	l.publisher = publisher
+	if publisher.diagnostics != nil {
+		go l.sendDiagnostics()
+	}
+	go l.publish()
+}
+
+// StopPublisher publishes the entries still queued, without retrying, and
+// waits for them to be published or spooled up to the timeout. No more
+// entries may be pushed once the publisher is stopped.
+func (l *Logger) StopPublisher(timeout time.Duration) {
+	if l.publisher == nil {
+		return
+	}
+	l.publisher.stopOnce.Do(func() {
+		close(l.publisher.stopping)
+		close(l.publisher.queue)
+	})
+	select {
+	case <-l.publisher.done:
+	case <-time.After(timeout):
+	}
+}
+
+/*
+Function to publish transfer log to a server
+*/
+func (l *Logger) PushToLogToServer(msg string) {
+	l.pushEntry(queuedEntry{msg: msg})
+}
+
+// PushTransferLogEntry publishes an entry of the transfer log. The checkpoint
+// is moved to the position once the entry has been published or spooled.
+func (l *Logger) PushTransferLogEntry(msg string, position LogPosition) {
+	l.pushEntry(queuedEntry{msg: msg, position: &position})
+}
+
+func (l *Logger) pushEntry(entry queuedEntry) {
+	msg := entry.msg
+	// Return if this is not a valid JSON
+	if !gjson.Valid(msg) {
+		return
+	}
+
+	// Simply return if The JSON does not contain eventDescription.
+	if !gjson.Get(msg, "eventDescription").Exists() {
+		return
+	}
+
+	if l.publisher == nil {
+		// Publish synchronously if no publisher was started.
+		l.publishBatch([]string{msg}, 0)
+		return
+	}
+	select {
+	case l.publisher.queue <- entry:
+	default:
+		// The checkpoint is left for the publisher to move, as entries before
+		// this one may still be queued.
+		if l.publisher.spool != nil && l.publisher.spool.append([]string{msg}) == nil {
+			return
+		}
+		atomic.AddUint64(&droppedEntries, 1)
+		atomic.AddUint64(&l.publisher.unreported, 1)
+	}
+}
+
+// Publish queued entries in batches until the queue is closed
+func (l *Logger) publish() {
+	defer close(l.publisher.done)
+	ticker := time.NewTicker(l.publisher.options.FlushInterval)
+	defer ticker.Stop()
+
+	// Publish entries spooled before a restart.
+	l.publishSpool()
+	batch := make([]queuedEntry, 0, l.publisher.options.BatchSize)
+	for {
+		select {
+		case entry, ok := <-l.publisher.queue:
+			if !ok {
+				l.publishQueued(batch, 0)
+				l.reportDropped()
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) < l.publisher.options.BatchSize {
+				continue
+			}
+		case <-ticker.C:
+			l.reportDropped()
+			l.publishSpool()
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		l.publishQueued(batch, l.publisher.options.MaxRetries)
+		batch = make([]queuedEntry, 0, l.publisher.options.BatchSize)
+	}
+}
+
+// Publish a batch of queued entries, then move the checkpoint past them. While
+// earlier entries are still spooled, the batch is spooled after them instead.
+func (l *Logger) publishQueued(batch []queuedEntry, maxRetries int) {
+	if len(batch) == 0 {
+		return
+	}
+	msgs := make([]string, len(batch))
+	var position *LogPosition
+	for i, entry := range batch {
+		msgs[i] = entry.msg
+		if entry.position != nil {
+			position = entry.position
+		}
+	}
+
+	spool := l.publisher.spool
+	if spool != nil && spool.pending() {
+		l.spoolEntries(msgs, nil)
+		l.publishSpool()
+	} else {
+		l.publishBatch(msgs, maxRetries)
+	}
+	if spool != nil && position != nil {
+		if err := spool.advance(*position); err != nil {
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLOG_CHECKPOINT_FAILED_0114, spool.dir, err))
+		}
+	}
+}
+
+// Publish spooled entries in batches, without retrying, until the spool is
+// empty or the server fails
+func (l *Logger) publishSpool() {
+	spool := l.publisher.spool
+	if spool == nil {
+		return
+	}
+	for spool.pending() && !l.isPublisherStopping() {
+		entries, offsets, err := spool.read(l.publisher.options.BatchSize)
+		if err != nil || len(entries) == 0 {
+			return
+		}
+		unpublished, err := l.send(entries)
+		if err != nil {
+			atomic.AddUint64(&publishErrors, 1)
+			if pubErr, ok := err.(*publishError); !ok || pubErr.retryable {
+				// Try again later. Entries published by a partial success are
+				// spooled again after the others, so that they are not sent twice.
+				if len(unpublished) == len(entries) || l.publisher.spool.append(unpublished) != nil {
+					return
+				}
+				l.consumeSpool(offsets[len(offsets)-1])
+				return
+			}
+			atomic.AddUint64(&droppedEntries, uint64(len(unpublished)))
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLOG_DROPPED_0112, len(unpublished), l.logUrl, err))
+		}
+		if !l.consumeSpool(offsets[len(offsets)-1]) {
+			return
+		}
+	}
+}
+
+// Record that spooled entries up to the offset have been published
+func (l *Logger) consumeSpool(offset int64) bool {
+	if err := l.publisher.spool.consumed(offset); err != nil {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLOG_CHECKPOINT_FAILED_0114, l.publisher.spool.dir, err))
+		return false
+	}
+	return true
+}
+
+// Spool entries that could not be published, dropping them if they can not be
+// spooled
+func (l *Logger) spoolEntries(batch []string, cause error) {
+	err := errSpoolFull
+	if l.publisher != nil && l.publisher.spool != nil {
+		err = l.publisher.spool.append(batch)
+	}
+	if err != nil {
+		if cause == nil {
+			cause = err
+		}
+		atomic.AddUint64(&droppedEntries, uint64(len(batch)))
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLOG_DROPPED_0112, len(batch), l.logUrl, cause))
+	}
+}
+
+// Log the entries dropped because the queue was full since the last report
+func (l *Logger) reportDropped() {
+	if dropped := atomic.SwapUint64(&l.publisher.unreported, 0); dropped > 0 {
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLOG_QUEUE_FULL_0110, dropped))
+	}
+}
+
+// Publish a batch of entries, retrying failed requests with backoff
+func (l *Logger) publishBatch(batch []string, maxRetries int) {
+	delay := time.Duration(0)
+	for attempt := 0; len(batch) > 0; attempt++ {
+		unpublished, err := l.send(batch)
+		if err == nil {
+			return
+		}
+		batch = unpublished
+		atomic.AddUint64(&publishErrors, 1)
+
+		retryable := true
+		if pubErr, ok := err.(*publishError); ok {
+			retryable = pubErr.retryable
+		}
+		if !retryable {
+			atomic.AddUint64(&droppedEntries, uint64(len(batch)))
+			utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLOG_DROPPED_0112, len(batch), l.logUrl, err))
+			return
+		}
+		if attempt >= maxRetries || l.isPublisherStopping() {
+			l.spoolEntries(batch, err)
+			return
+		}
+
+		if delay == 0 {
+			delay = l.publisher.options.RetryDelay
+		} else {
+			delay *= 2
+		}
+		if delay > l.publisher.options.MaxRetryDelay {
+			delay = l.publisher.options.MaxRetryDelay
+		}
+		utils.PrintLog(fmt.Sprintf(utils.MFT_CONT_TLOG_RETRYING_0111, len(batch), l.logUrl, delay, err))
+		select {
+		case <-time.After(delay):
+		case <-l.publisher.stopping:
+		}
+	}
+}
+
+func (l *Logger) isPublisherStopping() bool {
+	if l.publisher == nil {
+		return true
+	}
+	select {
+	case <-l.publisher.stopping:
+		return true
+	default:
+		return false
+	}
+}
+
+// Send a batch of entries to the server. Returns the entries that were not
+// published if there is an error.
+func (l *Logger) send(batch []string) ([]string, error) {
+	var err error
+	switch l.logServerType {
+	case LOG_SERVER_TYPE_DNA:
+		err = l.post(l.logDNARequest(batch))
+	case LOG_SERVER_TYPE_SPLUNK:
+		err = l.post(l.splunkRequest(batch))
+	case LOG_SERVER_TYPE_LOKI:
+		err = l.post(l.lokiRequest(batch))
+	case LOG_SERVER_TYPE_SYSLOG:
+		return l.sendSyslog(batch)
+	case LOG_SERVER_TYPE_ELK:
+		return l.sendElk(batch)
+	}
+	if err != nil {
+		return batch, err
+	}
+	return nil, nil
+}
+
+// Post a request to the server and check its response
+func (l *Logger) post(request *http.Request, err error) error {
+	_, err = l.postForResponse(request, err, 1024)
+	return err
+}
+
+// Post a request to the server and return up to maxLength bytes of the body
+// of a successful response
+func (l *Logger) postForResponse(request *http.Request, err error, maxLength int64) ([]byte, error) {
+	if err != nil {
+		return nil, &publishError{err: err, retryable: false}
+	}
+	client := http.DefaultClient
+	if l.publisher != nil {
+		client = l.publisher.client
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, &publishError{err: err, retryable: true}
+	}
+	defer response.Body.Close()
+	body, _ := ioutil.ReadAll(io.LimitReader(response.Body, maxLength))
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return body, nil
+	}
+	if len(body) > 1024 {
+		body = body[:1024]
+	}
+	return nil, &publishError{
+		err:       fmt.Errorf("server returned status %s: %s", response.Status, strings.TrimSpace(string(body))),
+		retryable: isRetryableStatus(response.StatusCode),
+	}
+}
+
+// Requests rejected by the server are not retried, unless the server is busy
+// or has failed.
+func isRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusRequestTimeout || status >= 500
+}
+
+// Returns the TLS configuration trusting the CA certificates in the file, or
+// the system's certificates if no file is given
+func newTLSConfig(caCertFile string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(caCertFile) > 0 {
+		pem, err := ioutil.ReadFile(caCertFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caCertFile)
+		}
+	}
+	return config, nil
+}
+
+// Build a request that publishes a batch of entries to logDNA
+func (l *Logger) logDNARequest(batch []string) (*http.Request, error) {
+	type logDNALine struct {
+		App   string          `json:"app"`
+		Level string          `json:"level"`
+		Line  string          `json:"line"`
+		Meta  json.RawMessage `json:"meta"`
+	}
+	lines := make([]logDNALine, len(batch))
+	for i, msg := range batch {
+		lines[i] = logDNALine{
+			App:   l.serverName,
+			Level: getLogLevel(msg),
+			Line:  gjson.Get(msg, "transferId").String() + " " + gjson.Get(msg, "eventDescription").String(),
+			Meta:  json.RawMessage(msg),
+		}
+	}
+	payload, err := json.Marshal(map[string]interface{}{"lines": lines})
+	if err != nil {
+		return nil, err
+	}
+
+	logDNAUrl := l.logUrl + "?hostname=" + l.host + "&now=" + strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)
+	request, err := http.NewRequest("POST", logDNAUrl, bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("apikey", l.logKey)
+	return request, nil
+}
@@ -0,0 +1,180 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// A log server that responds with the given statuses in turn, then 200
+type testLogServer struct {
+	lock     sync.Mutex
+	statuses []int
+	requests []string
+}
+
+func (s *testLogServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.requests = append(s.requests, string(body))
+	if len(s.statuses) > 0 {
+		w.WriteHeader(s.statuses[0])
+		s.statuses = s.statuses[1:]
+	}
+}
+
+func (s *testLogServer) received() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]string{}, s.requests...)
+}
+
+func testPublisherOptions() PublisherOptions {
+	return PublisherOptions{
+		QueueSize:      10,
+		BatchSize:      3,
+		FlushInterval:  time.Hour,
+		MaxRetries:     2,
+		RetryDelay:     time.Millisecond,
+		MaxRetryDelay:  time.Millisecond,
+		RequestTimeout: time.Second,
+	}
+}
+
+func newTestPublisher(t *testing.T, server *testLogServer, serverType int16, options PublisherOptions) *Logger {
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	l, err := NewLogger(new(bytes.Buffer), false, false, "SRC", httpServer.URL, "key", serverType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.StartPublisher(options)
+	return l
+}
+
+// Entries are published in batches, and a batch that fails is retried
+func TestPublisherBatchesAndRetries(t *testing.T) {
+	server := &testLogServer{statuses: []int{http.StatusServiceUnavailable}}
+	l := newTestPublisher(t, server, LOG_SERVER_TYPE_DNA, testPublisherOptions())
+	errorsBefore := PublishErrors()
+
+	for _, id := range []string{"a1", "a2", "a3"} {
+		l.PushToLogToServer(`{"transferId":"` + id + `","eventDescription":"Transfer started"}`)
+	}
+	l.PushToLogToServer(`Not a transfer log entry`)
+	// Wait for the full batch to be retried, as batches are not retried on stop.
+	for deadline := time.Now().Add(5 * time.Second); len(server.received()) < 2 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	l.PushToLogToServer(`{"transferId":"a4","eventDescription":"Transfer started"}`)
+	l.StopPublisher(5 * time.Second)
+
+	requests := server.received()
+	// The first batch is rejected once, and the last entry is published on stop.
+	if len(requests) != 3 || requests[0] != requests[1] {
+		t.Fatalf("Unexpected requests %v", requests)
+	}
+	var payload struct {
+		Lines []struct {
+			App  string
+			Line string
+			Meta map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal([]byte(requests[1]), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Lines) != 3 || payload.Lines[0].App != "SRC" || payload.Lines[2].Meta["transferId"] != "a3" {
+		t.Errorf("Unexpected batch %s", requests[1])
+	}
+	if PublishErrors() != errorsBefore+1 {
+		t.Errorf("Expected one publish error, found %d", PublishErrors()-errorsBefore)
+	}
+}
+
+// Entries rejected by the server are dropped without retrying, and publishing
+// carries on with the next batch
+func TestPublisherDropsRejectedEntries(t *testing.T) {
+	server := &testLogServer{statuses: []int{http.StatusBadRequest}}
+	options := testPublisherOptions()
+	options.BatchSize = 1
+	l := newTestPublisher(t, server, LOG_SERVER_TYPE_ELK, options)
+	droppedBefore := DroppedEntries()
+
+	l.PushToLogToServer(`{"transferId":"a1","eventDescription":"Transfer started"}`)
+	l.PushToLogToServer(`{"transferId":"a2","eventDescription":"Transfer started"}`)
+	l.StopPublisher(5 * time.Second)
+
+	requests := server.received()
+	if len(requests) != 2 {
+		t.Fatalf("Unexpected requests %v", requests)
+	}
+	// A bulk request holds an action and a document for each entry.
+	lines := strings.Split(strings.TrimSpace(requests[1]), "\n")
+	var document map[string]interface{}
+	if len(lines) != 2 || json.Unmarshal([]byte(lines[1]), &document) != nil {
+		t.Fatalf("Invalid ELK bulk request %s", requests[1])
+	}
+	if DroppedEntries() != droppedBefore+1 {
+		t.Errorf("Expected one dropped entry, found %d", DroppedEntries()-droppedBefore)
+	}
+}
+
+// Entries are dropped rather than blocking the caller when the queue is full
+func TestPublisherQueueFull(t *testing.T) {
+	block := make(chan struct{})
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	}))
+	defer httpServer.Close()
+	l, err := NewLogger(new(bytes.Buffer), false, false, "SRC", httpServer.URL, "key", LOG_SERVER_TYPE_DNA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := testPublisherOptions()
+	options.QueueSize = 2
+	options.BatchSize = 1
+	l.StartPublisher(options)
+	defer l.StopPublisher(5 * time.Second)
+	defer close(block)
+	droppedBefore := DroppedEntries()
+
+	pushed := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			l.PushToLogToServer(`{"transferId":"a1","eventDescription":"Transfer progress"}`)
+		}
+		close(pushed)
+	}()
+	select {
+	case <-pushed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Pushing entries blocked")
+	}
+	// One entry is being published and two are queued.
+	if dropped := DroppedEntries() - droppedBefore; dropped < 7 {
+		t.Errorf("Expected at least 7 dropped entries, found %d", dropped)
+	}
+}
@@ -0,0 +1,96 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+/*
+ * Publication of transfer logs to the HTTP Event Collector (HEC) of Splunk.
+ */
+
+// Path of the HEC endpoint that receives events in JSON format
+const SPLUNK_HEC_EVENT_PATH = "/services/collector/event"
+
+// Source type of the events unless configured
+const SPLUNK_DEFAULT_SOURCE_TYPE = "ibm:mqmft:transferlog"
+
+// SplunkOptions controls how transfer log entries are indexed by Splunk
+type SplunkOptions struct {
+	// Index of the events. The default index of the token is used if empty.
+	Index string
+	// Source type of the events
+	SourceType string
+	// Source of the events. The name of the logger is used if empty.
+	Source string
+}
+
+// Build a request that publishes a batch of entries to the Splunk HEC
+func (l *Logger) splunkRequest(batch []string) (*http.Request, error) {
+	type splunkEvent struct {
+		Time       float64           `json:"time"`
+		Host       string            `json:"host"`
+		Source     string            `json:"source"`
+		SourceType string            `json:"sourcetype"`
+		Index      string            `json:"index,omitempty"`
+		Event      json.RawMessage   `json:"event"`
+		Fields     map[string]string `json:"fields"`
+	}
+	options := l.serverOptions.Splunk
+	source := options.Source
+	if len(source) == 0 {
+		source = l.serverName
+	}
+	sourceType := options.SourceType
+	if len(sourceType) == 0 {
+		sourceType = SPLUNK_DEFAULT_SOURCE_TYPE
+	}
+
+	// The HEC accepts a batch as a sequence of JSON events.
+	var payload bytes.Buffer
+	encoder := json.NewEncoder(&payload)
+	for _, msg := range batch {
+		eventTime := getEventTime(msg)
+		err := encoder.Encode(splunkEvent{
+			Time:       float64(eventTime.UnixNano()/1e6) / 1e3,
+			Host:       l.host,
+			Source:     source,
+			SourceType: sourceType,
+			Index:      options.Index,
+			Event:      json.RawMessage(msg),
+			Fields:     map[string]string{"level": getLogLevel(msg)},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	hecUrl := strings.TrimRight(l.logUrl, "/")
+	if !strings.Contains(hecUrl, "/services/collector") {
+		hecUrl += SPLUNK_HEC_EVENT_PATH
+	}
+	request, err := http.NewRequest("POST", hecUrl, &payload)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Splunk "+l.logKey)
+	return request, nil
+}
@@ -0,0 +1,75 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSplunkPublisher(t *testing.T) {
+	var path, authorization string
+	var events []map[string]interface{}
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		authorization = r.Header.Get("Authorization")
+		decoder := json.NewDecoder(r.Body)
+		for {
+			var event map[string]interface{}
+			if err := decoder.Decode(&event); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Errorf("Invalid event: %v", err)
+				break
+			}
+			events = append(events, event)
+		}
+	}))
+	defer httpServer.Close()
+	l, err := NewLogger(new(bytes.Buffer), false, false, "SRC", httpServer.URL+"/", "token", LOG_SERVER_TYPE_SPLUNK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.SetServerOptions(ServerOptions{Splunk: SplunkOptions{Index: "mft"}})
+	l.StartPublisher(testPublisherOptions())
+
+	l.PushToLogToServer(`{"transferId":"a1","eventDescription":"Transfer started","time":"2022-01-01T00:00:01.500Z"}`)
+	l.PushToLogToServer(`{"transferId":"a1","eventDescription":"Transfer failed","transferCompleted":{"resultCode":1}}`)
+	l.StopPublisher(5 * time.Second)
+
+	if path != SPLUNK_HEC_EVENT_PATH || authorization != "Splunk token" {
+		t.Errorf("Unexpected request to %s with authorization %q", path, authorization)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Unexpected events %v", events)
+	}
+	first := events[0]
+	if first["time"] != 1640995201.5 || first["index"] != "mft" || first["source"] != "SRC" ||
+		first["sourcetype"] != SPLUNK_DEFAULT_SOURCE_TYPE {
+		t.Errorf("Unexpected event %v", first)
+	}
+	if event, ok := first["event"].(map[string]interface{}); !ok || event["transferId"] != "a1" {
+		t.Errorf("Unexpected event %v", first)
+	}
+	if fields, ok := events[1]["fields"].(map[string]interface{}); !ok || fields["level"] != "ERROR" {
+		t.Errorf("Unexpected fields of event %v", events[1])
+	}
+}
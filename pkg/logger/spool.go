@@ -0,0 +1,215 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+/*
+ * Durable state of a transfer log publisher.
+ *
+ * The checkpoint records the position in the transfer log up to which every
+ * entry has been published or spooled, so that publishing resumes from there
+ * after a restart. Entries that could not be published are appended to the
+ * spool file and published from it once the server is available again.
+ */
+
+// Files kept in the spool directory
+const SPOOL_FILE_NAME = "spool.jsonl"
+const CHECKPOINT_FILE_NAME = "checkpoint.json"
+
+// Error returned when the spool has reached its maximum size
+var errSpoolFull = errors.New("spool is full")
+
+// LogPosition is the position in the transfer log following an entry
+type LogPosition struct {
+	// Identity of the transfer log file
+	Device uint64 `json:"device"`
+	Inode  uint64 `json:"inode"`
+	// Offset following the entry
+	Offset int64 `json:"offset"`
+}
+
+// Contents of the checkpoint file
+type checkpointData struct {
+	Position *LogPosition `json:"position,omitempty"`
+	// Offset in the spool file of the first entry not yet published
+	SpoolOffset int64 `json:"spoolOffset"`
+}
+
+// Entries waiting to be published, and the checkpoint of the transfer log
+type spool struct {
+	lock       sync.Mutex
+	dir        string
+	maxSize    int64
+	checkpoint checkpointData
+}
+
+// ReadCheckpoint returns the position in the transfer log up to which entries
+// have been published or spooled, if any has been recorded in the directory
+func ReadCheckpoint(dir string) (LogPosition, bool) {
+	checkpoint, err := readCheckpointFile(dir)
+	if err != nil || checkpoint.Position == nil {
+		return LogPosition{}, false
+	}
+	return *checkpoint.Position, true
+}
+
+func readCheckpointFile(dir string) (checkpointData, error) {
+	var checkpoint checkpointData
+	data, err := ioutil.ReadFile(filepath.Join(dir, CHECKPOINT_FILE_NAME))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return checkpoint, nil
+		}
+		return checkpoint, err
+	}
+	err = json.Unmarshal(data, &checkpoint)
+	return checkpoint, err
+}
+
+// Open the spool in the directory, creating the directory if needed
+func openSpool(dir string, maxSize int64) (*spool, error) {
+	if err := os.MkdirAll(dir, 0770); err != nil {
+		return nil, err
+	}
+	checkpoint, err := readCheckpointFile(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &spool{dir: dir, maxSize: maxSize, checkpoint: checkpoint}, nil
+}
+
+func (s *spool) fileName() string {
+	return filepath.Join(s.dir, SPOOL_FILE_NAME)
+}
+
+// Append entries to the spool and flush them to disk
+func (s *spool) append(entries []string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	file, err := os.OpenFile(s.fileName(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0660)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if s.maxSize > 0 {
+		if fileInfo, err := file.Stat(); err == nil && fileInfo.Size()-s.checkpoint.SpoolOffset >= s.maxSize {
+			return errSpoolFull
+		}
+	}
+	writer := bufio.NewWriter(file)
+	for _, entry := range entries {
+		// Entries are single lines of JSON
+		if entry = strings.TrimRight(entry, "\r\n"); len(entry) > 0 {
+			writer.WriteString(entry + "\n")
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// Returns up to count spooled entries, and the spool offset following each
+func (s *spool) read(count int) ([]string, []int64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	file, err := os.Open(s.fileName())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	defer file.Close()
+	if _, err := file.Seek(s.checkpoint.SpoolOffset, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+
+	var entries []string
+	var offsets []int64
+	offset := s.checkpoint.SpoolOffset
+	reader := bufio.NewReader(file)
+	for len(entries) < count {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			// An incomplete last line is left for a later read.
+			break
+		}
+		offset += int64(len(line))
+		entries = append(entries, strings.TrimRight(line, "\r\n"))
+		offsets = append(offsets, offset)
+	}
+	return entries, offsets, nil
+}
+
+// Record that the spooled entries before the offset have been published. The
+// spool file is emptied once all its entries have been published.
+func (s *spool) consumed(offset int64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.checkpoint.SpoolOffset = offset
+	if fileInfo, err := os.Stat(s.fileName()); err == nil && fileInfo.Size() <= offset {
+		if err := os.Truncate(s.fileName(), 0); err != nil {
+			return err
+		}
+		s.checkpoint.SpoolOffset = 0
+	}
+	return s.save()
+}
+
+// Returns true if there are spooled entries to publish
+func (s *spool) pending() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	fileInfo, err := os.Stat(s.fileName())
+	return err == nil && fileInfo.Size() > s.checkpoint.SpoolOffset
+}
+
+// Record that entries of the transfer log up to the position have been
+// published or spooled
+func (s *spool) advance(position LogPosition) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.checkpoint.Position = &position
+	return s.save()
+}
+
+// Write the checkpoint, replacing the previous one in a single step
+func (s *spool) save() error {
+	data, err := json.Marshal(s.checkpoint)
+	if err != nil {
+		return err
+	}
+	tempFileName := filepath.Join(s.dir, CHECKPOINT_FILE_NAME+".tmp")
+	if err := ioutil.WriteFile(tempFileName, data, 0660); err != nil {
+		return err
+	}
+	return os.Rename(tempFileName, filepath.Join(s.dir, CHECKPOINT_FILE_NAME))
+}
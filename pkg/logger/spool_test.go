@@ -0,0 +1,101 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logger
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Entries that can not be published are spooled, the checkpoint moves past
+// them, and they are published by the next publisher once the server is back
+func TestPublisherSpoolsAndResumes(t *testing.T) {
+	spoolDir := t.TempDir()
+	server := &testLogServer{statuses: []int{http.StatusServiceUnavailable}}
+	options := testPublisherOptions()
+	options.MaxRetries = 0
+	options.SpoolDir = spoolDir
+	l := newTestPublisher(t, server, LOG_SERVER_TYPE_DNA, options)
+	droppedBefore := DroppedEntries()
+
+	l.PushTransferLogEntry(`{"transferId":"a1","eventDescription":"Transfer started"}`, LogPosition{Device: 1, Inode: 2, Offset: 60})
+	l.PushTransferLogEntry(`{"transferId":"a2","eventDescription":"Transfer started"}`, LogPosition{Device: 1, Inode: 2, Offset: 120})
+	l.PushTransferLogEntry(`{"transferId":"a3","eventDescription":"Transfer started"}`, LogPosition{Device: 1, Inode: 2, Offset: 180})
+	l.StopPublisher(5 * time.Second)
+
+	// The batch failed and was spooled.
+	if requests := server.received(); len(requests) != 1 {
+		t.Fatalf("Unexpected requests %v", requests)
+	}
+	if DroppedEntries() != droppedBefore {
+		t.Errorf("Expected no dropped entries, found %d", DroppedEntries()-droppedBefore)
+	}
+	position, found := ReadCheckpoint(spoolDir)
+	if !found || position != (LogPosition{Device: 1, Inode: 2, Offset: 180}) {
+		t.Errorf("Unexpected checkpoint %v, %v", position, found)
+	}
+	spooled, err := os.ReadFile(filepath.Join(spoolDir, SPOOL_FILE_NAME))
+	if err != nil || strings.Count(string(spooled), "\n") != 3 {
+		t.Fatalf("Unexpected spool %q: %v", spooled, err)
+	}
+
+	// The spooled entries are published first by the next publisher.
+	l = newTestPublisher(t, server, LOG_SERVER_TYPE_DNA, options)
+	for deadline := time.Now().Add(5 * time.Second); len(server.received()) < 2 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	l.PushTransferLogEntry(`{"transferId":"a4","eventDescription":"Transfer started"}`, LogPosition{Device: 1, Inode: 2, Offset: 240})
+	l.StopPublisher(5 * time.Second)
+
+	requests := server.received()
+	if len(requests) != 3 || requests[1] != requests[0] || !strings.Contains(requests[2], `"a4"`) {
+		t.Fatalf("Unexpected requests %v", requests)
+	}
+	if spooled, _ := os.ReadFile(filepath.Join(spoolDir, SPOOL_FILE_NAME)); len(spooled) != 0 {
+		t.Errorf("Expected an empty spool, found %q", spooled)
+	}
+	if position, _ := ReadCheckpoint(spoolDir); position.Offset != 240 {
+		t.Errorf("Unexpected checkpoint %v", position)
+	}
+}
+
+// Entries are dropped once the spool is full
+func TestPublisherSpoolFull(t *testing.T) {
+	spoolDir := t.TempDir()
+	server := &testLogServer{statuses: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable}}
+	options := testPublisherOptions()
+	options.BatchSize = 1
+	options.MaxRetries = 0
+	options.SpoolDir = spoolDir
+	options.MaxSpoolSize = 1
+	l := newTestPublisher(t, server, LOG_SERVER_TYPE_DNA, options)
+	droppedBefore := DroppedEntries()
+
+	l.PushToLogToServer(`{"transferId":"a1","eventDescription":"Transfer started"}`)
+	l.PushToLogToServer(`{"transferId":"a2","eventDescription":"Transfer started"}`)
+	l.StopPublisher(5 * time.Second)
+
+	if DroppedEntries() != droppedBefore+1 {
+		t.Errorf("Expected one dropped entry, found %d", DroppedEntries()-droppedBefore)
+	}
+	if _, found := ReadCheckpoint(spoolDir); found {
+		t.Errorf("Unexpected checkpoint for entries without a position")
+	}
+}
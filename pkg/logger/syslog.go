@@ -0,0 +1,246 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logger
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+/*
+ * Publication of transfer logs, and optionally of the container's own
+ * diagnostics, to a syslog server in RFC 5424 format.
+ *
+ * Messages are sent over UDP, one per datagram, or over TCP or TLS, framed
+ * with their length as described in RFC 6587 and RFC 5425.
+ */
+
+// Transports of syslog messages
+const SYSLOG_NETWORK_UDP = "udp"
+const SYSLOG_NETWORK_TCP = "tcp"
+const SYSLOG_NETWORK_TLS = "tls"
+
+// Defaults of syslog messages
+const SYSLOG_DEFAULT_FACILITY = 16 // local0
+const SYSLOG_DEFAULT_APP_NAME = "ibmmqmft"
+
+// ID of the structured data element of transfer events. 2 is the private
+// enterprise number of IBM.
+const SYSLOG_SD_ID = "mqmft@2"
+
+// Message IDs of transfer events and diagnostics
+const SYSLOG_MSGID_TRANSFER = "TRANSFER"
+const SYSLOG_MSGID_DIAGNOSTIC = "DIAGNOSTIC"
+
+// Time format of RFC 5424, which allows up to microseconds
+const syslogTimestampFormat = "2006-01-02T15:04:05.999999Z07:00"
+
+// Number of diagnostics held while waiting to be sent
+const syslogDiagnosticsQueueSize = 100
+
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// SyslogOptions controls how messages are sent to a syslog server
+type SyslogOptions struct {
+	// Transport of the messages: udp, tcp or tls
+	Network string
+	// Facility of the messages
+	Facility int
+	// Name of the application sending the messages
+	AppName string
+	// File of the CA certificates trusted to verify the server when using
+	// TLS. The system's certificates are trusted if empty.
+	CACertFile string
+	// Whether messages logged by the container are sent as well
+	Diagnostics bool
+}
+
+// ParseSyslogFacility returns the number of a syslog facility given its name
+func ParseSyslogFacility(name string) (int, error) {
+	if facility, found := syslogFacilities[strings.ToLower(strings.TrimSpace(name))]; found {
+		return facility, nil
+	}
+	return SYSLOG_DEFAULT_FACILITY, fmt.Errorf("unknown syslog facility %q", name)
+}
+
+// Connection to the syslog server, opened when first needed and reopened
+// after a failure
+type syslogConnection struct {
+	lock sync.Mutex
+	conn net.Conn
+}
+
+// Send transfer log entries to the syslog server
+func (l *Logger) sendSyslog(batch []string) ([]string, error) {
+	for i, msg := range batch {
+		if err := l.writeSyslog(l.syslogMessage(getLogLevel(msg), SYSLOG_MSGID_TRANSFER, getEventTime(msg),
+			syslogStructuredData(msg), msg)); err != nil {
+			return batch[i:], err
+		}
+	}
+	return nil, nil
+}
+
+// Queue a message logged by the container to be sent to the syslog server,
+// dropping it if the queue is full
+func (l *Logger) pushDiagnostic(level string, msg string) {
+	if l.publisher == nil || l.publisher.diagnostics == nil {
+		return
+	}
+	select {
+	case l.publisher.diagnostics <- l.syslogMessage(level, SYSLOG_MSGID_DIAGNOSTIC, time.Now(), "-", msg):
+	default:
+	}
+}
+
+// Send queued diagnostics until the publisher stops. Diagnostics that can not
+// be sent are not retried.
+func (l *Logger) sendDiagnostics() {
+	for {
+		select {
+		case message := <-l.publisher.diagnostics:
+			l.writeSyslog(message)
+		case <-l.publisher.stopping:
+			return
+		}
+	}
+}
+
+// Format a syslog message
+func (l *Logger) syslogMessage(level string, msgID string, timestamp time.Time, structuredData string, msg string) string {
+	options := l.serverOptions.Syslog
+	appName := options.AppName
+	if len(appName) == 0 {
+		appName = SYSLOG_DEFAULT_APP_NAME
+	}
+	priority := options.Facility*8 + syslogSeverity(level)
+	return fmt.Sprintf("<%d>1 %s %s %s %s %s %s %s", priority, timestamp.UTC().Format(syslogTimestampFormat),
+		syslogHeaderField(l.host, 255), syslogHeaderField(appName, 48), syslogHeaderField(l.pid, 128),
+		msgID, structuredData, msg)
+}
+
+// Returns the severity of messages of a level
+func syslogSeverity(level string) int {
+	switch level {
+	case "FATAL":
+		return 2
+	case errorLevel:
+		return 3
+	case "WARN":
+		return 4
+	case debugLevel:
+		return 7
+	}
+	return 6
+}
+
+// Returns a field of the header, with the characters not allowed removed
+func syslogHeaderField(value string, maxLength int) string {
+	field := strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+		return r
+	}, value)
+	if len(field) == 0 {
+		return "-"
+	}
+	if len(field) > maxLength {
+		field = field[:maxLength]
+	}
+	return field
+}
+
+// Returns the structured data element of a transfer log entry
+func syslogStructuredData(msg string) string {
+	params := []struct {
+		name  string
+		value gjson.Result
+	}{
+		{"transferId", gjson.Get(msg, "transferId")},
+		{"sourceAgent", gjson.Get(msg, "sourceAgent")},
+		{"destinationAgent", gjson.Get(msg, "destinationAgent")},
+		{"resultCode", gjson.Get(msg, "transferCompleted.resultCode")},
+	}
+	var element strings.Builder
+	element.WriteString("[" + SYSLOG_SD_ID)
+	for _, param := range params {
+		if param.value.Exists() {
+			value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(param.value.String())
+			element.WriteString(" " + param.name + `="` + value + `"`)
+		}
+	}
+	element.WriteString("]")
+	return element.String()
+}
+
+// Write a message to the syslog server, connecting first if needed
+func (l *Logger) writeSyslog(message string) error {
+	l.syslog.lock.Lock()
+	defer l.syslog.lock.Unlock()
+
+	timeout := DefaultPublisherOptions().RequestTimeout
+	if l.publisher != nil {
+		timeout = l.publisher.options.RequestTimeout
+	}
+	if l.syslog.conn == nil {
+		conn, err := l.dialSyslog(timeout)
+		if err != nil {
+			return &publishError{err: err, retryable: true}
+		}
+		l.syslog.conn = conn
+	}
+
+	frame := message
+	if l.serverOptions.Syslog.Network != SYSLOG_NETWORK_UDP {
+		frame = strconv.Itoa(len(message)) + " " + message
+	}
+	l.syslog.conn.SetWriteDeadline(time.Now().Add(timeout))
+	if _, err := l.syslog.conn.Write([]byte(frame)); err != nil {
+		l.syslog.conn.Close()
+		l.syslog.conn = nil
+		return &publishError{err: err, retryable: true}
+	}
+	return nil
+}
+
+// Connect to the syslog server
+func (l *Logger) dialSyslog(timeout time.Duration) (net.Conn, error) {
+	options := l.serverOptions.Syslog
+	switch options.Network {
+	case SYSLOG_NETWORK_UDP, SYSLOG_NETWORK_TCP:
+		return net.DialTimeout(options.Network, l.logUrl, timeout)
+	case SYSLOG_NETWORK_TLS:
+		config, err := newTLSConfig(options.CACertFile)
+		if err != nil {
+			return nil, err
+		}
+		return tls.DialWithDialer(&net.Dialer{Timeout: timeout}, SYSLOG_NETWORK_TCP, l.logUrl, config)
+	}
+	return nil, fmt.Errorf("unknown syslog transport %q", options.Network)
+}
@@ -0,0 +1,93 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Read messages framed with their length from a syslog connection
+func readSyslogMessages(t *testing.T, reader *bufio.Reader, count int) []string {
+	var messages []string
+	for len(messages) < count {
+		length, err := reader.ReadString(' ')
+		if err != nil {
+			t.Fatalf("Read %v before error: %v", messages, err)
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(length))
+		if err != nil {
+			t.Fatalf("Invalid frame length %q", length)
+		}
+		message := make([]byte, size)
+		if _, err := io.ReadFull(reader, message); err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, string(message))
+	}
+	return messages
+}
+
+func TestSyslogPublisher(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	l, err := NewLogger(new(bytes.Buffer), false, false, "SRC", listener.Addr().String(), "", LOG_SERVER_TYPE_SYSLOG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.SetServerOptions(ServerOptions{Syslog: SyslogOptions{Network: SYSLOG_NETWORK_TCP, Facility: 20, Diagnostics: true}})
+	l.StartPublisher(testPublisherOptions())
+	defer l.StopPublisher(5 * time.Second)
+
+	l.PushToLogToServer(`{"transferId":"a1","sourceAgent":"SRC","destinationAgent":"DEST","eventDescription":"Transfer failed",` +
+		`"transferCompleted":{"resultCode":1},"time":"2022-01-01T00:00:01.5Z"}`)
+	l.PushToLogToServer(`{"transferId":"a2","sourceAgent":"S\"R]C","eventDescription":"Transfer started"}`)
+	l.PushToLogToServer(`{"transferId":"a3","eventDescription":"Transfer started"}`)
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+	messages := readSyslogMessages(t, reader, 3)
+
+	// Facility local4 and severity error
+	expected := `<163>1 2022-01-01T00:00:01.5Z ` + l.host + ` ibmmqmft ` + l.pid + ` TRANSFER ` +
+		`[mqmft@2 transferId="a1" sourceAgent="SRC" destinationAgent="DEST" resultCode="1"] {"transferId":"a1"`
+	if !strings.HasPrefix(messages[0], expected) {
+		t.Errorf("Expected message starting with %s, found %s", expected, messages[0])
+	}
+	if !strings.Contains(messages[1], ` TRANSFER [mqmft@2 transferId="a2" sourceAgent="S\"R\]C"] `) {
+		t.Errorf("Unexpected structured data in %s", messages[1])
+	}
+
+	// Messages logged by the container are sent as diagnostics.
+	l.Errorf("Something failed")
+	messages = readSyslogMessages(t, reader, 1)
+	if !strings.HasPrefix(messages[0], "<163>1 ") || !strings.HasSuffix(messages[0], " DIAGNOSTIC - Something failed") {
+		t.Errorf("Unexpected diagnostic %s", messages[0])
+	}
+}
@@ -0,0 +1,216 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"embed"
+	"encoding/json"
+	"os"
+	"path"
+	"strings"
+)
+
+/*
+ * Catalog of the messages of the container.
+ *
+ * Every message has an ID, a severity, an explanation and a user action, and
+ * is displayed with its ID as a prefix. Messages are displayed in English
+ * unless a translated catalog exists for the language selected by LANG, in
+ * which case the translated text of each message is displayed instead.
+ */
+
+// Prefix of the IDs of messages
+const MESSAGE_ID_PREFIX = "MFTC"
+
+// Severities of messages, the last letter of their ID
+const MESSAGE_SEVERITY_INFO = "I"
+const MESSAGE_SEVERITY_WARNING = "W"
+const MESSAGE_SEVERITY_ERROR = "E"
+
+// Environment variable selecting the language of messages
+const MESSAGE_LANG = "LANG"
+
+// Language of the messages in messages.go
+const MESSAGE_LANG_DEFAULT = "en"
+
+// Directory of the translated catalogs, named messages_<language>.json. Each
+// maps the ID of a message to its translation.
+const messageCatalogDir = "catalog"
+
+//go:embed catalog
+var messageCatalogFiles embed.FS
+
+// CatalogMessage describes a message of the container
+type CatalogMessage struct {
+	// ID of the message, and name of the variable holding it
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Severity    string `json:"severity"`
+	Text        string `json:"text"`
+	Explanation string `json:"explanation"`
+	UserAction  string `json:"userAction"`
+	// Names of the values reported by the message, in the order they appear
+	Fields []string `json:"fields,omitempty"`
+}
+
+// Translation of a message in a translated catalog
+type MessageTranslation struct {
+	Text        string `json:"text"`
+	Explanation string `json:"explanation,omitempty"`
+	UserAction  string `json:"userAction,omitempty"`
+}
+
+// Messages of the container in English, in the order they are declared
+var messageCatalog []CatalogMessage
+
+// Translations of the messages to the language selected by LANG
+var messageTranslations = loadMessageTranslations(ResolveLanguage(os.Getenv(MESSAGE_LANG)))
+
+// Register a message of the container and the names of the values it reports.
+// Returns the message as displayed: its ID followed by its text, translated if
+// a translation exists.
+func message(id string, text string, explanation string, userAction string, fields ...string) string {
+	messageCatalog = append(messageCatalog, CatalogMessage{
+		ID:          id,
+		Severity:    id[len(id)-1:],
+		Text:        text,
+		Explanation: explanation,
+		UserAction:  userAction,
+		Fields:      fields,
+	})
+	if translation, ok := messageTranslations[id]; ok && sameFormatVerbs(text, translation.Text) {
+		text = translation.Text
+	}
+	format := id + ": " + text
+	messageFormats = append(messageFormats, &messageFormat{id: id, format: format, fields: fields})
+	return format
+}
+
+/**
+* Returns the language of messages and license text selected by the value of
+* LANG, for example zh_tw for zh_TW.UTF-8. Returns en for languages the
+* container has no text in.
+* @param lang - Value of LANG.
+ */
+func ResolveLanguage(lang string) string {
+	switch {
+	case strings.HasPrefix(lang, "zh_TW"):
+		return "zh_tw"
+	case strings.HasPrefix(lang, "zh"):
+		return "zh"
+	// Differentiate Czech (cs) and Kashubian (csb)
+	case strings.HasPrefix(lang, "cs") && !strings.HasPrefix(lang, "csb"):
+		return "cs"
+	case strings.HasPrefix(lang, "fr"):
+		return "fr"
+	case strings.HasPrefix(lang, "de"):
+		return "de"
+	case strings.HasPrefix(lang, "el"):
+		return "el"
+	case strings.HasPrefix(lang, "id"):
+		return "id"
+	case strings.HasPrefix(lang, "it"):
+		return "it"
+	case strings.HasPrefix(lang, "ja"):
+		return "ja"
+	// Differentiate Korean (ko) from Konkani (kok)
+	case strings.HasPrefix(lang, "ko") && !strings.HasPrefix(lang, "kok"):
+		return "ko"
+	case strings.HasPrefix(lang, "lt"):
+		return "lt"
+	case strings.HasPrefix(lang, "pl"):
+		return "pl"
+	case strings.HasPrefix(lang, "pt"):
+		return "pt"
+	case strings.HasPrefix(lang, "ru"):
+		return "ru"
+	case strings.HasPrefix(lang, "sl"):
+		return "sl"
+	case strings.HasPrefix(lang, "es"):
+		return "es"
+	case strings.HasPrefix(lang, "tr"):
+		return "tr"
+	}
+	return MESSAGE_LANG_DEFAULT
+}
+
+// Read the translated catalog of a language. Returns nil if there is none, or
+// if it can not be read, in which case messages are displayed in English.
+func loadMessageTranslations(lang string) map[string]MessageTranslation {
+	if lang == MESSAGE_LANG_DEFAULT {
+		return nil
+	}
+	data, err := messageCatalogFiles.ReadFile(path.Join(messageCatalogDir, "messages_"+lang+".json"))
+	if err != nil {
+		return nil
+	}
+	var translations map[string]MessageTranslation
+	if err := json.Unmarshal(data, &translations); err != nil {
+		return nil
+	}
+	return translations
+}
+
+// Returns true if a translated text has the verbs of the text, in the same
+// order, so that it can be formatted with the same arguments
+func sameFormatVerbs(text string, translated string) bool {
+	verbs := formatVerbPattern.FindAllString(text, -1)
+	translatedVerbs := formatVerbPattern.FindAllString(translated, -1)
+	if len(translated) == 0 || len(verbs) != len(translatedVerbs) {
+		return false
+	}
+	for i := range verbs {
+		if verbs[i] != translatedVerbs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the messages of the container in English, in the order they are
+// declared
+func MessageCatalog() []CatalogMessage {
+	catalog := append([]CatalogMessage(nil), messageCatalog...)
+	for i := range catalog {
+		catalog[i].Name = messageNames[catalog[i].ID]
+	}
+	return catalog
+}
+
+/**
+* Returns the messages of the container translated to a language. Messages
+* that are not translated, or whose translation can not be formatted with
+* their arguments, are in English.
+* @param lang - Language of the messages, as returned by ResolveLanguage.
+ */
+func TranslatedMessageCatalog(lang string) []CatalogMessage {
+	translations := loadMessageTranslations(lang)
+	catalog := MessageCatalog()
+	for i, m := range catalog {
+		translation, ok := translations[m.ID]
+		if !ok || !sameFormatVerbs(m.Text, translation.Text) {
+			continue
+		}
+		catalog[i].Text = translation.Text
+		if len(translation.Explanation) > 0 {
+			catalog[i].Explanation = translation.Explanation
+		}
+		if len(translation.UserAction) > 0 {
+			catalog[i].UserAction = translation.UserAction
+		}
+	}
+	return catalog
+}
@@ -0,0 +1,426 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+/**
+* Configuration files may be written in JSON or YAML, and a directory of
+* fragment files may be supplied instead of a single file.
+*
+* Fragments of a directory, and documents of a multi-document YAML file, are
+* merged in order. Files in a directory are processed in lexical order of their
+* names. Later values override earlier ones, with these rules:
+*  - Objects are merged attribute by attribute.
+*  - Arrays of objects with a "name" attribute, like agents and protocolServers,
+*    are merged element by element using the name. Elements with a new name are
+*    appended.
+*  - Any other value, including any other array, is replaced.
+*
+* String values may refer to environment variables and files, for example
+* secrets mounted as files, using ${env:NAME} and ${file:/path}. References are
+* resolved by ResolveConfigurationReferences. $${ is replaced with a literal ${.
+ */
+
+// Extensions of configuration files read from a directory
+var configFileExtensions = []string{".json", ".yaml", ".yml"}
+
+// Returns true if the file name has a YAML extension
+func isYAMLFile(fileName string) bool {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// Read configuration data of a directory of fragment files and return the
+// merged data as JSON.
+func readConfigurationDirectory(configDir string) (string, error) {
+	entries, err := os.ReadDir(configDir)
+	if err != nil {
+		return "", err
+	}
+
+	fileNames := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		// Skip hidden files, including the ..data link of Kubernetes volumes.
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		for _, configExt := range configFileExtensions {
+			if ext == configExt {
+				fileNames = append(fileNames, entry.Name())
+				break
+			}
+		}
+	}
+	sort.Strings(fileNames)
+
+	var merged interface{}
+	fragments := 0
+	for _, fileName := range fileNames {
+		filePath := filepath.Join(configDir, fileName)
+		// Entries may be links, so check the target is a file.
+		fileInfo, err := os.Stat(filePath)
+		if err != nil {
+			return "", err
+		}
+		if fileInfo.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return "", err
+		}
+		documents, err := parseConfigurationDocuments(data, isYAMLFile(fileName))
+		if err != nil {
+			return "", fmt.Errorf("%s: %v", filePath, err)
+		}
+		for _, document := range documents {
+			merged = mergeConfigurationValues(merged, document)
+		}
+		fragments++
+	}
+
+	if fragments == 0 {
+		return "", fmt.Errorf("no configuration files with extension %s found in directory %s",
+			strings.Join(configFileExtensions, ", "), configDir)
+	}
+	return marshalConfigurationData(merged)
+}
+
+// Convert configuration data to JSON if required. JSON data is returned as is.
+// YAML is detected by the extension of the file or, failing that, by content.
+func convertConfigurationData(configFile string, data []byte) (string, error) {
+	yamlFile := isYAMLFile(configFile)
+	if !yamlFile && json.Valid(data) {
+		return string(data), nil
+	}
+
+	documents, err := parseConfigurationDocuments(data, true)
+	if err != nil {
+		if yamlFile {
+			return "", fmt.Errorf("%s: %v", configFile, err)
+		}
+		// Not YAML either, let the caller deal with the data.
+		return string(data), nil
+	}
+
+	// Data that is neither JSON nor a YAML mapping, like a base64 encoded
+	// string, is returned as is unless the file says it is YAML.
+	if !yamlFile {
+		for _, document := range documents {
+			if _, isMap := document.(map[string]interface{}); !isMap {
+				return string(data), nil
+			}
+		}
+	}
+
+	var merged interface{}
+	for _, document := range documents {
+		merged = mergeConfigurationValues(merged, document)
+	}
+	return marshalConfigurationData(merged)
+}
+
+// Parse all documents in the data. JSON data is a single document.
+func parseConfigurationDocuments(data []byte, yamlData bool) ([]interface{}, error) {
+	documents := make([]interface{}, 0)
+	if !yamlData {
+		var document interface{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&document); err != nil {
+			return nil, err
+		}
+		return append(documents, document), nil
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var document interface{}
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Skip empty documents, for example a trailing "---".
+		if document == nil {
+			continue
+		}
+		converted, err := toJSONValue(document)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, converted)
+	}
+	if len(documents) == 0 {
+		return nil, errors.New("no configuration data found")
+	}
+	return documents, nil
+}
+
+// Convert a decoded YAML value to a value that can be marshalled as JSON
+func toJSONValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			converted, err := toJSONValue(item)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = converted
+		}
+		return v, nil
+	case map[interface{}]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted, err := toJSONValue(item)
+			if err != nil {
+				return nil, err
+			}
+			object[fmt.Sprint(key)] = converted
+		}
+		return object, nil
+	case []interface{}:
+		for i, item := range v {
+			converted, err := toJSONValue(item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = converted
+		}
+		return v, nil
+	case time.Time:
+		return v.Format(time.RFC3339), nil
+	}
+	return value, nil
+}
+
+// Merge the override value into the base value as per the precedence rules
+func mergeConfigurationValues(base interface{}, override interface{}) interface{} {
+	switch overrideValue := override.(type) {
+	case map[string]interface{}:
+		baseValue, ok := base.(map[string]interface{})
+		if !ok {
+			return overrideValue
+		}
+		for key, item := range overrideValue {
+			baseValue[key] = mergeConfigurationValues(baseValue[key], item)
+		}
+		return baseValue
+	case []interface{}:
+		baseValue, ok := base.([]interface{})
+		if !ok || !isNamedArray(baseValue) || !isNamedArray(overrideValue) {
+			return overrideValue
+		}
+		for _, item := range overrideValue {
+			name := item.(map[string]interface{})["name"]
+			merged := false
+			for i, baseItem := range baseValue {
+				if baseItem.(map[string]interface{})["name"] == name {
+					baseValue[i] = mergeConfigurationValues(baseItem, item)
+					merged = true
+					break
+				}
+			}
+			if !merged {
+				baseValue = append(baseValue, item)
+			}
+		}
+		return baseValue
+	}
+	return override
+}
+
+// Returns true if all elements of the array are objects with a string name
+func isNamedArray(array []interface{}) bool {
+	for _, item := range array {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok := object["name"].(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func marshalConfigurationData(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Matches ${env:NAME} and ${file:/path} references, and escaped $${
+var configReferencePattern = regexp.MustCompile(`\$\$\{|\$\{(env|file):([^}]*)\}`)
+
+// Values that references have been resolved to, as they appear in JSON
+// strings, so that they can be kept out of the log
+var resolvedReferenceValues = struct {
+	sync.Mutex
+	values map[string]bool
+}{values: map[string]bool{}}
+
+// A reference that could not be resolved, with the JSON path of the value
+type UnresolvedReference struct {
+	Path    string
+	Message string
+}
+
+// Error listing all references that could not be resolved
+type UnresolvedReferencesError []UnresolvedReference
+
+func (e UnresolvedReferencesError) Error() string {
+	problems := make([]string, 0, len(e))
+	for _, reference := range e {
+		problems = append(problems, reference.Path+": "+reference.Message)
+	}
+	return strings.Join(problems, "; ")
+}
+
+// Resolve all environment variable and file references in string values of the
+// configuration. All unresolved references are reported together in an
+// UnresolvedReferencesError.
+func ResolveConfigurationReferences(configData string) (string, error) {
+	if !strings.Contains(configData, "${") {
+		return configData, nil
+	}
+
+	var document interface{}
+	decoder := json.NewDecoder(strings.NewReader(configData))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return "", err
+	}
+
+	problems := make(UnresolvedReferencesError, 0)
+	document = resolveConfigurationValue(document, "$", &problems)
+	if len(problems) > 0 {
+		return "", problems
+	}
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(document); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buffer.String(), "\n"), nil
+}
+
+func resolveConfigurationValue(value interface{}, path string, problems *UnresolvedReferencesError) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = resolveConfigurationValue(item, path+"."+key, problems)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = resolveConfigurationValue(item, fmt.Sprintf("%s[%d]", path, i), problems)
+		}
+	case string:
+		return configReferencePattern.ReplaceAllStringFunc(v, func(reference string) string {
+			if reference == "$${" {
+				return "${"
+			}
+			parts := configReferencePattern.FindStringSubmatch(reference)
+			resolved, err := resolveConfigurationReference(parts[1], strings.TrimSpace(parts[2]))
+			if err != nil {
+				*problems = append(*problems, UnresolvedReference{Path: path, Message: err.Error()})
+				return reference
+			}
+			recordResolvedReference(resolved)
+			return resolved
+		})
+	}
+	return value
+}
+
+// Remember a resolved value, as is and as encoded in a JSON string
+func recordResolvedReference(value string) {
+	if len(value) == 0 {
+		return
+	}
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	encoded := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(buffer.String(), `"`), "\n"), `"`)
+
+	resolvedReferenceValues.Lock()
+	defer resolvedReferenceValues.Unlock()
+	resolvedReferenceValues.values[value] = true
+	resolvedReferenceValues.values[encoded] = true
+}
+
+// Returns the text with every value resolved from a reference replaced by the
+// mask, so that configuration can be logged without the secrets it refers to
+func RedactResolvedReferences(text string, mask string) string {
+	resolvedReferenceValues.Lock()
+	values := make([]string, 0, len(resolvedReferenceValues.values))
+	for value := range resolvedReferenceValues.values {
+		values = append(values, value)
+	}
+	resolvedReferenceValues.Unlock()
+
+	// Longer values first, so that a value containing another is not left
+	// partly visible.
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	for _, value := range values {
+		text = strings.ReplaceAll(text, value, mask)
+	}
+	return text
+}
+
+func resolveConfigurationReference(kind string, name string) (string, error) {
+	if len(name) == 0 {
+		return "", fmt.Errorf("reference ${%s:} is incomplete", kind)
+	}
+	if kind == "env" {
+		value, set := os.LookupEnv(name)
+		if !set {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return value, nil
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+	// Files created from secrets often end with a new line.
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
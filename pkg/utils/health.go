@@ -0,0 +1,74 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Environment variable with the port of the health endpoints of runagent.
+// 0 disables the endpoints.
+const MFT_HEALTH_PORT = "MFT_HEALTH_PORT"
+
+// Default port of the health endpoints
+const HEALTH_DEFAULT_PORT = "8080"
+
+// Value of MFT_HEALTH_PORT that disables the health endpoints
+const HEALTH_PORT_DISABLED = "0"
+
+// Health endpoints served by runagent
+const HEALTH_PATH_LIVE = "/livez"
+const HEALTH_PATH_READY = "/readyz"
+const HEALTH_PATH_STARTUP = "/startupz"
+
+// Time allowed for a health endpoint to respond
+const HEALTH_QUERY_TIMEOUT = 2 * time.Second
+
+// Returns the port of the health endpoints
+func GetHealthPort() string {
+	port := strings.TrimSpace(os.Getenv(MFT_HEALTH_PORT))
+	if len(port) == 0 {
+		return HEALTH_DEFAULT_PORT
+	}
+	return port
+}
+
+// Query a health endpoint of runagent in this container. Returns true if the
+// endpoint reports healthy, along with the status it returned. An error is
+// returned if the endpoint is disabled or could not be reached.
+func QueryHealthEndpoint(path string) (bool, string, error) {
+	port := GetHealthPort()
+	if port == HEALTH_PORT_DISABLED {
+		return false, "", os.ErrNotExist
+	}
+
+	client := http.Client{Timeout: HEALTH_QUERY_TIMEOUT}
+	response, err := client.Get("http://" + net.JoinHostPort("127.0.0.1", port) + path)
+	if err != nil {
+		return false, "", err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return false, "", err
+	}
+	return response.StatusCode == http.StatusOK, strings.TrimSpace(string(body)), nil
+}
@@ -0,0 +1,270 @@
+/*
+© Copyright IBM Corporation 2022, 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+ * Format of the messages logged by the container.
+ *
+ * Messages are printed as text by default. In JSON format each message is
+ * printed as a JSON object on a single line. A message of the container is
+ * identified by the ID it starts with, and is logged with the ID, the name of
+ * the variable holding it and the values of its arguments.
+ */
+
+// Environment variable with the format of logged messages: basic or json
+const MFT_LOG_FORMAT = "MFT_LOG_FORMAT"
+
+// Environment variable with the name of the agent logged with each message
+const MFT_LOG_AGENT_NAME = "MFT_AGENT_NAME"
+
+// Formats of logged messages
+const LOG_FORMAT_BASIC = "basic"
+const LOG_FORMAT_JSON = "json"
+
+// Levels of logged messages
+const LOG_LEVEL_DEBUG = "DEBUG"
+const LOG_LEVEL_INFO = "INFO"
+const LOG_LEVEL_WARN = "WARN"
+const LOG_LEVEL_ERROR = "ERROR"
+
+// Time format of messages logged in JSON format
+const jsonLogTimestampFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// A message of the container as displayed
+type messageFormat struct {
+	id     string
+	format string
+	// Names of the values inserted in the message
+	fields []string
+	// Expression matching the message once formatted
+	pattern *regexp.Regexp
+}
+
+// Messages of the container in the order they are declared
+var messageFormats []*messageFormat
+var messageFormatsByID map[string]*messageFormat
+var compileMessageFormats sync.Once
+
+// Verbs of a format string
+var formatVerbPattern = regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z%]`)
+
+// ID at the start of a message of the container
+var messageIDPattern = regexp.MustCompile(`^` + MESSAGE_ID_PREFIX + `[0-9]{4}[IWE]: `)
+
+// Settings of logged messages, read from the environment unless set
+var logSettings struct {
+	lock      sync.RWMutex
+	loaded    bool
+	json      bool
+	agentName string
+	host      string
+}
+
+// A message logged in JSON format
+type jsonLogEntry struct {
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	MessageID   string            `json:"messageId,omitempty"`
+	MessageName string            `json:"messageName,omitempty"`
+	AgentName   string            `json:"agentName,omitempty"`
+	Message     string            `json:"message"`
+	Fields      map[string]string `json:"fields,omitempty"`
+	Process     string            `json:"process"`
+	ProcessID   int               `json:"pid"`
+	Host        string            `json:"host,omitempty"`
+}
+
+// Build the expression matching a message once formatted
+func (m *messageFormat) compile() {
+	var expr strings.Builder
+	expr.WriteString(`(?s)^`)
+	last := 0
+	for _, loc := range formatVerbPattern.FindAllStringIndex(m.format, -1) {
+		expr.WriteString(regexp.QuoteMeta(m.format[last:loc[0]]))
+		if m.format[loc[1]-1] == '%' {
+			expr.WriteString("%")
+		} else {
+			expr.WriteString("(.*?)")
+		}
+		last = loc[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(m.format[last:]))
+	expr.WriteString(`$`)
+	m.pattern = regexp.MustCompile(expr.String())
+}
+
+/**
+* Identify the message of the container a text was formatted from, by the ID
+* the text starts with.
+* @param text - Text of the message.
+* @return ID of the message, or an empty string if the text is not a message
+* of the container, and the values of its arguments.
+ */
+func IdentifyMessage(text string) (string, []string) {
+	compileMessageFormats.Do(func() {
+		messageFormatsByID = make(map[string]*messageFormat, len(messageFormats))
+		for _, m := range messageFormats {
+			m.compile()
+			messageFormatsByID[m.id] = m
+		}
+	})
+	prefix := messageIDPattern.FindString(text)
+	if len(prefix) == 0 {
+		return "", nil
+	}
+	m, ok := messageFormatsByID[strings.TrimSuffix(prefix, ": ")]
+	if !ok {
+		return "", nil
+	}
+	if match := m.pattern.FindStringSubmatch(text); match != nil {
+		return m.id, match[1:]
+	}
+	return m.id, nil
+}
+
+// Returns the names of the values of a message identified by IdentifyMessage,
+// as declared in the catalog. Values of a message declared without a name for
+// each are named arg1, arg2 and so on.
+func messageFieldNames(id string, count int) []string {
+	if m, ok := messageFormatsByID[id]; ok && len(m.fields) == count {
+		return m.fields
+	}
+	names := make([]string, count)
+	for i := range names {
+		names[i] = "arg" + strconv.Itoa(i+1)
+	}
+	return names
+}
+
+// Read the settings from the environment unless already set
+func loadLogSettings() {
+	logSettings.lock.Lock()
+	defer logSettings.lock.Unlock()
+	if logSettings.loaded {
+		return
+	}
+	logSettings.json = strings.EqualFold(strings.TrimSpace(os.Getenv(MFT_LOG_FORMAT)), LOG_FORMAT_JSON)
+	logSettings.agentName = strings.TrimSpace(os.Getenv(MFT_LOG_AGENT_NAME))
+	logSettings.host, _ = os.Hostname()
+	logSettings.loaded = true
+}
+
+/**
+* Set the format of logged messages, instead of MFT_LOG_FORMAT.
+* @param format - basic or json.
+ */
+func SetLogFormat(format string) {
+	loadLogSettings()
+	logSettings.lock.Lock()
+	defer logSettings.lock.Unlock()
+	logSettings.json = strings.EqualFold(format, LOG_FORMAT_JSON)
+}
+
+/**
+* Set the name of the agent logged with messages in JSON format, instead of
+* MFT_AGENT_NAME.
+* @param agentName - Name of the agent.
+ */
+func SetLogAgentName(agentName string) {
+	loadLogSettings()
+	logSettings.lock.Lock()
+	defer logSettings.lock.Unlock()
+	logSettings.agentName = agentName
+}
+
+// Returns true if messages are logged in JSON format
+func IsJSONLogFormat() bool {
+	loadLogSettings()
+	logSettings.lock.RLock()
+	defer logSettings.lock.RUnlock()
+	return logSettings.json
+}
+
+/**
+* Format a message as a line of JSON.
+* @param logTime - Time the message was logged.
+* @param level - Level of the message.
+* @param agentName - Name of the agent, used if none has been set.
+* @param msg - Text of the message.
+ */
+func FormatJSONLog(logTime time.Time, level string, agentName string, msg string) string {
+	loadLogSettings()
+	logSettings.lock.RLock()
+	if len(logSettings.agentName) > 0 {
+		agentName = logSettings.agentName
+	}
+	host := logSettings.host
+	logSettings.lock.RUnlock()
+
+	msg = strings.TrimRight(msg, "\r\n")
+	entry := jsonLogEntry{
+		Timestamp: logTime.UTC().Format(jsonLogTimestampFormat),
+		Level:     level,
+		AgentName: agentName,
+		Message:   msg,
+		Process:   filepath.Base(os.Args[0]),
+		ProcessID: os.Getpid(),
+		Host:      host,
+	}
+	var args []string
+	entry.MessageID, args = IdentifyMessage(msg)
+	entry.MessageName = messageNames[entry.MessageID]
+	if len(args) > 0 {
+		names := messageFieldNames(entry.MessageID, len(args))
+		entry.Fields = make(map[string]string, len(args))
+		for i, arg := range args {
+			entry.Fields[names[i]] = arg
+		}
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return msg
+	}
+	return string(line)
+}
+
+// Returns the level of a message printed by PrintLog. The level of a message
+// of the container is its severity. Other messages reporting an error or
+// failure are errors.
+func MessageLevel(msg string) string {
+	if id, _ := IdentifyMessage(msg); len(id) > 0 {
+		switch id[len(id)-1:] {
+		case MESSAGE_SEVERITY_ERROR:
+			return LOG_LEVEL_ERROR
+		case MESSAGE_SEVERITY_WARNING:
+			return LOG_LEVEL_WARN
+		}
+		return LOG_LEVEL_INFO
+	}
+	text := strings.ToLower(msg)
+	for _, word := range []string{"error", "fail", "not valid", "invalid"} {
+		if strings.Contains(text, word) {
+			return LOG_LEVEL_ERROR
+		}
+	}
+	return LOG_LEVEL_INFO
+}
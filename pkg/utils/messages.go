@@ -16,118 +16,876 @@ limitations under the License.
 
 package utils
 
+//go:generate go run ../../cmd/msgcatalog --output ../../docs/messages.md
+//go:generate go run ../../cmd/msgcatalog --format names --input messages.go --output messagenames.go
+
 /**
-* This file contains messages displayed by the container.
+* This file contains the catalog of messages displayed by the container. Each
+* message is registered with its ID, the text displayed, an explanation, the
+* action the user should take and the names of the values it reports, in the
+* order they appear in the text. The names are the fields of the message when
+* it is logged in JSON format. IDs are MFTC followed by the number of the message
+* and its severity: I for information, W for warning and E for error. The number
+* of a message must not change once released. New messages take the next number,
+* which is 0168.
+*
+* docs/messages.md, and the table of the names of the messages logged in JSON
+* format, are generated from this catalog, and must be generated again when a
+* message is added or changed.
  */
-const MFT_CONT_DIAGNOSTIC_LEVEL_0001 = "Diangostic log level set to 'info'."
-const MFT_CONT_DIAGNOSTIC_LEVEL_0002 = "Diagnostic log level set to 'verbose'."
+var MFT_CONT_DIAGNOSTIC_LEVEL_0001 = message("MFTC0001I",
+	"Diangostic log level set to 'info'.",
+	"The container logs the minimum of diagnostic information.",
+	"No action is required.")
+var MFT_CONT_DIAGNOSTIC_LEVEL_0002 = message("MFTC0002I",
+	"Diagnostic log level set to 'verbose'.",
+	"The container logs detailed diagnostic information, including the output of the commands it runs.",
+	"No action is required.")
 
-const MFT_CONT_LICENES_NOT_ACCESSPTED_0004 = "License terms and conditions not accepted. License agreements and information can be viewed by setting the environment variable LICENSE=view.  You can also set the LANG environment variable to view the license in a different language. Set environment variable LICENSE=accept to indicate acceptance of license terms and conditions."
-const MFT_CONT_RUNTIME_NAME_0005 = "Container Runtime: %s."
-const MFT_CONT_ENV_AGENT_NAME_NOT_SPECIFIED_0006 = "Container failed to start as the MFT_AGENT_NAME environment variable was not specified. Resubmit the reqeust with MFT_AGENT_NAME environment variable specified with a valid agent name."
-const MFT_CONT_ENV_AGENT_NAME_BLANK_0007 = "Container failed to start as the value specified in MFT_AGENT_NAME environment variable is blank. Resubmit the request with MFT_AGENT_NAME environment with a valid agent name."
-const MFT_CONT_ENV_AGENT_START_TIME_0008 = "MFT_AGENT_START_WAIT_TIME is set to an invalid value. Defaulting to wait time of 10 seconds."
-const MFT_CONT_ENV_BFG_DATA_BLANK_0009 = "A blank value was specified for BFG_DATA environment variable. Default path '/mnt/mftdata' will be used for agent configuration and logs."
-const MFT_CONT_CONFIG_PATH_0010 = "Agent configuration and log directory: %s."
-const MFT_CONT_ENV_AGNT_CFG_FILE_NOT_SPECIFIED_0011 = "Container failed start as MFT_AGENT_CONFIG_FILE environment variable was not specified. Resubmit the request specifying the MFT_AGENT_CONFIG_FILE environment variable with a valid path."
-const MFT_CONT_ENV_AGNT_CFG_FILE_BLANK_0012 = "Container failed to start as MFT_AGENT_CONFIG_FILE as the value specified is blank. Resubmit the request specifying the MFT_AGENT_CONFIG_FILE environment variable with a valid path."
-const MFT_CONT_CFG_FILE_READ_0013 = "An error occurred when attempting to read the configuration file [%s]. The error is: %v. Correct the error and resubmit the request."
-const MFT_CONT_CFG_CORD_QM_NAME_MISSING_0014 = "Coordination queue manager name missing."
-const MFT_CONT_CFG_CORD_QM_HOST_MISSING_0015 = "Coordination queue manager host name."
-const MFT_CONT_CFG_MISSING_ATTRIBS_0016 = "An error occurred when validating agent configuration attributes from file %s. The errors is %s."
-const MFT_CONT_CFG_CMD_QM_NAME_MISSING_0017 = "Command queue manager name missing."
-const MFT_CONT_CFG_CMD_QM_HOST_MISSING_0018 = "Command queue manager host name missing."
-const MFT_CONT_CFG_AGENT_CONFIG_MISSING_0019 = "Information required to configure agent %s was not found in file %s. Container will end now. Update the configuration file with required attributes and resubmit the request."
-const MFT_CONT_CFG_AGENT_NAME_MISSING_0020 = "Agent name missing from configuration file."
-const MFT_CONT_CFG_AGENT_QM_NAME_MISSING_0021 = "Agent queue manager name missing from configuration file."
-const MFT_CONT_CFG_AGENT_QM_HOST_MISSING_0022 = "Agent queue manager host name missing from configuration file."
-const MFT_CONT_CFG_AGENT_CONFIG_ERROR_0023 = "An error occurred when attempting validate required agent attributes from configuration file %s. The error is: %v."
-const MFT_CONT_CFG_CORD_CONFIG_MSG_0024 = "Setting up coordination configuration for agent %s. Name of the coordination queue manager %s."
-const MFT_CONT_CFG_CORD_CONFIG_CRED_NOT_EXIST_0025 = "File %s provided in MFT_AGENT_CREDENTIAL_FILE environment variable does not exist or does not have access permission."
-const MFT_CONT_CFG_CORD_CONFIG_CRED_IGNORED_0026 = "Path provided in MFT_AGENT_CREDENTIAL_FILE environment variable is blank and has been ignored."
-const MFT_CONT_CFG_CORD_CONFIG_CRED_PATH_0027 = "Coordination queue manager credential path %s."
-const MFT_CONT_CMD_NOT_FOUND_0028 = "Command not found. The error is: %v."
-const MFT_CONT_CORD_CFG_FAILED_0029 = "Failed to create coordination queue manager configuration. Container will end now. Review and fix errors and resubmit the request."
-const MFT_CONT_CMD_CFG_FAILED_0030 = "Failed to create command queue manager configuration. Container will end now. Review and fix errors and resubmit the request."
-const MFT_CONT_AGNT_CFG_FAILED_0031 = "Failed to configure agent %s. Container will end now. Review and fix any errors and then resubmit request."
-const MFT_CONT_AGNT_START_FAILED_0032 = "Failed to start agent %s. Container will end now. Review and fix any errors and then resubmit request."
-const MFT_CONT_AGNT_NOT_STARTED_0033 = "Agent %s has not started yet. Status will be verified again after %d seconds."
-const MFT_CONT_AGNT_FAILED_TO_START_0034 = "Agent %s did not start."
-const MFT_CONT_AGNT_WAIT_MIRROR_CMP_0035 = "Waiting for log mirroring to complete for agent %s."
-const MFT_CONT_AGNT_WAIT_MIRROR_STOP_0036 = "Stopping log mirroring for agent %s."
-const MFT_CONT_AGNT_CAPT_LOG_ERROR_0037 = "%s is not a valid value for MFT_AGENT_DISPLAY_CAPTURE_LOG environment variable. Transfer logs will not be displayed on the console."
-const MFT_CONT_AGNT_STARTED_0038 = "Agent %s has started."
-const MFT_CONT_AGNT_CFG_DELETED_0039 = "Configuration of agent %s not deleted."
-const MFT_CONT_AGNT_START_FAILED_0040 = "Agent %s failed to start. Container will end now. Review and fix any errors and resubmit the request."
-const MFT_CONT_AGNT_STARTING_0041 = "Starting agent %s."
-const MFT_CONT_CMD_ERROR_0042 = "Command output: %s\nError: %s."
-const MFT_CONT_CMD_INFO_0043 = "Command output: %s."
-const MFT_CONT_AGNT_VRFY_STATUS_0044 = "Verifying status of agent %s."
-const MFT_CONT_AGNT_INVALID_TYPE_0045 = "%s is an invalid agent type. Defaulting type to %s."
-const MFT_CONT_AGNT_CREATING_0046 = "Creating %s type configuration for agent %s."
-const MFT_CONT_AGNT_CREATED_0047 = "Configuration for agent %s has been created."
-const MFT_CONT_AGNT_CLN_0048 = "Invalid value %s specified for cleanOnStart attribute. The option has been ignored."
-const MFT_CONT_AGNT_DLTNG_0049 = "Deleting configuration for agent %s."
-const MFT_CONT_AGNT_DLTED_0050 = "Configuration of agent %s has been deleted."
-const MFT_CONT_AGNT_CLN_0051 = "Cleaning %s from agent %s."
-const MFT_CONT_AGNT_ITEM_CLN_0052 = "All %s have been deleted from agent %s."
-const MFT_CONT_AGNT_RM_CRT_0053 = "Creating resource monitor %s."
-const MFT_CONT_CORD_SETUP_COMP_0054 = "Coordination configuration for %s is complete."
-const MFT_CONT_CMD_SETUP_STRT_0055 = "Setting up commands configuration for agent %s. Name of the command queue manager: %s."
-const MFT_CONT_CMD_QMGR_CRED_PATH_0056 = "Command queue manager credential path %s."
-const MFT_CONT_CMD_SETUP_COMP_0057 = "Commands configuration for %s is complete."
-const MFT_CONT_CRED_ENCRYPTING_0058 = "Encrypting credentials file %s."
-const MFT_CONT_CRED_ENCRYPTED_0059 = "Credentials file %s has been encrypted."
-const MFT_CONT_CRED_DECODE_FAILED_0060 = "An error occurred while decoding base64 encoded data. The error is %v."
-const MFT_CONT_CRED_NOT_AVAIL_0061 = "Credentials for connecting to queue manager %s have not been provided."
-const MFT_CONT_CRED_NOT_AVAIL_ASM_DFLT_0062 = "Failed to decode password provided. Assuming it is not base64 encoded."
-const MFT_CONT_ERR_CONT_USER_0063 = "An error occurred while determing current user. The error is: %v."
-const MFT_CONT_ERR_OPN_CRED_FILE_0064 = "An error occurred while opening credential file %s. The error is: %v."
-const MFT_CONT_ERR_OPN_SNDBOX_FILE_0065 = "An error occurred while opening sandbox file %s. The error is: %v."
-const MFT_CONT_ERR_UPDTING_FILE_0066 = "An error occurred while updating file %s. The error is: %v."
-const MFT_CONT_ERR_OPN_FILE_0067 = "An error occurred while opening file %s. The error is: %v."
-const MFT_CONT_AGENT_STOPPED_0068 = "Agent %s has been stopped."
-const MFT_CONT_SIGNAL_CHILD_0069 = "Received SIGCHLD signal."
-const MFT_CONT_SIGNAL_LISTEN_0070 = "Listening for SIGCHLD signals."
-const MFT_CONT_SIGNAL_RECD_0071 = "Received signal %v."
-const MFT_CONT_REAPED_PID_0072 = "Reaped process ID %v."
-const MFT_CONT_DIAGNOSTIC_LEVEL_0073 = "Unknown diagnostic level specified. Defaulting to 'info'."
-const MFT_CONT_LIC_ERROR_OCCUR_0074 = "An error occurred while checking for license. The error is :%v."
-const MFT_CONT_RUNTM_ERROR_OCCUR_0075 = "An error occurred while determining container runtime. The error is :%v."
-const MFT_CONT_AGNT_ALL_ITEM_CLN_0076 = "All objects from agent %s have been deleted."
-const MFT_CONT_AGNT_PROC_NOT_RUNING_0077 = "An error occurred while determining the agent status. The error is: %v."
-const MFT_CONT_AGNT_TRANSFER_LOG_ERROR_0078 = "%s is not a valid value for MFT_AGENT_PUSH_TRANSFER_LOGS_TO_SERVER environment variable. Transfer logs will not be published to specified server."
-const MFT_CONT_BRIDGE_PROPERTY_NOT_SET = "A mandatory property '%s' for configuring bridge agent was not specified for server %s."
-const MFT_CONT_BRIDGE_NOT_ENOUGH_INFO = "Information required to setup bridge agent not found. Can not continue."
-const MFT_FAILED_OPEN_FILE = "An error occurred while opening file %s. The error is: %v"
-const MFT_FAILED_WRITE_DATA = "An error occurred while writing data to file %s. The error is: %v"
-const MFT_FAILED_DELETE_FILE = "An error occurred while deleting file %s. The error is: %v"
-const MFT_FAILED_WRITING_SANDBOX = "An error occurred while updating agent sandbox. The error is: %v"
-const MFT_CONT_NO_AGENT_CONFIG_SUPPLIED = "Configuration required for agent creation not found in supplied file %s. Container creation can not continue and will end now."
-const MFT_CONT_MTLS_NOT_CONFIGURED = "Mutual TLS not configured."
-const MFT_CONT_CORDQMGR_NON_SECURE_CONN = "Commands will use non-secure connection to coordination queue manager."
-const MFT_CONT_CMDQMGR_NON_SECURE_CONN = "Commands will use non-secure connection to command queue manager."
-const MFT_CONT_UPDATED_CMD_CONFIG = "Updated command configuration - %v."
-const MFT_CONT_AGNTQMGR_NON_SECURE_CONN = "Agent will use non-secure connections to agent queue manager."
-const MFT_CONT_KEYSTORE_CREATE_FAILED = "An error occurred while creating keystore %s. The error is: %v"
-const MFT_CONT_AGNT_NOT_READY = "Agent %s is not ready. Container will end now. Review and fix any errors and then resubmit request."
-const MFT_CONT_AGNT_NOT_READY_ERROR = "An error occurred while verifying status of agent %s. The error is %v."
-const MFT_AGENT_NAME_CONFIGURE = "Creating configuration for agent %s."
-const MFT_AGENT_JSON_CONFIG = "Configuration information of the agent: %v."
-const MFT_AGENT_NAME_CONFIG_FILE = "Name of the agent found in configuration file %v."
-const MFT_UPDATED_CONFIGURATION = "Updated coordination configuration - %v."
-const MFT_PBA_HOST_AND_TYPE_NOT_FOUND = "Protocol server host name and type not supplied in the configuration file %s. Configuration will not be updated."
-const MFT_FAILED_PERMISSION_KEYSTORE = "Error occurred while setting persmission to keystore %v. The error is %v."
+var MFT_CONT_LICENES_NOT_ACCESSPTED_0004 = message("MFTC0004E",
+	"License terms and conditions not accepted. License agreements and information can be viewed by setting the environment variable LICENSE=view.  You can also set the LANG environment variable to view the license in a different language. Set environment variable LICENSE=accept to indicate acceptance of license terms and conditions.",
+	"The LICENSE environment variable was not set to accept, so the container ended.",
+	"Review the license by setting LICENSE=view, then set LICENSE=accept and restart the container.")
+var MFT_CONT_RUNTIME_NAME_0005 = message("MFTC0005I",
+	"Container Runtime: %s.",
+	"The container runtime was detected.",
+	"No action is required.",
+	"runtime")
+var MFT_CONT_ENV_AGENT_NAME_NOT_SPECIFIED_0006 = message("MFTC0006E",
+	"Container failed to start as the MFT_AGENT_NAME environment variable was not specified. Resubmit the reqeust with MFT_AGENT_NAME environment variable specified with a valid agent name.",
+	"The name of the agent to run is given by the MFT_AGENT_NAME environment variable, which was not set.",
+	"Set MFT_AGENT_NAME to the name of an agent in the configuration file and restart the container.")
+var MFT_CONT_ENV_AGENT_NAME_BLANK_0007 = message("MFTC0007E",
+	"Container failed to start as the value specified in MFT_AGENT_NAME environment variable is blank. Resubmit the request with MFT_AGENT_NAME environment with a valid agent name.",
+	"The MFT_AGENT_NAME environment variable was set to a blank value.",
+	"Set MFT_AGENT_NAME to the name of an agent in the configuration file and restart the container.")
+var MFT_CONT_ENV_AGENT_START_TIME_0008 = message("MFTC0008W",
+	"MFT_AGENT_START_WAIT_TIME is set to an invalid value. Defaulting to wait time of 10 seconds.",
+	"The MFT_AGENT_START_WAIT_TIME environment variable is not a number of seconds.",
+	"Set MFT_AGENT_START_WAIT_TIME to a number of seconds, or remove it to use the default.")
+var MFT_CONT_ENV_BFG_DATA_BLANK_0009 = message("MFTC0009W",
+	"A blank value was specified for BFG_DATA environment variable. Default path '/mnt/mftdata' will be used for agent configuration and logs.",
+	"The BFG_DATA environment variable was set to a blank value.",
+	"Set BFG_DATA to a directory, or remove it to use the default.")
+var MFT_CONT_CONFIG_PATH_0010 = message("MFTC0010I",
+	"Agent configuration and log directory: %s.",
+	"The directory in which the agent configuration and logs are created was determined.",
+	"No action is required.",
+	"directory")
+var MFT_CONT_ENV_AGNT_CFG_FILE_NOT_SPECIFIED_0011 = message("MFTC0011E",
+	"Container failed start as MFT_AGENT_CONFIG_FILE environment variable was not specified. Resubmit the request specifying the MFT_AGENT_CONFIG_FILE environment variable with a valid path.",
+	"The path of the configuration file is given by the MFT_AGENT_CONFIG_FILE environment variable, which was not set.",
+	"Set MFT_AGENT_CONFIG_FILE to the path of the configuration file and restart the container.")
+var MFT_CONT_ENV_AGNT_CFG_FILE_BLANK_0012 = message("MFTC0012E",
+	"Container failed to start as MFT_AGENT_CONFIG_FILE as the value specified is blank. Resubmit the request specifying the MFT_AGENT_CONFIG_FILE environment variable with a valid path.",
+	"The MFT_AGENT_CONFIG_FILE environment variable was set to a blank value.",
+	"Set MFT_AGENT_CONFIG_FILE to the path of the configuration file and restart the container.")
+var MFT_CONT_CFG_FILE_READ_0013 = message("MFTC0013E",
+	"An error occurred when attempting to read the configuration file [%s]. The error is: %v. Correct the error and resubmit the request.",
+	"The configuration file could not be read or is not valid JSON.",
+	"Check that the file is mounted in the container, can be read by the container user and contains valid JSON.",
+	"file", "error")
+var MFT_CONT_CFG_CORD_QM_NAME_MISSING_0014 = message("MFTC0014E",
+	"Coordination queue manager name missing.",
+	"The coordinationQMgr section of the configuration file does not name the coordination queue manager.",
+	"Add the name attribute to the coordinationQMgr section.")
+var MFT_CONT_CFG_CORD_QM_HOST_MISSING_0015 = message("MFTC0015E",
+	"Coordination queue manager host name.",
+	"The coordinationQMgr section of the configuration file does not give the host of the coordination queue manager.",
+	"Add the host attribute to the coordinationQMgr section.")
+var MFT_CONT_CFG_MISSING_ATTRIBS_0016 = message("MFTC0016E",
+	"An error occurred when validating agent configuration attributes from file %s. The errors is %s.",
+	"Attributes required to configure the agent are missing from the configuration file.",
+	"Add the attributes reported by the error to the configuration file.",
+	"file", "error")
+var MFT_CONT_CFG_CMD_QM_NAME_MISSING_0017 = message("MFTC0017E",
+	"Command queue manager name missing.",
+	"The commandQMgr section of the configuration file does not name the command queue manager.",
+	"Add the name attribute to the commandQMgr section.")
+var MFT_CONT_CFG_CMD_QM_HOST_MISSING_0018 = message("MFTC0018E",
+	"Command queue manager host name missing.",
+	"The commandQMgr section of the configuration file does not give the host of the command queue manager.",
+	"Add the host attribute to the commandQMgr section.")
+var MFT_CONT_CFG_AGENT_CONFIG_MISSING_0019 = message("MFTC0019E",
+	"Information required to configure agent %s was not found in file %s. Container will end now. Update the configuration file with required attributes and resubmit the request.",
+	"The agents section of the configuration file has no entry for the agent named by MFT_AGENT_NAME.",
+	"Add the agent to the agents section, or set MFT_AGENT_NAME to an agent that is configured.",
+	"agentName", "file")
+var MFT_CONT_CFG_AGENT_NAME_MISSING_0020 = message("MFTC0020E",
+	"Agent name missing from configuration file.",
+	"An entry of the agents section of the configuration file has no name.",
+	"Add the name attribute to the agent.")
+var MFT_CONT_CFG_AGENT_QM_NAME_MISSING_0021 = message("MFTC0021E",
+	"Agent queue manager name missing from configuration file.",
+	"The agent in the configuration file does not name its queue manager.",
+	"Add the qmgrName attribute to the agent.")
+var MFT_CONT_CFG_AGENT_QM_HOST_MISSING_0022 = message("MFTC0022E",
+	"Agent queue manager host name missing from configuration file.",
+	"The agent in the configuration file does not give the host of its queue manager.",
+	"Add the qmgrHost attribute to the agent.")
+var MFT_CONT_CFG_AGENT_CONFIG_ERROR_0023 = message("MFTC0023E",
+	"An error occurred when attempting validate required agent attributes from configuration file %s. The error is: %v.",
+	"The attributes of the agent in the configuration file are not valid.",
+	"Correct the attributes reported by the error.",
+	"file", "error")
+var MFT_CONT_CFG_CORD_CONFIG_MSG_0024 = message("MFTC0024I",
+	"Setting up coordination configuration for agent %s. Name of the coordination queue manager %s.",
+	"The coordination configuration of the agent is being created.",
+	"No action is required.",
+	"agentName", "queueManager")
+var MFT_CONT_CFG_CORD_CONFIG_CRED_NOT_EXIST_0025 = message("MFTC0025W",
+	"File %s provided in MFT_AGENT_CREDENTIAL_FILE environment variable does not exist or does not have access permission.",
+	"The credentials file given by MFT_AGENT_CREDENTIAL_FILE could not be found or read, so queue managers are connected to without credentials.",
+	"Check that the credentials file is mounted in the container and can be read by the container user.",
+	"file")
+var MFT_CONT_CFG_CORD_CONFIG_CRED_IGNORED_0026 = message("MFTC0026W",
+	"Path provided in MFT_AGENT_CREDENTIAL_FILE environment variable is blank and has been ignored.",
+	"The MFT_AGENT_CREDENTIAL_FILE environment variable was set to a blank value.",
+	"Set MFT_AGENT_CREDENTIAL_FILE to the path of the credentials file, or remove it.")
+var MFT_CONT_CFG_CORD_CONFIG_CRED_PATH_0027 = message("MFTC0027I",
+	"Coordination queue manager credential path %s.",
+	"The coordination queue manager is connected to with the credentials in the file.",
+	"No action is required.",
+	"path")
+var MFT_CONT_CMD_NOT_FOUND_0028 = message("MFTC0028E",
+	"Command not found. The error is: %v.",
+	"A command of IBM MQ Managed File Transfer could not be run.",
+	"Check that the container image was built with the MFT redistributable package.",
+	"error")
+var MFT_CONT_CORD_CFG_FAILED_0029 = message("MFTC0029E",
+	"Failed to create coordination queue manager configuration. Container will end now. Review and fix errors and resubmit the request.",
+	"fteSetupCoordination failed, so the container ended.",
+	"Review the command output logged before this message, correct the coordinationQMgr section and restart the container.")
+var MFT_CONT_CMD_CFG_FAILED_0030 = message("MFTC0030E",
+	"Failed to create command queue manager configuration. Container will end now. Review and fix errors and resubmit the request.",
+	"fteSetupCommands failed, so the container ended.",
+	"Review the command output logged before this message, correct the commandQMgr section and restart the container.")
+var MFT_CONT_AGNT_CFG_FAILED_0031 = message("MFTC0031E",
+	"Failed to configure agent %s. Container will end now. Review and fix any errors and then resubmit request.",
+	"The agent could not be created, so the container ended.",
+	"Review the messages logged before this message, correct the configuration of the agent and restart the container.",
+	"agentName")
+var MFT_CONT_AGNT_START_FAILED_0032 = message("MFTC0032E",
+	"Failed to start agent %s. Container will end now. Review and fix any errors and then resubmit request.",
+	"fteStartAgent failed, so the container ended.",
+	"Review the command output logged before this message and the agent's output0.log, then restart the container.",
+	"agentName")
+var MFT_CONT_AGNT_NOT_STARTED_0033 = message("MFTC0033I",
+	"Agent %s has not started yet. Status will be verified again after %d seconds.",
+	"The agent has not reported that it started. Its status is checked again after a delay.",
+	"No action is required.",
+	"agentName", "waitSeconds")
+var MFT_CONT_AGNT_FAILED_TO_START_0034 = message("MFTC0034E",
+	"Agent %s did not start.",
+	"The agent did not start within MFT_AGENT_START_WAIT_TIME.",
+	"Review the agent's output0.log. Increase MFT_AGENT_START_WAIT_TIME if the agent needs longer to start.",
+	"agentName")
+var MFT_CONT_AGNT_WAIT_MIRROR_CMP_0035 = message("MFTC0035I",
+	"Waiting for log mirroring to complete for agent %s.",
+	"The container is stopping and waits for the agent logs to be mirrored to the console.",
+	"No action is required.",
+	"agentName")
+var MFT_CONT_AGNT_WAIT_MIRROR_STOP_0036 = message("MFTC0036I",
+	"Stopping log mirroring for agent %s.",
+	"The container is stopping the mirroring of the agent logs to the console.",
+	"No action is required.",
+	"agentName")
+var MFT_CONT_AGNT_CAPT_LOG_ERROR_0037 = message("MFTC0037W",
+	"%s is not a valid value for MFT_AGENT_DISPLAY_CAPTURE_LOG environment variable. Transfer logs will not be displayed on the console.",
+	"MFT_AGENT_DISPLAY_CAPTURE_LOG must be yes or no.",
+	"Set MFT_AGENT_DISPLAY_CAPTURE_LOG to yes or no.",
+	"value")
+var MFT_CONT_AGNT_STARTED_0038 = message("MFTC0038I",
+	"Agent %s has started.",
+	"The agent has started.",
+	"No action is required.",
+	"agentName")
+var MFT_CONT_AGNT_CFG_DELETED_0039 = message("MFTC0039W",
+	"Configuration of agent %s not deleted.",
+	"The existing configuration of the agent could not be deleted before the agent was created again.",
+	"Review the command output logged before this message.",
+	"agentName")
+var MFT_CONT_AGNT_START_FAILED_0040 = message("MFTC0040E",
+	"Agent %s failed to start. Container will end now. Review and fix any errors and resubmit the request.",
+	"The agent failed to start, so the container ended.",
+	"Review the agent's output0.log, correct the error and restart the container.",
+	"agentName")
+var MFT_CONT_AGNT_STARTING_0041 = message("MFTC0041I",
+	"Starting agent %s.",
+	"The agent is being started with fteStartAgent.",
+	"No action is required.",
+	"agentName")
+var MFT_CONT_CMD_ERROR_0042 = message("MFTC0042E",
+	"Command output: %s\nError: %s.",
+	"A command of IBM MQ Managed File Transfer run by the container failed.",
+	"Review the output and error of the command.",
+	"output", "error")
+var MFT_CONT_CMD_INFO_0043 = message("MFTC0043I",
+	"Command output: %s.",
+	"Output of a command of IBM MQ Managed File Transfer run by the container.",
+	"No action is required.",
+	"output")
+var MFT_CONT_AGNT_VRFY_STATUS_0044 = message("MFTC0044I",
+	"Verifying status of agent %s.",
+	"The status of the agent is being checked with ftePingAgent.",
+	"No action is required.",
+	"agentName")
+var MFT_CONT_AGNT_INVALID_TYPE_0045 = message("MFTC0045W",
+	"%s is an invalid agent type. Defaulting type to %s.",
+	"The type of the agent in the configuration file must be STANDARD or BRIDGE.",
+	"Set the type attribute of the agent to STANDARD or BRIDGE.",
+	"agentType", "defaultType")
+var MFT_CONT_AGNT_CREATING_0046 = message("MFTC0046I",
+	"Creating %s type configuration for agent %s.",
+	"The agent is being created.",
+	"No action is required.",
+	"agentType", "agentName")
+var MFT_CONT_AGNT_CREATED_0047 = message("MFTC0047I",
+	"Configuration for agent %s has been created.",
+	"The agent has been created.",
+	"No action is required.",
+	"agentName")
+var MFT_CONT_AGNT_CLN_0048 = message("MFTC0048W",
+	"Invalid value %s specified for cleanOnStart attribute. The option has been ignored.",
+	"The cleanOnStart attribute of the agent must be transfers, monitors, scheduledTransfers, invalidMessages or all.",
+	"Correct the cleanOnStart attribute of the agent.",
+	"value")
+var MFT_CONT_AGNT_DLTNG_0049 = message("MFTC0049I",
+	"Deleting configuration for agent %s.",
+	"The existing configuration of the agent is being deleted before the agent is created again.",
+	"No action is required.",
+	"agentName")
+var MFT_CONT_AGNT_DLTED_0050 = message("MFTC0050I",
+	"Configuration of agent %s has been deleted.",
+	"The existing configuration of the agent has been deleted.",
+	"No action is required.",
+	"agentName")
+var MFT_CONT_AGNT_CLN_0051 = message("MFTC0051I",
+	"Cleaning %s from agent %s.",
+	"Objects of the agent are being deleted as requested by its cleanOnStart attribute.",
+	"No action is required.",
+	"objects", "agentName")
+var MFT_CONT_AGNT_ITEM_CLN_0052 = message("MFTC0052I",
+	"All %s have been deleted from agent %s.",
+	"Objects of the agent have been deleted as requested by its cleanOnStart attribute.",
+	"No action is required.",
+	"objects", "agentName")
+var MFT_CONT_AGNT_RM_CRT_0053 = message("MFTC0053I",
+	"Creating resource monitor %s.",
+	"A resource monitor in the configuration file is being created.",
+	"No action is required.",
+	"monitorName")
+var MFT_CONT_CORD_SETUP_COMP_0054 = message("MFTC0054I",
+	"Coordination configuration for %s is complete.",
+	"The coordination configuration has been created.",
+	"No action is required.",
+	"agentName")
+var MFT_CONT_CMD_SETUP_STRT_0055 = message("MFTC0055I",
+	"Setting up commands configuration for agent %s. Name of the command queue manager: %s.",
+	"The command configuration of the agent is being created.",
+	"No action is required.",
+	"agentName", "queueManager")
+var MFT_CONT_CMD_QMGR_CRED_PATH_0056 = message("MFTC0056I",
+	"Command queue manager credential path %s.",
+	"The command queue manager is connected to with the credentials in the file.",
+	"No action is required.",
+	"path")
+var MFT_CONT_CMD_SETUP_COMP_0057 = message("MFTC0057I",
+	"Commands configuration for %s is complete.",
+	"The command configuration has been created.",
+	"No action is required.",
+	"agentName")
+var MFT_CONT_CRED_ENCRYPTING_0058 = message("MFTC0058I",
+	"Encrypting credentials file %s.",
+	"The passwords in the credentials file are being encrypted with fteObfuscate.",
+	"No action is required.",
+	"file")
+var MFT_CONT_CRED_ENCRYPTED_0059 = message("MFTC0059I",
+	"Credentials file %s has been encrypted.",
+	"The passwords in the credentials file have been encrypted.",
+	"No action is required.",
+	"file")
+var MFT_CONT_CRED_DECODE_FAILED_0060 = message("MFTC0060E",
+	"An error occurred while decoding base64 encoded data. The error is %v.",
+	"A value that must be base64 encoded could not be decoded.",
+	"Encode the value in base64.",
+	"error")
+var MFT_CONT_CRED_NOT_AVAIL_0061 = message("MFTC0061W",
+	"Credentials for connecting to queue manager %s have not been provided.",
+	"The credentials file has no credentials for the queue manager, so it is connected to without credentials.",
+	"Add credentials for the queue manager to the credentials file if it requires them.",
+	"queueManager")
+var MFT_CONT_CRED_NOT_AVAIL_ASM_DFLT_0062 = message("MFTC0062W",
+	"Failed to decode password provided. Assuming it is not base64 encoded.",
+	"A password in the credentials file is not base64 encoded, so it is used as it is.",
+	"Encode the password in base64 if it should have been decoded.")
+var MFT_CONT_ERR_CONT_USER_0063 = message("MFTC0063E",
+	"An error occurred while determing current user. The error is: %v.",
+	"The user the container runs as could not be determined.",
+	"Check the user the container is run as.",
+	"error")
+var MFT_CONT_ERR_OPN_CRED_FILE_0064 = message("MFTC0064E",
+	"An error occurred while opening credential file %s. The error is: %v.",
+	"The credentials file could not be opened.",
+	"Check that the credentials file is mounted in the container and can be read by the container user.",
+	"file", "error")
+var MFT_CONT_ERR_OPN_SNDBOX_FILE_0065 = message("MFTC0065E",
+	"An error occurred while opening sandbox file %s. The error is: %v.",
+	"The sandbox file of the agent could not be opened.",
+	"Check that the agent configuration directory can be written by the container user.",
+	"file", "error")
+var MFT_CONT_ERR_UPDTING_FILE_0066 = message("MFTC0066E",
+	"An error occurred while updating file %s. The error is: %v.",
+	"A file of the agent configuration could not be updated.",
+	"Check that the agent configuration directory can be written by the container user.",
+	"file", "error")
+var MFT_CONT_ERR_OPN_FILE_0067 = message("MFTC0067E",
+	"An error occurred while opening file %s. The error is: %v.",
+	"A file could not be opened.",
+	"Check that the file exists and can be read by the container user.",
+	"file", "error")
+var MFT_CONT_AGENT_STOPPED_0068 = message("MFTC0068I",
+	"Agent %s has been stopped.",
+	"The agent has been stopped.",
+	"No action is required.",
+	"agentName")
+var MFT_CONT_SIGNAL_CHILD_0069 = message("MFTC0069I",
+	"Received SIGCHLD signal.",
+	"A process started by the container has ended.",
+	"No action is required.")
+var MFT_CONT_SIGNAL_LISTEN_0070 = message("MFTC0070I",
+	"Listening for SIGCHLD signals.",
+	"The container reaps the processes it starts when they end.",
+	"No action is required.")
+var MFT_CONT_SIGNAL_RECD_0071 = message("MFTC0071I",
+	"Received signal %v.",
+	"The container received a signal.",
+	"No action is required.",
+	"signal")
+var MFT_CONT_REAPED_PID_0072 = message("MFTC0072I",
+	"Reaped process ID %v.",
+	"A process that had ended has been reaped.",
+	"No action is required.",
+	"pid")
+var MFT_CONT_DIAGNOSTIC_LEVEL_0073 = message("MFTC0073W",
+	"Unknown diagnostic level specified. Defaulting to 'info'.",
+	"MFT_LOG_LEVEL must be info or verbose.",
+	"Set MFT_LOG_LEVEL to info or verbose.")
+var MFT_CONT_LIC_ERROR_OCCUR_0074 = message("MFTC0074E",
+	"An error occurred while checking for license. The error is :%v.",
+	"The license could not be checked, so the container ended.",
+	"Correct the error, then restart the container.",
+	"error")
+var MFT_CONT_RUNTM_ERROR_OCCUR_0075 = message("MFTC0075E",
+	"An error occurred while determining container runtime. The error is :%v.",
+	"The container runtime could not be determined.",
+	"Correct the error, then restart the container.",
+	"error")
+var MFT_CONT_AGNT_ALL_ITEM_CLN_0076 = message("MFTC0076I",
+	"All objects from agent %s have been deleted.",
+	"All objects of the agent have been deleted as requested by its cleanOnStart attribute.",
+	"No action is required.",
+	"agentName")
+var MFT_CONT_AGNT_PROC_NOT_RUNING_0077 = message("MFTC0077E",
+	"An error occurred while determining the agent status. The error is: %v.",
+	"The status of the agent process could not be determined.",
+	"Review the agent's output0.log.",
+	"error")
+var MFT_CONT_AGNT_TRANSFER_LOG_ERROR_0078 = message("MFTC0078W",
+	"%s is not a valid value for MFT_AGENT_PUSH_TRANSFER_LOGS_TO_SERVER environment variable. Transfer logs will not be published to specified server.",
+	"MFT_AGENT_PUSH_TRANSFER_LOGS_TO_SERVER must be yes or no.",
+	"Set MFT_AGENT_PUSH_TRANSFER_LOGS_TO_SERVER to yes or no.",
+	"value")
+var MFT_CONT_CFG_SCHEMA_INVALID_0079 = message("MFTC0079E",
+	"Configuration file %s does not conform to agent configuration schema %s. %d problem(s) found.",
+	"The configuration file does not conform to the schema of agent configuration files, so the container ended. Each problem is reported by message MFTC0080E.",
+	"Correct the problems and restart the container.",
+	"file", "schema", "problems")
+var MFT_CONT_CFG_SCHEMA_VIOLATION_0080 = message("MFTC0080E",
+	"  %s",
+	"A problem found in the configuration file, with the path of the attribute it concerns.",
+	"Correct the attribute.",
+	"problem")
+var MFT_CONT_CFG_SCHEMA_VALID_0081 = message("MFTC0081I",
+	"Configuration file %s conforms to agent configuration schema %s.",
+	"The configuration file conforms to the schema of agent configuration files.",
+	"No action is required.",
+	"file", "schema")
+var MFT_CONT_VALIDATE_USAGE_0082 = message("MFTC0082I",
+	"Usage: runagent validate --config <configuration file> --agent <agent name> --output <output directory> [--bfgdata <path>]",
+	"The arguments of runagent validate were not valid.",
+	"Run the command with the arguments shown.")
+var MFT_CONT_VALIDATE_FAILED_0083 = message("MFTC0083E",
+	"Validation of configuration file %s for agent %s failed. %d problem(s) found. See %s for details.",
+	"runagent validate found problems in the configuration file.",
+	"Correct the problems listed in the report and validate the file again.",
+	"file", "agentName", "problems", "reportFile")
+var MFT_CONT_VALIDATE_PASSED_0084 = message("MFTC0084I",
+	"Configuration file %s for agent %s is valid. Configuration files rendered to %s.",
+	"runagent validate found no problems in the configuration file.",
+	"No action is required.",
+	"file", "agentName", "outputDirectory")
+var MFT_CONT_BRIDGE_DEFAULT_SERVER_UNKNOWN_0085 = message("MFTC0085E",
+	"Default server %s is not defined in protocolServers.",
+	"The defaultServer of the bridge agent is not one of its protocolServers.",
+	"Set defaultServer to the name of one of the protocolServers.",
+	"serverName")
+var MFT_CONT_STEP_RETRY_0086 = message("MFTC0086W",
+	"Setup step %s failed on attempt %d of %d. The error is: %v. Retrying in %v.",
+	"A setup step that needs a queue manager failed and will be tried again.",
+	"If the step keeps failing, check that the queue manager is running and can be reached.",
+	"step", "attempt", "maxAttempts", "error", "delay")
+var MFT_CONT_STEP_FAILED_0087 = message("MFTC0087E",
+	"Setup step %s failed after %d attempt(s). The error is: %v.",
+	"A setup step failed every time it was tried.",
+	"Check that the queue manager is running and can be reached, then restart the container.",
+	"step", "attempts", "error")
+var MFT_CONT_ENV_RETRY_INVALID_0088 = message("MFTC0088W",
+	"Invalid value %s specified for %s environment variable. The value is ignored.",
+	"An environment variable controlling retries, restarts or timeouts is not a valid number, so its default is used.",
+	"Set the environment variable to a valid number, or remove it.",
+	"value", "variable")
+var MFT_CONT_CFG_REFERENCE_ERROR_0089 = message("MFTC0089E",
+	"Failed to resolve references in configuration file %s. The error is: %v.",
+	"A reference to an environment variable or file in the configuration file could not be resolved.",
+	"Set the environment variable or mount the file the configuration refers to.",
+	"file", "error")
+var MFT_CONT_AGNT_ENDED_0090 = message("MFTC0090E",
+	"Agent %s has ended unexpectedly. Last lines of output0.log:\n%s",
+	"The agent process ended while the container was running.",
+	"Review the lines of output0.log shown.",
+	"agentName", "logTail")
+var MFT_CONT_AGNT_RESTARTING_0091 = message("MFTC0091W",
+	"Restarting agent %s in %v. This is restart %d of %d.",
+	"The agent ended unexpectedly and is restarted as set by MFT_AGENT_RESTART_LIMIT.",
+	"Review the reason the agent ended, reported by message MFTC0090E.",
+	"agentName", "delay", "restart", "restartLimit")
+var MFT_CONT_AGNT_RESTARTED_0092 = message("MFTC0092I",
+	"Agent %s has been restarted.",
+	"The agent has been restarted.",
+	"No action is required.",
+	"agentName")
+var MFT_CONT_AGNT_RESTART_FAILED_0093 = message("MFTC0093E",
+	"Agent %s ended unexpectedly and could not be restarted after %d restart(s). Container will end now.",
+	"The agent kept ending after it was restarted, so the container ended.",
+	"Review the reason the agent ended, reported by message MFTC0090E.",
+	"agentName", "restarts")
+var MFT_CONT_CFG_RELOADING_0094 = message("MFTC0094I",
+	"Configuration file %s has changed. Reloading configuration of agent %s.",
+	"The configuration file changed, or the container received SIGHUP, so the configuration is reloaded.",
+	"No action is required.",
+	"file", "agentName")
+var MFT_CONT_CFG_RELOAD_REJECTED_0095 = message("MFTC0095E",
+	"Configuration file %s has not been reloaded. The error is: %v.",
+	"The changed configuration file is not valid, so the agent keeps its current configuration.",
+	"Correct the configuration file.",
+	"file", "error")
+var MFT_CONT_CFG_RELOAD_NO_CHANGES_0096 = message("MFTC0096I",
+	"No changes to configuration of agent %s found in configuration file %s.",
+	"The configuration of the agent is the same as the one applied.",
+	"No action is required.",
+	"agentName", "file")
+var MFT_CONT_CFG_RELOAD_CONTAINER_RESTART_0097 = message("MFTC0097W",
+	"Changes to %s require a restart of the container and have not been applied.",
+	"Changes to some sections of the configuration file can only be applied when the container starts.",
+	"Restart the container to apply the changes.",
+	"attributes")
+var MFT_CONT_CFG_RELOAD_AGENT_RESTART_0098 = message("MFTC0098I",
+	"Restarting agent %s to apply changes to %s.",
+	"The agent is restarted to apply changes to its configuration.",
+	"No action is required.",
+	"agentName", "attributes")
+var MFT_CONT_CFG_RELOADED_0099 = message("MFTC0099I",
+	"Configuration of agent %s has been reloaded.",
+	"The changes to the configuration of the agent have been applied.",
+	"No action is required.",
+	"agentName")
+var MFT_CONT_CFG_RELOAD_FAILED_0100 = message("MFTC0100E",
+	"Agent %s could not be restarted with the reloaded configuration.",
+	"The agent could not be restarted after its configuration was reloaded.",
+	"Review the agent's output0.log and correct the configuration file.",
+	"agentName")
+var MFT_CONT_AGNT_RM_DLT_0101 = message("MFTC0101I",
+	"Deleting resource monitor %s.",
+	"A resource monitor removed from the configuration file is being deleted.",
+	"No action is required.",
+	"monitorName")
+var MFT_CONT_AGNT_STOPPING_0102 = message("MFTC0102I",
+	"Stopping agent %s. Waiting up to %v for %d active transfer(s) to complete.",
+	"The container is stopping and asks the agent to stop once its active transfers are complete.",
+	"No action is required.",
+	"agentName", "timeout", "activeTransfers")
+var MFT_CONT_AGNT_STOP_WAITING_0103 = message("MFTC0103I",
+	"Waiting for %d active transfer(s) of agent %s to complete.",
+	"The agent is waiting for its active transfers to complete before it stops.",
+	"No action is required.",
+	"activeTransfers", "agentName")
+var MFT_CONT_AGNT_STOP_TIMEOUT_0104 = message("MFTC0104W",
+	"Agent %s did not stop within %v. Stopping the agent immediately.",
+	"The active transfers of the agent did not complete within MFT_AGENT_STOP_TIMEOUT, so the agent is stopped immediately.",
+	"Increase MFT_AGENT_STOP_TIMEOUT, and terminationGracePeriodSeconds of the pod, if transfers need longer to complete.",
+	"agentName", "timeout")
+var MFT_CONT_TRANSFER_INTERRUPTED_0105 = message("MFTC0105W",
+	"Transfer %s from agent %s to agent %s was interrupted by the immediate stop of agent %s.",
+	"A transfer was active when the agent was stopped immediately.",
+	"Check the state of the transfer and submit it again if needed.",
+	"transferId", "sourceAgent", "destinationAgent", "agentName")
+var MFT_CONT_HEALTH_LISTENING_0106 = message("MFTC0106I",
+	"Health endpoints of the container are available on port %s.",
+	"The /livez, /readyz and /startupz health endpoints are served.",
+	"No action is required.",
+	"port")
+var MFT_CONT_HEALTH_FAILED_0107 = message("MFTC0107W",
+	"Health endpoints could not be started on port %s. Probes will check the agent directly. The error is: %v",
+	"The health endpoints could not be served.",
+	"Set MFT_HEALTH_PORT to a free port.",
+	"port", "error")
+var MFT_CONT_METRICS_LISTENING_0108 = message("MFTC0108I",
+	"Metrics of agent %s are available on port %s.",
+	"The /metrics endpoint is served.",
+	"No action is required.",
+	"agentName", "port")
+var MFT_CONT_METRICS_FAILED_0109 = message("MFTC0109E",
+	"Metrics could not be served on port %s. The error is: %v",
+	"The /metrics endpoint could not be served.",
+	"Set MFT_METRICS_PORT to a free port.",
+	"port", "error")
+var MFT_CONT_TLOG_QUEUE_FULL_0110 = message("MFTC0110W",
+	"%d transfer log entries were dropped because the publishing queue was full.",
+	"Transfer log entries were read faster than they could be published and spooling is not enabled.",
+	"Enable spooling, or increase the queueSize of the publisher.",
+	"entries")
+var MFT_CONT_TLOG_RETRYING_0111 = message("MFTC0111W",
+	"Failed to publish %d transfer log entries to %s. Retrying in %v. The error is: %v",
+	"Transfer log entries could not be published and will be published again.",
+	"Check that the server can be reached if the failure persists.",
+	"entries", "destination", "delay", "error")
+var MFT_CONT_TLOG_DROPPED_0112 = message("MFTC0112E",
+	"%d transfer log entries could not be published to %s and were dropped. The error is: %v",
+	"Transfer log entries could not be published and will not be tried again.",
+	"Correct the error reported.",
+	"entries", "destination", "error")
+var MFT_CONT_TLOG_SPOOL_OPEN_FAILED_0113 = message("MFTC0113W",
+	"Transfer log entries will not be spooled to disk because spool directory %s could not be opened. The error is: %v",
+	"The spool directory could not be opened, so entries that can not be published are kept in memory only.",
+	"Check that the spool directory can be written by the container user.",
+	"directory", "error")
+var MFT_CONT_TLOG_CHECKPOINT_FAILED_0114 = message("MFTC0114E",
+	"Failed to update the transfer log checkpoint in %s. The error is: %v",
+	"The position of the last entry published could not be saved, so entries may be published again when the container restarts.",
+	"Check that the spool directory can be written by the container user.",
+	"directory", "error")
+var MFT_CONT_TLOG_RESUMING_0115 = message("MFTC0115I",
+	"Resuming publication of transfer log %s from offset %d.",
+	"Publication of the transfer log resumes from the position saved before the container stopped.",
+	"No action is required.",
+	"file", "offset")
+var MFT_CONT_TLOG_SYSLOG_FACILITY_0116 = message("MFTC0116W",
+	"Syslog facility %s is not valid. Facility local0 will be used.",
+	"The syslog facility is not one of the facilities of RFC 5424.",
+	"Set the facility to a name such as user or local0 to local7.",
+	"facility")
+var MFT_CONT_TLOG_SYSLOG_PROTOCOL_0117 = message("MFTC0117E",
+	"Syslog protocol %s is not valid. Valid protocols are udp, tcp and tls. Transfer logs will not be published.",
+	"The syslog protocol is not udp, tcp or tls.",
+	"Set the protocol to udp, tcp or tls.",
+	"protocol")
+var MFT_CONT_OTEL_EXPORTING_0118 = message("MFTC0118I",
+	"Exporting OpenTelemetry data of agent %s to %s.",
+	"Transfers and container diagnostics are exported to an OpenTelemetry collector.",
+	"No action is required.",
+	"agentName", "endpoint")
+var MFT_CONT_OTEL_EXPORT_FAILED_0119 = message("MFTC0119W",
+	"Failed to export OpenTelemetry %s to %s. The error is: %v",
+	"Data could not be exported to the OpenTelemetry collector.",
+	"Check that the collector can be reached at OTEL_EXPORTER_OTLP_ENDPOINT.",
+	"signal", "endpoint", "error")
+var MFT_CONT_OTEL_PROTOCOL_0120 = message("MFTC0120W",
+	"OpenTelemetry protocol %s is not supported. Data will be exported using http/json.",
+	"Only the http/json protocol of OpenTelemetry is supported.",
+	"Set OTEL_EXPORTER_OTLP_PROTOCOL to http/json, or remove it.",
+	"protocol")
+var MFT_CONT_TLOG_ELK_REJECTED_0121 = message("MFTC0121E",
+	"Transfer log entry of transfer %s was rejected by index %s with status %d and was dropped. The error is: %s",
+	"Elasticsearch rejected a transfer log entry, so it will not be published again.",
+	"Correct the mapping of the index, or the error reported.",
+	"transferId", "index", "status", "error")
+var MFT_CONT_TLOG_CA_CERT_FAILED_0122 = message("MFTC0122W",
+	"CA certificates could not be loaded from %s. The system's certificates will be trusted. The error is: %v",
+	"The CA certificates of a transfer log server could not be loaded.",
+	"Check that the file is mounted in the container and contains PEM certificates.",
+	"file", "error")
+var MFT_CONT_TLOG_DESTINATION_INVALID_0123 = message("MFTC0123E",
+	"Transfer log destination %s is not valid and will be ignored. Specify its type and the details of the server.",
+	"A transfer log destination has an unknown type or is missing details of its server.",
+	"Correct the destination in the transfer log publish configuration.",
+	"destination")
+var MFT_CONT_BRIDGE_PROPERTY_NOT_SET = message("MFTC0124E",
+	"A mandatory property '%s' for configuring bridge agent was not specified for server %s.",
+	"A protocol server of the bridge agent is missing a mandatory property.",
+	"Add the property to the protocol server.",
+	"property", "serverName")
+var MFT_CONT_BRIDGE_NOT_ENOUGH_INFO = message("MFTC0125E",
+	"Information required to setup bridge agent not found. Can not continue.",
+	"The configuration of the bridge agent has no protocol servers.",
+	"Add the protocolBridge section to the agent.")
+var MFT_FAILED_OPEN_FILE = message("MFTC0126E",
+	"An error occurred while opening file %s. The error is: %v",
+	"A file could not be opened.",
+	"Check that the file exists and can be read by the container user.",
+	"file", "error")
+var MFT_FAILED_WRITE_DATA = message("MFTC0127E",
+	"An error occurred while writing data to file %s. The error is: %v",
+	"A file could not be written.",
+	"Check that the directory can be written by the container user.",
+	"file", "error")
+var MFT_FAILED_DELETE_FILE = message("MFTC0128E",
+	"An error occurred while deleting file %s. The error is: %v",
+	"A file could not be deleted.",
+	"Check that the directory can be written by the container user.",
+	"file", "error")
+var MFT_FAILED_WRITING_SANDBOX = message("MFTC0129E",
+	"An error occurred while updating agent sandbox. The error is: %v",
+	"The sandbox of the agent could not be updated.",
+	"Check that the agent configuration directory can be written by the container user.",
+	"error")
+var MFT_CONT_NO_AGENT_CONFIG_SUPPLIED = message("MFTC0130E",
+	"Configuration required for agent creation not found in supplied file %s. Container creation can not continue and will end now.",
+	"The configuration file has no agents section, so the container ended.",
+	"Add the agent to the agents section of the configuration file.",
+	"file")
+var MFT_CONT_MTLS_NOT_CONFIGURED = message("MFTC0131I",
+	"Mutual TLS not configured.",
+	"No client certificate is configured, so TLS connections do not authenticate the agent.",
+	"No action is required.")
+var MFT_CONT_CORDQMGR_NON_SECURE_CONN = message("MFTC0132W",
+	"Commands will use non-secure connection to coordination queue manager.",
+	"No CipherSpec is configured for the coordination queue manager, so commands connect to it without TLS.",
+	"Set MFT_COORD_QMGR_CIPHER to use TLS.")
+var MFT_CONT_CMDQMGR_NON_SECURE_CONN = message("MFTC0133W",
+	"Commands will use non-secure connection to command queue manager.",
+	"No CipherSpec is configured for the command queue manager, so commands connect to it without TLS.",
+	"Set MFT_CMD_QMGR_CIPHER to use TLS.")
+var MFT_CONT_UPDATED_CMD_CONFIG = message("MFTC0134I",
+	"Updated command configuration - %v.",
+	"The command properties have been updated.",
+	"No action is required.",
+	"properties")
+var MFT_CONT_AGNTQMGR_NON_SECURE_CONN = message("MFTC0135W",
+	"Agent will use non-secure connections to agent queue manager.",
+	"No CipherSpec is configured for the agent queue manager, so the agent connects to it without TLS.",
+	"Set MFT_AGENT_QMGR_CIPHER to use TLS.")
+var MFT_CONT_KEYSTORE_CREATE_FAILED = message("MFTC0136E",
+	"An error occurred while creating keystore %s. The error is: %v",
+	"A keystore could not be created from the certificates of a queue manager.",
+	"Check that the certificates are mounted in the container and are valid.",
+	"keyStore", "error")
+var MFT_CONT_AGNT_NOT_READY = message("MFTC0137E",
+	"Agent %s is not ready. Container will end now. Review and fix any errors and then resubmit request.",
+	"The agent did not become ready, so the container ended.",
+	"Review the agent's output0.log, correct the error and restart the container.",
+	"agentName")
+var MFT_CONT_AGNT_NOT_READY_ERROR = message("MFTC0138E",
+	"An error occurred while verifying status of agent %s. The error is %v.",
+	"The status of the agent could not be checked.",
+	"Review the error and the agent's output0.log.",
+	"agentName", "error")
+var MFT_AGENT_NAME_CONFIGURE = message("MFTC0139I",
+	"Creating configuration for agent %s.",
+	"The agent is being configured.",
+	"No action is required.",
+	"agentName")
+var MFT_AGENT_JSON_CONFIG = message("MFTC0140I",
+	"Configuration information of the agent: %v.",
+	"Configuration of the agent read from the configuration file.",
+	"No action is required.",
+	"configuration")
+var MFT_AGENT_NAME_CONFIG_FILE = message("MFTC0141I",
+	"Name of the agent found in configuration file %v.",
+	"The agent was found in the configuration file.",
+	"No action is required.",
+	"agentName")
+var MFT_UPDATED_CONFIGURATION = message("MFTC0142I",
+	"Updated coordination configuration - %v.",
+	"The coordination properties have been updated.",
+	"No action is required.",
+	"properties")
+var MFT_PBA_HOST_AND_TYPE_NOT_FOUND = message("MFTC0143W",
+	"Protocol server host name and type not supplied in the configuration file %s. Configuration will not be updated.",
+	"A protocol server of the bridge agent has no host or type, so it is not configured.",
+	"Add the host and type attributes to the protocol server.",
+	"file")
+var MFT_FAILED_PERMISSION_KEYSTORE = message("MFTC0144E",
+	"Error occurred while setting persmission to keystore %v. The error is %v.",
+	"The permissions of a keystore could not be restricted to the container user.",
+	"Check that the keystore directory can be written by the container user.",
+	"keyStore", "error")
+var MFT_CONT_TLS_NO_CERTIFICATES_0145 = message("MFTC0145E",
+	"No certificates or private key were found in file %s.",
+	"A file of the certificate directory of a queue manager does not hold certificates in PEM or DER format.",
+	"Replace the file with one holding the certificates of the queue manager or its CA in PEM format.",
+	"file")
+var MFT_CONT_TLS_CERT_INVALID_0146 = message("MFTC0146E",
+	"Certificate %d in file %s could not be parsed. The error is: %v",
+	"A certificate in a file of the certificate directory of a queue manager is not a valid X.509 certificate.",
+	"Replace the certificate with a valid certificate in PEM format.",
+	"certificateNumber", "file", "error")
+var MFT_CONT_TLS_KEY_INVALID_0147 = message("MFTC0147E",
+	"The private key in file %s could not be parsed. The error is: %v",
+	"The private key is not a valid RSA, ECDSA or Ed25519 key in PKCS#1, SEC 1 or PKCS#8 form.",
+	"Replace the private key with a valid key in PEM format.",
+	"file", "error")
+var MFT_CONT_TLS_KEY_ENCRYPTED_0148 = message("MFTC0148E",
+	"The private key in file %s is encrypted. Private keys must not be encrypted.",
+	"The container can not decrypt private keys, as it has no password for them.",
+	"Provide the private key unencrypted, for example from a Kubernetes secret.",
+	"file")
+var MFT_CONT_TLS_KEY_MISMATCH_0149 = message("MFTC0149E",
+	"The private key in %s does not match certificate %s.",
+	"No certificate in the file, or in the other certificate files of its directory, has the public key of the private key.",
+	"Provide the certificate issued for the private key, in the file of the key or in the same directory.",
+	"keySource", "certificate")
+var MFT_CONT_TLS_KEY_CERT_NOT_FOUND_0150 = message("MFTC0150E",
+	"No certificate matching the private key in %s was found in %s.",
+	"The certificate of the private key must be supplied with the key, in a .crt, .pem or .cer file of the certificate directory, or with the certificates to trust of the queue manager.",
+	"Provide the certificate issued for the private key, in the file of the key or in the same directory.",
+	"keySource", "certificateSources")
+var MFT_CONT_TLS_CERT_EXPIRED_0151 = message("MFTC0151E",
+	"Certificate %s in file %s expired on %s.",
+	"A certificate of the chain of the private key used to connect to a queue manager has expired, so the keystore was not created.",
+	"Replace the certificate with one that is valid.",
+	"certificate", "file", "notAfter")
+var MFT_CONT_TLS_CERT_NOT_YET_VALID_0152 = message("MFTC0152E",
+	"Certificate %s in file %s is not valid until %s.",
+	"A certificate of the chain of the private key used to connect to a queue manager is not valid yet, so the keystore was not created.",
+	"Replace the certificate with one that is valid, or check the clock of the host.",
+	"certificate", "file", "notBefore")
+var MFT_CONT_TLS_MULTIPLE_KEYS_0153 = message("MFTC0153E",
+	"File %s holds more than one private key. Each private key must be in its own file.",
+	"The keystore of a queue manager holds a single private key.",
+	"Keep only the private key of the client certificate in the file.",
+	"file")
+var MFT_CONT_TLS_NO_TRUSTED_CERTIFICATES_0154 = message("MFTC0154E",
+	"No valid certificates were found in %s.",
+	"The certificate files of the certificate directory, the CA bundle and the inline certificates of a queue manager do not hold any certificate that is valid now, so the truststore was not created.",
+	"Add the certificate of the queue manager, or of the CA that issued it, to the certificate directory or the tls attribute of the queue manager.",
+	"certificateSources")
+var MFT_CONT_TLS_TRUSTSTORE_CREATED_0155 = message("MFTC0155I",
+	"Truststore %s holds %d certificates from %s.",
+	"The truststore of a queue manager was built from the certificates of its certificate directory, CA bundle and tls attribute. The certificates are listed in the following messages.",
+	"No action is required.",
+	"trustStore", "certificates", "certificateSources")
+var MFT_CONT_TLS_TRUSTED_CERTIFICATE_0156 = message("MFTC0156I",
+	"Trusted certificate %s: subject %s, issuer %s, expires on %s, file %s.",
+	"A certificate of the certificate directory, CA bundle or tls attribute of a queue manager was added to its truststore with the alias shown.",
+	"No action is required.",
+	"alias", "subject", "issuer", "notAfter", "file")
+var MFT_CONT_TLS_CERTS_CHANGED_0157 = message("MFTC0157I",
+	"Certificates of the %s queue manager in %s have changed. Creating its keystores again.",
+	"The files of the certificate directory or the CA bundle of a queue manager changed, for example because a certificate was renewed, so the keystores and credentials file of the queue manager are created again.",
+	"No action is required.",
+	"queueManagerRole", "certificateSources")
+var MFT_CONT_TLS_CERTS_REJECTED_0158 = message("MFTC0158E",
+	"The new certificates of the %s queue manager in %s have not been used. The error is: %v",
+	"The changed files of the certificate directory or the CA bundle of a queue manager are not valid, so the keystores in use are kept.",
+	"Correct the files. They are used as soon as they are valid.",
+	"queueManagerRole", "certificateSources", "error")
+var MFT_CONT_TLS_AGENT_RESTART_0159 = message("MFTC0159I",
+	"Restarting agent %s to use the new certificates.",
+	"The agent reads its keystores when it starts, so it is restarted after the keystores of the coordination or agent queue manager are created again.",
+	"No action is required.",
+	"agentName")
+var MFT_CONT_TLS_CERTS_ROTATED_0160 = message("MFTC0160I",
+	"Keystores of the %s queue manager have been created again from the new certificates.",
+	"The keystores and credentials file of a queue manager were created again after its certificates changed.",
+	"No action is required.",
+	"queueManagerRole")
+var MFT_CONT_TLS_AGENT_RESTART_FAILED_0161 = message("MFTC0161E",
+	"Agent %s could not be restarted with the new certificates.",
+	"The agent could not be restarted after the keystores of the coordination or agent queue manager were created again.",
+	"Review the agent's output0.log and the certificates of the queue managers.",
+	"agentName")
+var MFT_CONT_TLS_CERT_EXPIRING_0162 = message("MFTC0162W",
+	"Certificate %s of the %s queue manager in file %s expires on %s, in %d days.",
+	"A certificate used to connect to a queue manager expires within the number of days set by MFT_CERT_EXPIRY_WARNING_DAYS. The warning is repeated every day until the certificate is replaced.",
+	"Renew the certificate and replace the file. The keystores are created again when the file changes.",
+	"certificate", "queueManagerRole", "file", "notAfter", "days")
+var MFT_CONT_TLS_CERT_HAS_EXPIRED_0163 = message("MFTC0163W",
+	"Certificate %s of the %s queue manager in file %s expired on %s.",
+	"A certificate used to connect to a queue manager has expired, so connections to the queue manager may fail. The warning is repeated every day until the certificate is replaced.",
+	"Renew the certificate and replace the file. The keystores are created again when the file changes.",
+	"certificate", "queueManagerRole", "file", "notAfter")
+var MFT_CONT_TLS_NO_PRIVATE_KEY_0164 = message("MFTC0164E",
+	"No private key was found in %s.",
+	"The privateKey attribute of the tls attribute of a queue manager must hold a private key in PEM format, optionally base64 encoded.",
+	"Provide the unencrypted private key of the client certificate in the privateKey attribute.",
+	"keySource")
+var MFT_CONT_ENV_STOP_MODE_INVALID_0165 = message("MFTC0165W",
+	"Invalid value %s specified for %s environment variable. The agent is stopped once its transfers complete.",
+	"The stop mode of the agent must be controlled or immediate, so the default of controlled is used.",
+	"Set the environment variable to controlled or immediate, or remove it.",
+	"value", "variable")
+var MFT_CONT_TLS_CERT_NOT_TRUSTED_0166 = message("MFTC0166W",
+	"Certificate %s in %s is only valid from %s to %s and has not been added to the truststore.",
+	"A certificate to trust has expired or is not valid yet. It is left out of the truststore, and the other certificates are trusted.",
+	"Remove the certificate from the certificate files, or replace it with one that is valid.",
+	"certificate", "file", "notBefore", "notAfter")
+var MFT_CONT_ENV_NUMBER_INVALID_0167 = message("MFTC0167W",
+	"Invalid value %s specified for %s environment variable. A number of at least %v is expected. The default is used.",
+	"An environment variable setting an interval or a number of days is not a valid number, so its default is used.",
+	"Set the environment variable to a valid number, or remove it.",
+	"value", "variable", "minimum")
 
-const AGENT_REDY_ENV_AGENT_NAME_NOT_SET_3001 = "IBMFT3001E: MFT_AGENT_NAME environment variable not specified."
-const AGENT_REDY_ENV_AGENT_CFG_FILE_NOT_SET_3002 = "IBMFT3002E: MFT_AGENT_CONFIG_FILE environment variable not specified."
-const AGENT_REDY_ENV_CFG_FILE_READ_3003 = "IBMFT3003E: An error occurred when attempting to read the configuration file [%s]. The error is: %v."
-const AGENT_REDY_NOT_RUNNING_3004 = "IBMFT3004E: Agent %s is not running."
-const AGENT_REDY_EVNT_NOT_FOUND_3005 = "IBMFT3005E: Agent ready event not found in output0.log file."
+var AGENT_REDY_ENV_AGENT_NAME_NOT_SET_3001 = message("MFTC3001E",
+	"MFT_AGENT_NAME environment variable not specified.",
+	"The readiness probe could not find the name of the agent in MFT_AGENT_NAME.",
+	"Set MFT_AGENT_NAME in the container.")
+var AGENT_REDY_ENV_AGENT_CFG_FILE_NOT_SET_3002 = message("MFTC3002E",
+	"MFT_AGENT_CONFIG_FILE environment variable not specified.",
+	"The readiness probe could not find the configuration file in MFT_AGENT_CONFIG_FILE.",
+	"Set MFT_AGENT_CONFIG_FILE in the container.")
+var AGENT_REDY_ENV_CFG_FILE_READ_3003 = message("MFTC3003E",
+	"An error occurred when attempting to read the configuration file [%s]. The error is: %v.",
+	"The readiness probe could not read the configuration file.",
+	"Check that the file is mounted in the container and contains valid JSON.",
+	"file", "error")
+var AGENT_REDY_NOT_RUNNING_3004 = message("MFTC3004E",
+	"Agent %s is not running.",
+	"The readiness probe found that the agent process is not running.",
+	"Review the agent's output0.log.",
+	"agentName")
+var AGENT_REDY_EVNT_NOT_FOUND_3005 = message("MFTC3005E",
+	"Agent ready event not found in output0.log file.",
+	"The agent has not reported that it is ready with message BFGAG0059I.",
+	"Wait for the agent to become ready. Review the agent's output0.log if it does not.")
+var AGENT_REDY_NOT_READY_3006 = message("MFTC3006E",
+	"Agent %s is not ready. The status is: %s",
+	"The readiness probe found that the agent is not ready.",
+	"Review the agent's output0.log.",
+	"agentName", "status")
 
-// Contains constants and messages for angetready probe
-// Constants must begin at 4000 as numbers 3000-3999 are reserverd for agentready application
-const AGENT_ALIV_ENV_AGENT_NAME_NOT_SET_4001 = "IBMFT4001E: MFT_AGENT_NAME environment variable not specified."
-const AGENT_ALIV_ENV_AGENT_CFG_FILE_NOT_SET_4002 = "IBMFT4002E: MFT_AGENT_CONFIG_FILE environment variable not specified."
-const AGENT_ALIV_ENV_CFG_FILE_READ_4003 = "IBMFT4003E: An error occurred when attempting to read the configuration file [%s]. The error is: %v."
-const AGENT_ALIV_NOT_RUNNING_4004 = "IBMFT4004E: Agent %s is not running."
+// Contains constants and messages for agentalive probe
+// Numbers must begin at 4000 as numbers 3000-3999 are reserved for agentready application
+var AGENT_ALIV_ENV_AGENT_NAME_NOT_SET_4001 = message("MFTC4001E",
+	"MFT_AGENT_NAME environment variable not specified.",
+	"The liveness probe could not find the name of the agent in MFT_AGENT_NAME.",
+	"Set MFT_AGENT_NAME in the container.")
+var AGENT_ALIV_ENV_AGENT_CFG_FILE_NOT_SET_4002 = message("MFTC4002E",
+	"MFT_AGENT_CONFIG_FILE environment variable not specified.",
+	"The liveness probe could not find the configuration file in MFT_AGENT_CONFIG_FILE.",
+	"Set MFT_AGENT_CONFIG_FILE in the container.")
+var AGENT_ALIV_ENV_CFG_FILE_READ_4003 = message("MFTC4003E",
+	"An error occurred when attempting to read the configuration file [%s]. The error is: %v.",
+	"The liveness probe could not read the configuration file.",
+	"Check that the file is mounted in the container and contains valid JSON.",
+	"file", "error")
+var AGENT_ALIV_NOT_RUNNING_4004 = message("MFTC4004E",
+	"Agent %s is not running.",
+	"The liveness probe found that the agent process is not running.",
+	"Review the agent's output0.log.",
+	"agentName")
+var AGENT_ALIV_NOT_LIVE_4005 = message("MFTC4005E",
+	"Agent %s is not live. The status is: %s",
+	"The liveness probe found that the agent is not live.",
+	"Review the agent's output0.log.",
+	"agentName", "status")
@@ -24,6 +24,7 @@ import (
 	"io"
 	"os"
 	"strconv"
+	"sync"
 	"syscall"
 	"time"
 
@@ -32,9 +33,17 @@ import (
 
 const FIXED_BFG_DATAPATH = "/mnt/mftdata"
 
-// Read configuration data from json file
+// Read configuration data from a JSON or YAML file, or from a directory of
+// configuration files. The data is always returned as JSON.
 func ReadConfigurationDataFromFile(configFile string) (string, error) {
-	var configData string
+	fileInfo, err := os.Stat(configFile)
+	if err != nil {
+		return "", err
+	}
+	if fileInfo.IsDir() {
+		return readConfigurationDirectory(configFile)
+	}
+
 	jsonFile, err := os.Open(configFile)
 	// if we os.Open returns an error then handle it
 	if err != nil {
@@ -49,9 +58,8 @@ func ReadConfigurationDataFromFile(configFile string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	// Convert to a string
-	configData = string(data)
-	return configData, err
+	// Convert to a JSON string
+	return convertConfigurationData(configFile, data)
 }
 
 // Is agent running?
@@ -143,6 +151,37 @@ func IsAgentReady(bfgDataPath string, agentName string, coordinationQMgr string)
 	return ready, returnError
 }
 
+// Returns up to the given number of lines from the end of a file, oldest first
+func ReadLastLines(fileName string, count int) ([]string, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	fi, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]string, 0, count)
+	scanner := backscanner.New(file, int(fi.Size()))
+	for len(lines) < count {
+		line, pos, err := scanner.Line()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		// Skip the empty line after the final new line of the file
+		if len(lines) == 0 && len(line) == 0 && pos == int(fi.Size()) {
+			continue
+		}
+		lines = append([]string{line}, lines...)
+	}
+	return lines, nil
+}
+
 func ListDirectory(dirName string) {
 	files, err := os.ReadDir(dirName)
 	if err != nil {
@@ -265,9 +304,34 @@ func ToNumber(s string) (int64, error) {
 func PrintLog(logToPrint string) {
 	format := "02/01/2006 15:04:05.000"
 	now := time.Now()
-	zone, _ := now.Local().Zone()
-	loc, _ := time.LoadLocation(zone)
-	fmt.Printf("[%s %s] %s\n", now.In(loc).Format(format), zone, logToPrint)
+	if IsJSONLogFormat() {
+		fmt.Println(FormatJSONLog(now, MessageLevel(logToPrint), "", logToPrint))
+	} else {
+		zone, _ := now.Local().Zone()
+		loc, _ := time.LoadLocation(zone)
+		fmt.Printf("[%s %s] %s\n", now.In(loc).Format(format), zone, logToPrint)
+	}
+
+	printLogHookLock.RLock()
+	hook := printLogHook
+	printLogHookLock.RUnlock()
+	if hook != nil {
+		hook(now, logToPrint)
+	}
+}
+
+// Function called with each message printed by PrintLog, if any
+var printLogHook func(logTime time.Time, msg string)
+var printLogHookLock sync.RWMutex
+
+/**
+* Set a function to be called with each message printed by PrintLog.
+* @param hook - Function to call, or nil to remove the current one.
+ */
+func SetPrintLogHook(hook func(logTime time.Time, msg string)) {
+	printLogHookLock.Lock()
+	defer printLogHookLock.Unlock()
+	printLogHook = hook
 }
 
 /**